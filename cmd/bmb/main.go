@@ -0,0 +1,217 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Main (bmb)
+ * Component: Command line interface
+ *
+ * This component provides a command line interface to the BIG Modelling Bus, allowing artefacts to be posted
+ * and fetched from scripts, or while debugging, without having to write any Go code. For example:
+ *
+ *   bmb post-json --config cfg.ini --artefact ID --json-version 1.0 --file model.json
+ *   bmb get-json --config cfg.ini --artefact ID --json-version 1.0 --agent A
+ *   bmb delete-env --config cfg.ini --yes
+ *
+ * Fetched content is printed on the standard output, while progress and errors are reported on the standard error.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/connect"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Reporting
+ */
+
+// Reporting progress on the standard error, so the standard output only contains fetched content
+func reportProgress(message string) {
+	fmt.Fprintln(os.Stderr, "PROGRESS:", message)
+}
+
+// Reporting errors on the standard error
+func reportError(message string) {
+	fmt.Fprintln(os.Stderr, "ERROR:", message)
+}
+
+// Show the usage of the command
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: bmb <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  post-json   Post the state of a JSON artefact")
+	fmt.Fprintln(os.Stderr, "  get-json    Fetch the state, update, or considering of a JSON artefact")
+	fmt.Fprintln(os.Stderr, "  delete-env  Delete a modelling environment from the bus")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Use \"bmb <command> --help\" for the flags of a command.")
+}
+
+/*
+ * Commands
+ */
+
+// Post the state of a JSON artefact, as read from a file
+func postJSON(arguments []string) int {
+	flags := flag.NewFlagSet("post-json", flag.ExitOnError)
+	configFile := flags.String("config", "config.ini", "The config file to use")
+	artefactID := flags.String("artefact", "", "The ID of the artefact to post")
+	jsonVersion := flags.String("json-version", "", "The JSON version of the artefact (required)")
+	file := flags.String("file", "", "The file holding the JSON state of the artefact")
+	level := flags.Int("progress", generics.ProgressLevelBasic, "The level of progress reporting")
+	flags.Parse(arguments)
+
+	// Check the flags
+	reporter := generics.CreateReporter(*level, reportError, reportProgress)
+	if reporter.MaybeReportEmptyFlagError(artefactID, "Missing artefact ID.") ||
+		reporter.MaybeReportEmptyFlagError(jsonVersion, "Missing JSON version.") ||
+		reporter.MaybeReportEmptyFlagError(file, "Missing file.") {
+		return 2
+	}
+
+	// Read the JSON state
+	stateJSON, err := os.ReadFile(*file)
+	if reporter.MaybeReportError("Error reading the JSON file:", err) {
+		return 1
+	}
+
+	// Connect to the bus
+	configData := generics.LoadConfig(*configFile, reporter)
+	modellingBusConnector := connect.CreateModellingBusConnector(configData, reporter, true)
+	artefactConnector := connect.CreateModellingBusArtefactConnector(modellingBusConnector, *jsonVersion, *artefactID)
+	defer artefactConnector.Close()
+
+	// Post the JSON state
+	if reporter.MaybeReportError("Error posting the JSON state:", artefactConnector.PostJSONArtefactStateE(stateJSON, true)) {
+		return 1
+	}
+
+	return 0
+}
+
+// Fetch the state, update, or considering of a JSON artefact, and print it
+func getJSON(arguments []string) int {
+	flags := flag.NewFlagSet("get-json", flag.ExitOnError)
+	configFile := flags.String("config", "config.ini", "The config file to use")
+	artefactID := flags.String("artefact", "", "The ID of the artefact to fetch")
+	jsonVersion := flags.String("json-version", "", "The JSON version of the artefact (required)")
+	agentID := flags.String("agent", "", "The ID of the agent that posted the artefact")
+	kind := flags.String("kind", "state", "What to fetch: state, update, or considering")
+	level := flags.Int("progress", generics.ProgressLevelBasic, "The level of progress reporting")
+	flags.Parse(arguments)
+
+	// Check the flags
+	reporter := generics.CreateReporter(*level, reportError, reportProgress)
+	if reporter.MaybeReportEmptyFlagError(artefactID, "Missing artefact ID.") ||
+		reporter.MaybeReportEmptyFlagError(jsonVersion, "Missing JSON version.") ||
+		reporter.MaybeReportEmptyFlagError(agentID, "Missing agent ID.") {
+		return 2
+	}
+
+	// Connect to the bus
+	configData := generics.LoadConfig(*configFile, reporter)
+	modellingBusConnector := connect.CreateModellingBusConnector(configData, reporter, false)
+	artefactConnector := connect.CreateModellingBusArtefactConnector(modellingBusConnector, *jsonVersion, *artefactID)
+	defer artefactConnector.Close()
+
+	// Fetch the requested content
+	var content []byte
+	switch *kind {
+	case "state":
+		artefactConnector.GetJSONArtefactState(*agentID, *artefactID)
+		content = artefactConnector.CurrentContent
+
+	case "update":
+		artefactConnector.GetJSONArtefactUpdate(*agentID, *artefactID)
+		content = artefactConnector.UpdatedContent
+
+	case "considering":
+		artefactConnector.GetJSONArtefactConsidering(*agentID, *artefactID)
+		content = artefactConnector.ConsideredContent
+
+	default:
+		reporter.Error("Unknown kind: %s", *kind)
+		return 2
+	}
+
+	// Nothing was found
+	if len(content) == 0 {
+		reporter.Error("No %s found for artefact %s of agent %s.", *kind, *artefactID, *agentID)
+		return 1
+	}
+
+	// Print the content
+	fmt.Println(string(content))
+
+	return 0
+}
+
+// Delete a modelling environment
+func deleteEnvironment(arguments []string) int {
+	flags := flag.NewFlagSet("delete-env", flag.ExitOnError)
+	configFile := flags.String("config", "config.ini", "The config file to use")
+	environmentID := flags.String("environment", "", "The environment to delete (defaults to the one in the config file)")
+	confirmed := flags.Bool("yes", false, "Confirm that the environment, including the postings of all agents in it, should be deleted")
+	level := flags.Int("progress", generics.ProgressLevelBasic, "The level of progress reporting")
+	flags.Parse(arguments)
+
+	// Deleting an environment cannot be undone, so it needs to be confirmed
+	reporter := generics.CreateReporter(*level, reportError, reportProgress)
+	if !*confirmed {
+		reporter.Error("Deleting an environment cannot be undone. Use --yes to confirm.")
+		return 2
+	}
+
+	// Connect to the bus
+	configData := generics.LoadConfig(*configFile, reporter)
+	modellingBusConnector := connect.CreateModellingBusConnector(configData, reporter, true)
+	defer modellingBusConnector.Close()
+
+	// Delete the environment
+	environment := []string{}
+	if *environmentID != "" {
+		environment = append(environment, *environmentID)
+	}
+	if reporter.MaybeReportError("Error deleting the environment:", modellingBusConnector.DeleteEnvironmentE(environment...)) {
+		return 1
+	}
+
+	return 0
+}
+
+/*
+ * Main
+ */
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	// Run the command
+	arguments := os.Args[2:]
+	switch os.Args[1] {
+	case "post-json":
+		os.Exit(postJSON(arguments))
+
+	case "get-json":
+		os.Exit(getJSON(arguments))
+
+	case "delete-env":
+		os.Exit(deleteEnvironment(arguments))
+
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,257 @@
+package connect
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Test helpers
+ */
+
+// A reporter collecting the reported errors, so tests can check them
+type tTestReporter struct {
+	*generics.TReporter
+
+	errors      []string
+	errorsMutex sync.Mutex
+}
+
+// Get the errors reported so far
+func (r *tTestReporter) reportedErrors() []string {
+	r.errorsMutex.Lock()
+	defer r.errorsMutex.Unlock()
+
+	return append([]string{}, r.errors...)
+}
+
+// Create a reporter collecting the reported errors, and ignoring progress
+func createTestReporter() *tTestReporter {
+	r := tTestReporter{}
+	r.TReporter = generics.CreateReporter(generics.ProgressLevelBasic, func(message string) {
+		r.errorsMutex.Lock()
+		defer r.errorsMutex.Unlock()
+
+		r.errors = append(r.errors, message)
+	}, func(string) {})
+
+	return &r
+}
+
+// Get the address of a local port on which nothing listens, so connecting to it fails right away
+func unusedLocalPort(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening on a local port: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	return port
+}
+
+// Load a config file with the given content, with the work folder set to a fresh temporary folder
+func loadTestConfig(t *testing.T, reporter *generics.TReporter, content ...string) (*generics.TConfigData, string) {
+	t.Helper()
+
+	workFolder := t.TempDir()
+	configFilePath := filepath.Join(t.TempDir(), "config.ini")
+	configContent := "work_folder = " + workFolder + "\n" + strings.Join(content, "\n") + "\n"
+	if err := os.WriteFile(configFilePath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("writing the config file: %v", err)
+	}
+
+	return generics.LoadConfig(configFilePath, reporter), workFolder
+}
+
+// Create a repository connector for an FTP server that cannot be reached
+func createUnreachableRepositoryConnector(t *testing.T, reporter *generics.TReporter, content ...string) (*tModellingBusRepositoryConnector, string) {
+	t.Helper()
+
+	content = append([]string{
+		"[ftp]",
+		"server = 127.0.0.1",
+		"port = " + unusedLocalPort(t),
+		"password = secret",
+	}, content...)
+	configData, workFolder := loadTestConfig(t, reporter, content...)
+
+	return createModellingBusRepositoryConnector("environment", "agent", configData, reporter), workFolder
+}
+
+// A clock that always gives the same time
+type tFixedClock struct {
+	now time.Time
+}
+
+func (c tFixedClock) Now() time.Time {
+	return c.now
+}
+
+// Create a modelling bus connector for agent "agent" in environment "environment", which is not connected to an
+// MQTT broker, and whose FTP server cannot be reached. Messages can be put on its events connector using storeMessage,
+// as if they were received from the MQTT broker.
+func createOfflineModellingBusConnector(t *testing.T, reporter *generics.TReporter, content ...string) TModellingBusConnector {
+	t.Helper()
+
+	content = append([]string{
+		"environment = environment",
+		"agent = agent",
+		"[ftp]",
+		"server = 127.0.0.1",
+		"port = " + unusedLocalPort(t),
+		"password = secret",
+	}, content...)
+	configData, _ := loadTestConfig(t, reporter, content...)
+
+	// The events connector, without a connection to an MQTT broker
+	e := tModellingBusEventsConnector{}
+	e.agentID = "agent"
+	e.environmentID = "environment"
+	e.prefix = "prefix"
+	e.maxPayloadBytes = defaultMaxPayloadBytes
+	e.currentMessages = map[string][]byte{}
+	e.openingMessages = map[string][]byte{}
+	e.messageWaiters = map[string][]chan []byte{}
+	e.subscribedTopics = map[string]bool{}
+	e.reporter = reporter
+
+	b := TModellingBusConnector{}
+	b.agentID = "agent"
+	b.environmentID = "environment"
+	b.configData = configData
+	b.Reporter = reporter
+	b.modellingBusEventsConnector = &e
+	b.modellingBusRepositoryConnector = createModellingBusRepositoryConnector(b.environmentID, b.agentID, configData, reporter)
+
+	return b
+}
+
+// Put a message on the given topic path of the given agent, as if it was received from the MQTT broker
+func storeTestMessage(b TModellingBusConnector, agentID, topicPath, message string) {
+	b.modellingBusEventsConnector.storeMessage(b.modellingBusEventsConnector.mqttAgentTopicPath(agentID, topicPath), []byte(message))
+}
+
+// An in-memory MQTT broker and client, which delivers published messages right away to the matching subscriptions.
+// Only the methods used by the events connector are implemented.
+type tFakeMQTTClient struct {
+	mqtt.Client
+
+	subscriptions   map[string]mqtt.MessageHandler
+	publishedTopics []string // The topics published on, in order
+	mutex           sync.Mutex
+}
+
+// A token of a completed MQTT operation
+type tFakeMQTTToken struct {
+	mqtt.Token
+}
+
+func (t tFakeMQTTToken) Wait() bool                     { return true }
+func (t tFakeMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t tFakeMQTTToken) Error() error                   { return nil }
+
+// A message delivered by the fake MQTT broker
+type tFakeMQTTMessage struct {
+	mqtt.Message
+
+	topic   string
+	payload []byte
+}
+
+func (m tFakeMQTTMessage) Topic() string   { return m.topic }
+func (m tFakeMQTTMessage) Payload() []byte { return m.payload }
+
+func (c *tFakeMQTTClient) IsConnected() bool { return true }
+
+func (c *tFakeMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	// Get the handlers of the matching subscriptions, and call them without holding the mutex
+	c.mutex.Lock()
+	c.publishedTopics = append(c.publishedTopics, topic)
+	handlers := []mqtt.MessageHandler{}
+	for filter, handler := range c.subscriptions {
+		if fakeMQTTTopicMatches(filter, topic) {
+			handlers = append(handlers, handler)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(c, tFakeMQTTMessage{topic: topic, payload: []byte(fmt.Sprint(payload))})
+	}
+
+	return tFakeMQTTToken{}
+}
+
+func (c *tFakeMQTTClient) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.subscriptions[topic] = callback
+
+	return tFakeMQTTToken{}
+}
+
+func (c *tFakeMQTTClient) Unsubscribe(topics ...string) mqtt.Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, topic := range topics {
+		delete(c.subscriptions, topic)
+	}
+
+	return tFakeMQTTToken{}
+}
+
+// Get the topics published on so far
+func (c *tFakeMQTTClient) published() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return slices.Clone(c.publishedTopics)
+}
+
+// Lose the connection, upon which the broker forgets the subscriptions, as the sessions are clean
+func (c *tFakeMQTTClient) loseConnection() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.subscriptions = map[string]mqtt.MessageHandler{}
+}
+
+// Check whether a topic matches a topic filter, with the "+" and "#" wildcards
+func fakeMQTTTopicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, filterLevel := range filterLevels {
+		if filterLevel == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || filterLevel != "+" && filterLevel != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+// Connect the events connector of a modelling bus connector to a fresh fake MQTT broker
+func connectToFakeMQTTBroker(b TModellingBusConnector) *tFakeMQTTClient {
+	client := &tFakeMQTTClient{subscriptions: map[string]mqtt.MessageHandler{}}
+	b.modellingBusEventsConnector.client = client
+	b.modellingBusEventsConnector.connectHandler(client)
+
+	return client
+}
@@ -10,14 +10,24 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 15.10.2026
  *
  */
 
 package connect
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -28,6 +38,9 @@ import (
  * Defining the events connector
  */
 
+// Creating an MQTT client from its options. Replacing it makes it possible to, e.g., use an in-process broker while testing.
+var NewMQTTClient = mqtt.NewClient
+
 type (
 	tModellingBusEventsConnector struct {
 		user          string // MQTT user
@@ -37,9 +50,30 @@ type (
 		agentID       string // Agent ID to be used in postings on the MQTT bus
 		password      string // MQTT password
 		environmentID string // Modelling environment ID
+		namespace     string // Namespace within the modelling environment (empty means none)
+		clientID      string // MQTT client ID, which is kept across reconnects
 
 		loadDelay int // Delay (in milliseconds) to allow messages to arrive from the MQTT bus
 
+		connectRetryDelay        time.Duration // Time to wait before the first retry of connecting to the MQTT broker
+		connectRetryMaximumDelay time.Duration // Maximum time to wait between retries of connecting to the MQTT broker
+
+		autoReconnect     bool        // Whether to automatically reconnect when the connection to the MQTT broker is lost
+		collectingTopics  bool        // Whether we collect all messages on the modelling environment
+		hasConnected      atomic.Bool // Whether we connected to the MQTT broker before, so later connects are reconnects
+		reconnectHandlers []func()    // Those to be called after a reconnect, e.g. to re-establish their subscriptions
+
+		publishQoS   byte // The MQTT quality of service level used when publishing
+		subscribeQoS byte // The MQTT quality of service level used when subscribing
+		retained     bool // Whether published messages are retained by the MQTT broker
+
+		maxInlineBytes  int // The maximum size of JSON deltas to be posted inline in the event, rather than as a file (0 means never)
+		maxPayloadBytes int // The maximum size of payloads that fit in an MQTT message, as accepted by the MQTT broker
+
+		useTLS             bool   // Whether to connect to the MQTT broker using TLS
+		caFile             string // File with the CA certificate(s) to verify the MQTT broker (empty means the system roots)
+		insecureSkipVerify bool   // Whether to skip verification of the MQTT broker's certificate (for development only)
+
 		connectionBeingOpenened bool // Whether the MQTT connection is still being opened.
 		// The opening phase is special, as we need to collect all existing messages on the bus. CHECK!!!
 
@@ -47,35 +81,132 @@ type (
 		openingMessages map[string][]byte // Messages known at the opening of the connection to the MQTT bus
 		// We need this to enable deletion of topics, as well as to be able to pro-actively
 		// pull information from the modelling bus
+		messageWaiters   map[string][]chan []byte // Those waiting for the next message on a topic
+		messageObservers []func(string, []byte)   // Those observing all messages received on the modelling environment
+		messagesMutex    sync.Mutex               // Guards the known messages, waiters, and observers, as they are updated while receiving messages
 
 		client mqtt.Client // The MQTT client
 
+		ignoreOwnPostings atomic.Bool // Whether to ignore postings by our own agent when listening for events
+
+		subscribedTopics   map[string]bool // The topics we subscribed to
+		disconnected       bool            // Whether we disconnected from the MQTT broker
+		subscriptionsMutex sync.Mutex      // Guards the subscribed topics, the disconnected flag, and the reconnect handlers
+
 		reporter *generics.TReporter // The Reporter to be used to report progress, errors, and panics
 	}
 )
 
+/*
+ * Defining constants
+ */
+
+const (
+	disconnectQuiesce = 250 // Time (in milliseconds) to allow in-flight work to complete when disconnecting
+
+	defaultMaxPayloadBytes = 128 * 1024 // The default maximum size of payloads that fit in an MQTT message, which most MQTT brokers accept
+
+	defaultConnectRetryDelay        = 5  // The default time (in seconds) to wait before the first retry of connecting to the MQTT broker
+	defaultConnectRetryMaximumDelay = 60 // The default maximum time (in seconds) to wait between retries of connecting to the MQTT broker
+
+	presencePathElement = "presence" // Presence path element, underneath the topic root of an agent
+)
+
+/*
+ * Defining presence
+ */
+
+type (
+	// The presence status of an agent, as retained on its presence topic
+	tPresence struct {
+		AgentID string `json:"agent id"` // The agent
+		Status  string `json:"status"`   // The presence status of the agent (PresenceOnline or PresenceOffline)
+	}
+)
+
 /*
  * Defining topic roots and paths
  */
 
-// Get the topic root for the given modelling environment
+// Get the topic root for our modelling environment, within our namespace (if any)
 func (e *tModellingBusEventsConnector) mqttEnvironmentTopicRoot() string {
-	return e.prefix + "/" + generics.ModellingBusVersion + "/" + e.environmentID
+	return e.mqttNamespaceTopicRootFor(e.environmentID, e.namespace)
+}
+
+// Get the topic root for the given modelling environment and namespace (if any)
+func (e *tModellingBusEventsConnector) mqttNamespaceTopicRootFor(environmentID, namespace string) string {
+	if namespace == "" {
+		return e.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID
+	}
+
+	return e.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID + "/" + namespace
 }
 
-// Get the topic list for the given modelling environment
+// Get the topic list for the given modelling environment, within our namespace (if any)
 func (e *tModellingBusEventsConnector) mqttEnvironmentTopicListFor(environmentID string) string {
-	return e.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID + "/#"
+	return e.mqttNamespaceTopicRootFor(environmentID, e.namespace) + "/#"
 }
 
-// Get the topic root for the given modelling environment and agent
+// Get the topic root for the given modelling environment and agent, within our namespace (if any)
 func (e *tModellingBusEventsConnector) mqttAgentTopicRootFor(environmentID, agentID string) string {
-	return e.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID + "/" + agentID
+	return e.mqttAgentTopicRootIn(environmentID, e.namespace, agentID)
+}
+
+// Get the topic root for the given modelling environment, namespace (if any), and agent
+func (e *tModellingBusEventsConnector) mqttAgentTopicRootIn(environmentID, namespace, agentID string) string {
+	return e.mqttNamespaceTopicRootFor(environmentID, namespace) + "/" + agentID
 }
 
 // Get the topic path for the given agent and topic path
 func (e *tModellingBusEventsConnector) mqttAgentTopicPath(agentID, topicPath string) string {
-	return e.prefix + "/" + generics.ModellingBusVersion + "/" + e.environmentID + "/" + agentID + "/" + topicPath
+	return e.mqttAgentTopicPathIn(e.environmentID, agentID, topicPath)
+}
+
+// Get the topic path for the given modelling environment, agent, and topic path
+func (e *tModellingBusEventsConnector) mqttAgentTopicPathIn(environmentID, agentID, topicPath string) string {
+	return e.mqttAgentTopicRootFor(environmentID, agentID) + "/" + topicPath
+}
+
+// Get the agent that posted on the given topic of our modelling environment, as well as the topic path it posted on
+func (e *tModellingBusEventsConnector) agentTopicPathOf(topic string) (string, string, bool) {
+	// Strip the topic root of the modelling environment
+	agentTopicPath, isEnvironmentTopic := strings.CutPrefix(topic, e.mqttEnvironmentTopicRoot()+"/")
+	if !isEnvironmentTopic {
+		return "", "", false
+	}
+
+	// The agent is the first element of what remains, the topic path the rest
+	return strings.Cut(agentTopicPath, "/")
+}
+
+/*
+ * Signalling presence
+ */
+
+// Get the presence message of our agent, for the given status
+func (e *tModellingBusEventsConnector) presenceMessage(status string) []byte {
+	presence := tPresence{}
+	presence.AgentID = e.agentID
+	presence.Status = status
+
+	message, _ := json.Marshal(presence)
+
+	return message
+}
+
+// Publish the presence status of our agent, retaining it so agents that join later learn about it
+func (e *tModellingBusEventsConnector) publishPresence(status string) {
+	e.publish(e.mqttAgentTopicPath(e.agentID, presencePathElement), e.presenceMessage(status), true)
+}
+
+// Listen for the presence status of the agents in a given modelling environment
+func (e *tModellingBusEventsConnector) listenForPresenceIn(environmentID string, presenceHandler func(string, string)) {
+	e.listenForEventsIn(environmentID, "+", presencePathElement, func(message []byte) {
+		presence := tPresence{}
+		if json.Unmarshal(message, &presence) == nil && presence.AgentID != "" {
+			presenceHandler(presence.AgentID, presence.Status)
+		}
+	})
 }
 
 /*
@@ -84,7 +215,46 @@ func (e *tModellingBusEventsConnector) mqttAgentTopicPath(agentID, topicPath str
 
 // Connection lost handler
 func (e *tModellingBusEventsConnector) connectionLostHandler(c mqtt.Client, err error) {
-	e.reporter.PanicError("MQTT connection lost.", err)
+	if !e.autoReconnect {
+		e.reporter.PanicError("MQTT connection lost.", err)
+	}
+
+	e.reporter.ReportError("MQTT connection lost. Reconnecting:", err)
+}
+
+// Connect handler, signalling we are online, and re-establishing the subscriptions after a reconnect.
+// As the MQTT sessions are clean, the broker forgets our subscriptions when the connection is lost.
+func (e *tModellingBusEventsConnector) connectHandler(c mqtt.Client) {
+	// Let the others know we are online
+	e.publishPresence(PresenceOnline)
+
+	// The first connect is not a reconnect
+	if !e.hasConnected.Swap(true) {
+		return
+	}
+
+	e.reporter.Progress(generics.ProgressLevelBasic, "Reconnected to the MQTT broker.")
+
+	// Resume collecting the messages on the modelling environment
+	if e.collectingTopics {
+		e.collectTopicsForModellingEnvironment(e.environmentID)
+	}
+
+	// Let the others re-establish their subscriptions
+	e.subscriptionsMutex.Lock()
+	reconnectHandlers := slices.Clone(e.reconnectHandlers)
+	e.subscriptionsMutex.Unlock()
+	for _, reconnectHandler := range reconnectHandlers {
+		reconnectHandler()
+	}
+}
+
+// Register a handler to be called after a reconnect
+func (e *tModellingBusEventsConnector) onReconnect(reconnectHandler func()) {
+	e.subscriptionsMutex.Lock()
+	defer e.subscriptionsMutex.Unlock()
+
+	e.reconnectHandlers = append(e.reconnectHandlers, reconnectHandler)
 }
 
 // Wait for a while to allow messages to arrive from the MQTT bus
@@ -96,54 +266,182 @@ func (e *tModellingBusEventsConnector) waitForMQTT() {
 	time.Sleep(time.Duration(e.loadDelay) * time.Second / 1000)
 }
 
+// Store a received message
+func (e *tModellingBusEventsConnector) storeMessage(topic string, payload []byte) {
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	// Store the topic and payload
+	if len(payload) == 0 {
+		// If the payload is empty, the topic has been deleted
+		delete(e.openingMessages, topic)
+		delete(e.currentMessages, topic)
+	} else {
+		// Otherwise, store the message
+		if e.connectionBeingOpenened {
+			// During opening, we need to store both opening and current messages
+			e.openingMessages[topic] = payload
+			e.currentMessages[topic] = payload
+		} else {
+			// After opening, we only need to store current messages
+			if _, defined := e.openingMessages[topic]; !defined {
+				// If not yet defined, define the openingMessage fot this topic with empty payload
+				e.openingMessages[topic] = []byte{}
+			}
+			e.currentMessages[topic] = payload
+		}
+
+		// Hand the message to those waiting for it
+		for _, messageWaiter := range e.messageWaiters[topic] {
+			messageWaiter <- payload
+		}
+		delete(e.messageWaiters, topic)
+	}
+}
+
+// Start observing all messages received on the modelling environment, returning the messages known so far.
+// This relies on the messages being collected, so it only works when the connector is not posting only.
+func (e *tModellingBusEventsConnector) observeMessages(observer func(string, []byte)) map[string][]byte {
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	// Register the observer
+	e.messageObservers = append(e.messageObservers, observer)
+
+	// Return the messages known so far, so the observer does not miss any message
+	knownMessages := map[string][]byte{}
+	for topic, payload := range e.currentMessages {
+		knownMessages[topic] = payload
+	}
+
+	return knownMessages
+}
+
+// Notify the observers of a received message
+func (e *tModellingBusEventsConnector) notifyMessageObservers(topic string, payload []byte) {
+	// Take the observers, so they are not called while holding the lock
+	e.messagesMutex.Lock()
+	messageObservers := e.messageObservers
+	e.messagesMutex.Unlock()
+
+	// Notify the observers
+	for _, messageObserver := range messageObservers {
+		messageObserver(topic, payload)
+	}
+}
+
+// Get the current message for a given topic
+func (e *tModellingBusEventsConnector) currentMessage(topic string) []byte {
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	return e.currentMessages[topic]
+}
+
+// Await a message on a given topic, returning the current one if there is one already.
+// This relies on the messages being collected, so it only works when the connector is not posting only.
+func (e *tModellingBusEventsConnector) awaitMessage(agentID, topicPath string, timeout time.Duration) ([]byte, bool) {
+	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
+
+	// Return the current message, if any, or start waiting for one
+	e.messagesMutex.Lock()
+	if message := e.currentMessages[mqttTopicPath]; len(message) > 0 {
+		e.messagesMutex.Unlock()
+
+		return message, true
+	}
+	messageWaiter := make(chan []byte, 1)
+	e.messageWaiters[mqttTopicPath] = append(e.messageWaiters[mqttTopicPath], messageWaiter)
+	e.messagesMutex.Unlock()
+
+	// Wait for the message, or the timeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-messageWaiter:
+		return message, true
+
+	case <-timer.C:
+		// Stop waiting
+		e.messagesMutex.Lock()
+		defer e.messagesMutex.Unlock()
+
+		messageWaiters := e.messageWaiters[mqttTopicPath]
+		for i, waiter := range messageWaiters {
+			if waiter == messageWaiter {
+				e.messageWaiters[mqttTopicPath] = append(messageWaiters[:i], messageWaiters[i+1:]...)
+				break
+			}
+		}
+
+		// The message may have arrived just in time
+		select {
+		case message := <-messageWaiter:
+			return message, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// Get the opening message for a given topic
+func (e *tModellingBusEventsConnector) openingMessage(topic string) []byte {
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	return e.openingMessages[topic]
+}
+
+// Get the known topics that start with the given prefix
+func (e *tModellingBusEventsConnector) knownTopicsWithPrefix(prefix string) []string {
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	topics := []string{}
+	for topic := range e.openingMessages {
+		if strings.HasPrefix(topic, prefix) {
+			topics = append(topics, topic)
+		}
+	}
+
+	return topics
+}
+
 // Report found topics
 func (e *tModellingBusEventsConnector) reportFoundTopics() {
 	// Report found topics
-	if len(e.openingMessages) == 0 {
+	topics := e.knownTopicsWithPrefix(e.mqttEnvironmentTopicRoot())
+	if len(topics) == 0 {
 		// No topics found
 		e.reporter.Progress(generics.ProgressLevelDetailed, "No topics found.")
 	} else {
 		// Topics found, so let's list them
 		e.reporter.Progress(generics.ProgressLevelDetailed, "Found topic(s):")
-		for topic := range e.openingMessages {
-			if strings.HasPrefix(topic, e.mqttEnvironmentTopicRoot()) {
-				e.reporter.Progress(generics.ProgressLevelDetailed, "- %s", topic)
-			}
+		for _, topic := range topics {
+			e.reporter.Progress(generics.ProgressLevelDetailed, "- %s", topic)
 		}
 	}
 }
 
 // Collect all MQTT topics for a given modelling environment
 func (e *tModellingBusEventsConnector) collectTopicsForModellingEnvironment(environmentID string) {
-	token := e.client.Subscribe(e.mqttEnvironmentTopicListFor(environmentID), 0, func(client mqtt.Client, msg mqtt.Message) {
-		// Get topic and payload
-		topic := msg.Topic()
-		payload := msg.Payload()
+	e.collectTopics(e.mqttEnvironmentTopicListFor(environmentID))
+}
 
+// Collect all MQTT topics in a given topic list
+func (e *tModellingBusEventsConnector) collectTopics(topicList string) {
+	token := e.client.Subscribe(topicList, e.subscribeQoS, func(client mqtt.Client, msg mqtt.Message) {
 		// Store the topic and payload
-		if len(payload) == 0 {
-			// If the payload is empty, the topic has been deleted
-			delete(e.openingMessages, topic)
-			delete(e.currentMessages, topic)
-		} else {
-			// Otherwise, store the message
-			if e.connectionBeingOpenened {
-				// During opening, we need to store both opening and current messages
-				e.openingMessages[topic] = payload
-				e.currentMessages[topic] = payload
-			} else {
-				// After opening, we only need to store current messages
-				if _, defined := e.openingMessages[topic]; !defined {
-					// If not yet defined, define the openingMessage fot this topic with empty payload
-					e.openingMessages[topic] = []byte{}
-				}
-				e.currentMessages[topic] = payload
-			}
-		}
+		e.storeMessage(msg.Topic(), msg.Payload())
+
+		// Let the observers know about it
+		e.notifyMessageObservers(msg.Topic(), msg.Payload())
 	})
 
 	// Wait for the subscription to be in place
 	token.Wait()
+	e.registerSubscription(topicList)
 
 	// Wait for a while to allow messages to arrive from the MQTT bus
 	e.waitForMQTT()
@@ -152,23 +450,57 @@ func (e *tModellingBusEventsConnector) collectTopicsForModellingEnvironment(envi
 	e.reportFoundTopics()
 }
 
+// Get the TLS configuration for the connection to the MQTT broker
+func (e *tModellingBusEventsConnector) tlsConfig() *tls.Config {
+	tlsConfig := tls.Config{}
+	tlsConfig.InsecureSkipVerify = e.insecureSkipVerify
+
+	// Without a CA file, the system roots are used
+	if e.caFile != "" {
+		caCertificates, err := os.ReadFile(e.caFile)
+		if err != nil {
+			e.reporter.PanicError("Failed to read the MQTT CA file.", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCertificates) {
+			e.reporter.Panic("No certificates found in the MQTT CA file: %s.", e.caFile)
+		}
+	}
+
+	return &tlsConfig
+}
+
 // Connect to the MQTT broker
 func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
 	// Setting up MQTT connection options
+	// As the client is created from these options, also the automatic reconnects will use them
 	opts := mqtt.NewClientOptions()
-	opts.AddBroker("tcp://" + e.broker + ":" + e.port)
+	if e.useTLS {
+		opts.AddBroker("ssl://" + e.broker + ":" + e.port)
+		opts.SetTLSConfig(e.tlsConfig())
+	} else {
+		opts.AddBroker("tcp://" + e.broker + ":" + e.port)
+	}
+	opts.SetClientID(e.clientID)
 	opts.SetUsername(e.user)
 	opts.SetPassword(e.password)
 	opts.SetConnectionLostHandler(e.connectionLostHandler)
+	opts.SetOnConnectHandler(e.connectHandler)
+	opts.SetAutoReconnect(e.autoReconnect)
 
-	// Connecting to the MQTT broker
+	// Have the broker signal we are offline when we lose the connection without disconnecting
+	opts.SetWill(e.mqttAgentTopicPath(e.agentID, presencePathElement), string(e.presenceMessage(PresenceOffline)), e.publishQoS, true)
+
+	// Connecting to the MQTT broker, backing off between retries
+	backoff := generics.CreateBackoff(e.connectRetryDelay, e.connectRetryMaximumDelay)
 	connected := false
 	for !connected {
 		// Trying to connect
 		e.reporter.Progress(generics.ProgressLevelBasic, "Trying to connect to the MQTT broker.")
 
 		// Creating the MQTT client
-		e.client = mqtt.NewClient(opts)
+		e.client = NewMQTTClient(opts)
 		token := e.client.Connect()
 		token.Wait()
 
@@ -177,7 +509,9 @@ func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
 		if err != nil {
 			e.reporter.ReportError("Error connecting to the MQTT broker:", err)
 
-			time.Sleep(5 * time.Second)
+			delay := backoff.Next()
+			e.reporter.Progress(generics.ProgressLevelBasic, "Retrying to connect to the MQTT broker in %s.", delay.Round(time.Second))
+			time.Sleep(delay)
 		} else {
 			connected = true
 		}
@@ -194,11 +528,14 @@ func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
 			// topic root, and their messages.
 			// We need this information to enable deletion of topics, as well as to be able to
 			// pro-actively pull information from the modelling bus
+			e.collectingTopics = true
 			e.collectTopicsForModellingEnvironment(e.environmentID)
 		}
 
 		// Mark the opening phase as finished
+		e.messagesMutex.Lock()
 		e.connectionBeingOpenened = false
+		e.messagesMutex.Unlock()
 	}
 }
 
@@ -206,28 +543,63 @@ func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
  *  Posting things
  */
 
+// Publish a message on a given topic path
+func (e *tModellingBusEventsConnector) publish(topicPath string, message []byte, retained bool) error {
+	// Publishing the message
+	token := e.client.Publish(topicPath, e.publishQoS, retained, string(message))
+	token.Wait()
+
+	// Handle potential errors
+	if err := token.Error(); err != nil {
+		e.reporter.ReportError("Error publishing on the MQTT broker:", err)
+		return fmt.Errorf("%w: %w", ErrMQTTPublish, err)
+	}
+
+	return nil
+}
+
 // Post a message on a given topic path
-func (e *tModellingBusEventsConnector) postMessage(topicPath string, message []byte) {
+func (e *tModellingBusEventsConnector) postMessage(topicPath string, message []byte) error {
 	// Posting the message
-	token := e.client.Publish(topicPath, 0, true, string(message))
-	token.Wait()
+	return e.publish(topicPath, message, e.retained)
 }
 
 // Post an event on a given topic path
-func (e *tModellingBusEventsConnector) postEvent(topicPath string, message []byte) {
+func (e *tModellingBusEventsConnector) postEvent(topicPath string, message []byte) error {
+	return e.postEventIn(e.environmentID, e.agentID, topicPath, message)
+}
+
+// Post an event on a given topic path, in a given modelling environment on behalf of the given agent
+func (e *tModellingBusEventsConnector) postEventIn(environmentID, agentID, topicPath string, message []byte) error {
 	// Posting the event message
-	e.postMessage(e.mqttAgentTopicPath(e.agentID, topicPath), message)
+	return e.postMessage(e.mqttAgentTopicPathIn(environmentID, agentID, topicPath), message)
+}
+
+// Check whether a payload of the given size may be posted inline in an event
+func (e *tModellingBusEventsConnector) allowsInline(size int) bool {
+	return size > 0 && size <= e.maxInlineBytes && e.fitsInline(size)
+}
+
+// Check whether a payload of the given size fits in an MQTT message
+func (e *tModellingBusEventsConnector) fitsInline(size int) bool {
+	return size <= e.maxPayloadBytes
+}
+
+// Post a transient (i.e. not retained) event on a given topic path, in a given modelling environment
+func (e *tModellingBusEventsConnector) postTransientEventIn(environmentID, topicPath string, message []byte) error {
+	return e.publish(e.mqttAgentTopicPathIn(environmentID, e.agentID, topicPath), message, false)
 }
 
-// Post an event on a given topic path, when there was no error
-func (e *tModellingBusEventsConnector) maybePostEvent(topicPath string, eventMessage []byte, errorMessage string, err error) {
+// Post an event on a given topic path, in a given modelling environment on behalf of the given agent,
+// when there was no error (in marshalling the event)
+func (e *tModellingBusEventsConnector) maybePostEventIn(environmentID, agentID, topicPath string, eventMessage []byte, errorMessage string, err error) error {
 	// Handle potential errors
 	if e.reporter.MaybeReportError(errorMessage, err) {
-		return
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
 	}
 
 	// Post the event message
-	e.postEvent(topicPath, eventMessage)
+	return e.postEventIn(environmentID, agentID, topicPath, eventMessage)
 }
 
 /*
@@ -240,13 +612,13 @@ func (e *tModellingBusEventsConnector) messageFromEvent(agentID, topicPath strin
 	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
 
 	// Getting the message
-	message := e.currentMessages[mqttTopicPath]
+	message := e.currentMessage(mqttTopicPath)
 
 	// When messageFromEvent is called too soon after opening the connection to the MQTT broker,
 	// we may not have received a message yet. So, we need to be "waitForMQTT" patient.
 	if len(message) == 0 {
 		e.waitForMQTT()
-		message = e.currentMessages[mqttTopicPath]
+		message = e.currentMessage(mqttTopicPath)
 	}
 
 	return message
@@ -258,22 +630,117 @@ func (e *tModellingBusEventsConnector) messageFromEvent(agentID, topicPath strin
 
 // Listen for events on a given topic path for a given agent
 func (e *tModellingBusEventsConnector) listenForEvents(agentID, topicPath string, eventHandler func([]byte)) {
+	e.listenForEventsIn(e.environmentID, agentID, topicPath, eventHandler)
+}
+
+// Listen for events on a given topic path, in a given modelling environment
+func (e *tModellingBusEventsConnector) listenForEventsIn(environmentID, agentID, topicPath string, eventHandler func([]byte)) {
+	e.listenForTopicEventsIn(environmentID, agentID, topicPath, func(_ string, payload []byte) {
+		eventHandler(payload)
+	})
+}
+
+// Listen for events on a given topic path for a given agent (or "+" for any agent), also passing on the agent that posted the event
+func (e *tModellingBusEventsConnector) listenForEventsWithAgent(agentID, topicPath string, eventHandler func(string, []byte)) {
+	e.listenForTopicEventsIn(e.environmentID, agentID, topicPath, func(topic string, payload []byte) {
+		if postingAgentID, _, ok := e.agentTopicPathOf(topic); ok {
+			eventHandler(postingAgentID, payload)
+		}
+	})
+}
+
+// Listen for events on a given topic path, in a given modelling environment, also passing on the topic the event was received on
+func (e *tModellingBusEventsConnector) listenForTopicEventsIn(environmentID, agentID, topicPath string, eventHandler func(string, []byte)) {
 	// Getting the MQTT topic path
-	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
+	mqttTopicPath := e.mqttAgentTopicPathIn(environmentID, agentID, topicPath)
+	ownTopicRoot := e.mqttAgentTopicRootFor(environmentID, e.agentID) + "/"
 
 	// Setting up the subscription
-	token := e.client.Subscribe(mqttTopicPath, 0, func(client mqtt.Client, msg mqtt.Message) {
+	token := e.client.Subscribe(mqttTopicPath, e.subscribeQoS, func(client mqtt.Client, msg mqtt.Message) {
 		// Getting the payload
 		payload := msg.Payload()
 
+		// Ignoring our own postings, if needed
+		if e.ignoreOwnPostings.Load() && strings.HasPrefix(msg.Topic(), ownTopicRoot) {
+			return
+		}
+
 		// Calling the event handler, if necessary
-		if len(payload) > 0 && string(e.openingMessages[mqttTopicPath]) != string(payload) {
-			eventHandler(payload)
+		if len(payload) > 0 && string(e.openingMessage(mqttTopicPath)) != string(payload) {
+			eventHandler(msg.Topic(), payload)
 		}
 	})
 
 	// Waiting for the subscription to be in place
 	token.Wait()
+	e.registerSubscription(mqttTopicPath)
+}
+
+// Stop listening for events on a given topic path for a given agent
+func (e *tModellingBusEventsConnector) stopListeningForEvents(agentID, topicPath string) {
+	e.stopListeningForEventsIn(e.environmentID, agentID, topicPath)
+}
+
+// Stop listening for events on a given topic path for a given agent, in a given modelling environment
+func (e *tModellingBusEventsConnector) stopListeningForEventsIn(environmentID, agentID, topicPath string) {
+	mqttTopicPath := e.mqttAgentTopicPathIn(environmentID, agentID, topicPath)
+
+	// Forget the subscription, so it is not unsubscribed from again when disconnecting
+	e.subscriptionsMutex.Lock()
+	delete(e.subscribedTopics, mqttTopicPath)
+	e.subscriptionsMutex.Unlock()
+
+	// Unsubscribe from the topic
+	token := e.client.Unsubscribe(mqttTopicPath)
+	token.Wait()
+}
+
+/*
+ *  Managing subscriptions
+ */
+
+// Register a topic as being subscribed to
+func (e *tModellingBusEventsConnector) registerSubscription(topic string) {
+	e.subscriptionsMutex.Lock()
+	defer e.subscriptionsMutex.Unlock()
+
+	e.subscribedTopics[topic] = true
+}
+
+// Check whether we are connected to the MQTT broker
+func (e *tModellingBusEventsConnector) isConnected() bool {
+	return e != nil && e.client != nil && e.client.IsConnected()
+}
+
+// Unsubscribe from all topics, and disconnect from the MQTT broker, unless we have already done so
+func (e *tModellingBusEventsConnector) disconnect() {
+	// Take the subscribed topics, unless we have already disconnected
+	e.subscriptionsMutex.Lock()
+	if e.disconnected {
+		e.subscriptionsMutex.Unlock()
+
+		return
+	}
+	topics := []string{}
+	for topic := range e.subscribedTopics {
+		topics = append(topics, topic)
+	}
+	e.subscribedTopics = map[string]bool{}
+	e.disconnected = true
+	e.subscriptionsMutex.Unlock()
+
+	// Let the others know we are going offline, as the broker only does so when we lose the connection
+	e.publishPresence(PresenceOffline)
+
+	// Unsubscribe from the topics
+	if len(topics) > 0 {
+		token := e.client.Unsubscribe(topics...)
+		token.Wait()
+	}
+
+	// Disconnect, allowing in-flight work to complete
+	e.client.Disconnect(disconnectQuiesce)
+	e.reporter.Progress(generics.ProgressLevelBasic, "Disconnected from the MQTT broker.")
 }
 
 /*
@@ -281,36 +748,113 @@ func (e *tModellingBusEventsConnector) listenForEvents(agentID, topicPath string
  */
 
 // Delete a given topic path
-func (e *tModellingBusEventsConnector) deletePath(topicPath string) {
+func (e *tModellingBusEventsConnector) deletePath(topicPath string) error {
 	// Deleting the path by posting an empty message
-	e.postMessage(topicPath, []byte{})
+	// This message is always retained, as that is what clears a retained message from the MQTT broker
+	return e.publish(topicPath, []byte{}, true)
 }
 
 // Delete a given topic path
 func (e *tModellingBusEventsConnector) deletePostingPath(topicPath string) {
-	// Deleting the path by posting an empty event
-	e.postEvent(topicPath, []byte{})
+	// Deleting the path by posting an empty (retained) event, which clears the retained message,
+	// so subscribers will not receive a stale link to a deleted file
+	e.deletePath(e.mqttAgentTopicPath(e.agentID, topicPath))
 }
 
-// Delete all topics for a given modelling environment
-func (e *tModellingBusEventsConnector) deleteEnvironment(environmentID string) {
-	// Collect all topics for the given modelling environment
-	e.collectTopicsForModellingEnvironment(environmentID)
-
-	// Delete all topics for the given modelling environment
-	for topic := range e.openingMessages {
-		// Check whether the topic belongs to the given modelling environment
-		if strings.HasPrefix(topic, e.mqttAgentTopicRootFor(environmentID, e.agentID)) {
-			// Delete the topic
-			e.deletePath(topic)
+// Delete all topics underneath a given topic root, of all agents, returning the errors (if any) that made deleting topics fail
+func (e *tModellingBusEventsConnector) deleteTopicTree(topicRoot string) error {
+	// Collect all topics underneath the given topic root
+	e.collectTopics(topicRoot + "/#")
+
+	// Delete all topics underneath the given topic root
+	errs := []error{}
+	for _, topic := range e.knownTopicsWithPrefix(topicRoot + "/") {
+		// Delete the topic
+		if err := e.deletePath(topic); err != nil {
+			errs = append(errs, err)
 		}
 	}
+
+	return errors.Join(errs...)
+}
+
+// Delete all topics for a given modelling environment, of all agents and in all namespaces, like the repository does,
+// so no retained links to deleted files remain
+func (e *tModellingBusEventsConnector) deleteEnvironment(environmentID string) error {
+	return e.deleteTopicTree(e.mqttNamespaceTopicRootFor(environmentID, ""))
+}
+
+// Delete all topics in a given namespace of our modelling environment, of all agents, like the repository does,
+// so no retained links to deleted files remain
+func (e *tModellingBusEventsConnector) deleteNamespace(namespace string) error {
+	return e.deleteTopicTree(e.mqttNamespaceTopicRootFor(e.environmentID, namespace))
+}
+
+// Delete all topics underneath a given topic path
+func (e *tModellingBusEventsConnector) deletePostingPathTree(topicPath string) {
+	// Collect all topics for our modelling environment
+	e.collectTopicsForModellingEnvironment(e.environmentID)
+
+	// Delete the topic path itself, as well as all topics underneath it
+	e.deletePostingPath(topicPath)
+	for _, topic := range e.knownTopicsWithPrefix(e.mqttAgentTopicPath(e.agentID, topicPath) + "/") {
+		e.deletePath(topic)
+	}
 }
 
 /*
  * Creating bus event connectors
  */
 
+// Get a quality of service level from the config file, falling back to the default when it is not valid
+func (e *tModellingBusEventsConnector) qosFromConfig(configData *generics.TConfigData, key string, defaultQoS int) byte {
+	qos := configData.GetValue("mqtt", key).IntWithDefault(defaultQoS)
+	if qos < 0 || qos > 2 {
+		e.reporter.Error("Invalid MQTT %s: %d. It should be 0, 1, or 2. Using %d instead.", key, qos, defaultQoS)
+
+		return byte(defaultQoS)
+	}
+
+	return byte(qos)
+}
+
+// Get the maximum size of payloads that fit in an MQTT message from the config file, falling back to the default when it is not valid
+func (e *tModellingBusEventsConnector) maxPayloadBytesFromConfig(configData *generics.TConfigData) int {
+	maxPayloadBytes := configData.GetValue("mqtt", "max_payload_bytes").IntWithDefault(defaultMaxPayloadBytes)
+	if maxPayloadBytes <= 0 {
+		e.reporter.Error("Invalid MQTT max_payload_bytes: %d. It should be positive. Using %d instead.", maxPayloadBytes, defaultMaxPayloadBytes)
+
+		return defaultMaxPayloadBytes
+	}
+
+	return maxPayloadBytes
+}
+
+// Get a delay (in seconds) between retries from the config file, falling back to the default when it is not valid
+func (e *tModellingBusEventsConnector) retryDelayFromConfig(configData *generics.TConfigData, key string, defaultDelay int) time.Duration {
+	delay := configData.GetValue("mqtt", key).IntWithDefault(defaultDelay)
+	if delay <= 0 {
+		e.reporter.Error("Invalid MQTT %s: %d. It should be positive. Using %d seconds instead.", key, delay, defaultDelay)
+
+		return time.Duration(defaultDelay) * time.Second
+	}
+
+	return time.Duration(delay) * time.Second
+}
+
+// Get the MQTT client ID from the config file.
+// A fixed client ID allows the broker to recognise the agent across restarts (e.g. for persistent sessions), but
+// the broker disconnects a client when another one connects with the same client ID. So, by default, the client ID
+// is based on the agent ID, with a random suffix, so that concurrent instances of an agent (e.g. during a rolling
+// deploy) do not collide.
+func (e *tModellingBusEventsConnector) clientIDFromConfig(configData *generics.TConfigData) string {
+	if clientID := configData.GetValue("mqtt", "client_id").String(); clientID != "" {
+		return clientID
+	}
+
+	return fmt.Sprintf("mqtt-client-%s-%08x", e.agentID, rand.Uint32())
+}
+
 // Create a modelling bus events connector
 func createModellingBusEventsConnector(environmentID, agentID string, configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) *tModellingBusEventsConnector {
 	// Creating the events connector
@@ -323,15 +867,36 @@ func createModellingBusEventsConnector(environmentID, agentID string, configData
 	e.password = configData.GetValue("mqtt", "password").String()
 	e.prefix = configData.GetValue("mqtt", "prefix").String()
 	e.loadDelay = configData.GetValue("mqtt", "load_delay").IntWithDefault(1)
+	e.autoReconnect = configData.GetValue("mqtt", "auto_reconnect").BoolWithDefault(false)
+	e.retained = configData.GetValue("mqtt", "retained").BoolWithDefault(true)
+	e.maxInlineBytes = configData.GetValue("mqtt", "max_inline_bytes").IntWithDefault(0)
+	e.useTLS = configData.GetValue("mqtt", "tls").BoolWithDefault(false)
+	e.caFile = configData.GetValue("mqtt", "ca_file").String()
+	e.insecureSkipVerify = configData.GetValue("mqtt", "insecure_skip_verify").BoolWithDefault(false)
 
 	// Initialising other data
 	e.connectionBeingOpenened = true
 	e.currentMessages = map[string][]byte{}
 	e.openingMessages = map[string][]byte{}
+	e.messageWaiters = map[string][]chan []byte{}
+	e.subscribedTopics = map[string]bool{}
 	e.agentID = agentID
 	e.environmentID = environmentID
+	e.namespace = generics.SanitizeTopicElement(configData.GetValue("", "namespace").String())
+	e.clientID = e.clientIDFromConfig(configData)
 	e.reporter = reporter
 
+	// Get the quality of service levels from the config file
+	e.publishQoS = e.qosFromConfig(configData, "publish_qos", 0)
+	e.subscribeQoS = e.qosFromConfig(configData, "subscribe_qos", 0)
+
+	// Get the maximum size of payloads that fit in an MQTT message from the config file
+	e.maxPayloadBytes = e.maxPayloadBytesFromConfig(configData)
+
+	// Get the delays between retries of connecting to the MQTT broker from the config file
+	e.connectRetryDelay = e.retryDelayFromConfig(configData, "connect_retry_delay", defaultConnectRetryDelay)
+	e.connectRetryMaximumDelay = e.retryDelayFromConfig(configData, "connect_retry_max_delay", defaultConnectRetryMaximumDelay)
+
 	// Connect to MQTT
 	e.connectToMQTT(postingOnly)
 
@@ -350,4 +915,8 @@ const (
 	// When creating an events connector only for posting, then use this constant to set this to true
 	// In this case, the connector will not collect existing messages from the bus
 	PostingOnly = true
+
+	// The presence statuses of agents
+	PresenceOnline  = "online"  // The agent is connected to the modelling bus
+	PresenceOffline = "offline" // The agent disconnected from, or lost its connection to, the modelling bus
 )
@@ -0,0 +1,36 @@
+package connect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayFromConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    []string
+		wantDelay  time.Duration
+		wantErrors int
+	}{
+		{"default", nil, defaultConnectRetryDelay * time.Second, 0},
+		{"configured", []string{"[mqtt]", "connect_retry_delay = 2"}, 2 * time.Second, 0},
+		{"not a number", []string{"[mqtt]", "connect_retry_delay = soon"}, defaultConnectRetryDelay * time.Second, 0},
+		{"zero", []string{"[mqtt]", "connect_retry_delay = 0"}, defaultConnectRetryDelay * time.Second, 1},
+		{"negative", []string{"[mqtt]", "connect_retry_delay = -3"}, defaultConnectRetryDelay * time.Second, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			configData, _ := loadTestConfig(t, reporter.TReporter, test.content...)
+			e := tModellingBusEventsConnector{reporter: reporter.TReporter}
+
+			if gotDelay := e.retryDelayFromConfig(configData, "connect_retry_delay", defaultConnectRetryDelay); gotDelay != test.wantDelay {
+				t.Errorf("retryDelayFromConfig() = %v, want %v", gotDelay, test.wantDelay)
+			}
+			if gotErrors := len(reporter.reportedErrors()); gotErrors != test.wantErrors {
+				t.Errorf("retryDelayFromConfig() reported %d error(s), want %d", gotErrors, test.wantErrors)
+			}
+		})
+	}
+}
@@ -8,16 +8,29 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 29.11.2025
+ * Version of: 15.10.2026
  *
  */
 
 package connect
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 	"github.com/secsy/goftp"
@@ -35,27 +48,136 @@ type (
 		prefix             string // FTP topic prefix
 		agentID            string // Agent ID to be used in postings on the FTP repository
 		password           string // FTP password
+		anonymous          bool   // Whether to log in to the FTP server anonymously, rather than with the user and password
 		environmentID      string // Modelling environment ID
+		namespace          string // Namespace within the modelling environment (empty means none)
 		localWorkDirectory string // Local work directory
 
+		maxFileBytes int64         // Maximum size of files to be retrieved (0 means unlimited)
+		timeout      time.Duration // Timeout for FTP operations (0 means the default of the FTP package)
+
+		compress        bool // Whether to compress JSON payloads before uploading them
+		prettyJSON      bool // Whether to store JSON payloads pretty-printed, rather than compact, e.g. to inspect them on the FTP server
+		verifyChecksums bool // Whether to verify the checksums of retrieved files
+
 		activeTransfers  bool // Whether to use active transfers for FTP
 		singleServerMode bool // Whether to use a single FTP server for all agents and environments
 
-		createdPaths map[string]bool // Paths already created on the FTP server
+		createdPaths      map[string]bool // Paths already created on the FTP server
+		createdPathsMutex sync.Mutex      // Guards the created paths, as postings may happen concurrently
+
+		retentionCount  int           // Number of postings to keep per topic path (0 means no limit on the number)
+		retentionPeriod time.Duration // Period for which postings are kept per topic path (0 means no limit on the age)
+
+		connectionPool *tFTPConnectionPool // Pool of (idle) FTP connections to be reused
+
+		maxFallbackBytes  int        // The maximum size of JSON to be posted inline while the FTP server is unreachable (0 means never)
+		unreachableSince  time.Time  // The time since which the FTP server is unreachable (zero when it is reachable)
+		reachabilityMutex sync.Mutex // Guards the reachability of the FTP server
 
 		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
 	}
 )
 
+/*
+ * Defining constants
+ */
+
+const (
+	unreachableRecheckDelay = 30 * time.Second // Time after which an unreachable FTP server is tried again
+
+	defaultRetention = "10" // The default number of postings kept per topic path, so the repository does not grow without bounds
+
+	prettyJSONIndent = "  " // Indentation used when storing JSON payloads pretty-printed
+)
+
 /*
  * Defining repository events
  */
 
 type tRepositoryEvent struct {
-	Server    string `json:"server,omitempty"`    // FTP server for the file
-	Port      string `json:"port,omitempty"`      // FTP port on the FTP server
-	FilePath  string `json:"file path,omitempty"` // Path to the file on the FTP server
-	Timestamp string `json:"timestamp"`           // Timestamp of the event
+	Server      string `json:"server,omitempty"`       // FTP server for the file
+	Port        string `json:"port,omitempty"`         // FTP port on the FTP server
+	FilePath    string `json:"file path,omitempty"`    // Path to the file on the FTP server
+	Compressed  bool   `json:"compressed,omitempty"`   // Whether the file is compressed (using gzip)
+	Checksum    string `json:"checksum,omitempty"`     // SHA-256 checksum of the file (as stored on the FTP server)
+	JSONVersion string `json:"json version,omitempty"` // JSON version of the file's content, for versioned JSON postings
+	AckID       string `json:"ack id,omitempty"`       // ID with which listeners should acknowledge receipt, for postings that ask for acknowledgements
+	Format      string `json:"format,omitempty"`       // Format of the file's content (such as "png" or "xml"), for raw postings
+	Timestamp   string `json:"timestamp"`              // Timestamp of the event
+}
+
+/*
+ * Defining FTP errors
+ */
+
+// The FTP operations that can fail
+const (
+	FTPOperationStore    = "store"    // Storing a file on the FTP server
+	FTPOperationRetrieve = "retrieve" // Retrieving a file from the FTP server
+	FTPOperationList     = "list"     // Listing a directory on the FTP server
+)
+
+// An error of an FTP operation, telling which operation failed on which remote path
+type TFTPError struct {
+	Operation  string // The FTP operation that failed
+	Server     string // The FTP server (with port) the operation was done on
+	RemotePath string // The remote path the operation was done on
+	Err        error  // The underlying error
+}
+
+func (e *TFTPError) Error() string {
+	return fmt.Sprintf("FTP %s of %s on %s failed: %s", e.Operation, e.RemotePath, e.Server, e.Err)
+}
+
+func (e *TFTPError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap an error of an FTP operation, if any
+func wrapFTPError(operation, server, remotePath string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &TFTPError{Operation: operation, Server: server, RemotePath: remotePath, Err: err}
+}
+
+/*
+ * Defining size limited writers
+ */
+
+type tLimitedWriter struct {
+	writer    io.Writer // The writer to pass the data on to
+	remaining int64     // The number of bytes that may still be written
+}
+
+var errFileTooLarge = errors.New("file exceeds the maximum file size")
+
+// Write, unless this would exceed the size limit
+func (w *tLimitedWriter) Write(data []byte) (int, error) {
+	if int64(len(data)) > w.remaining {
+		return 0, errFileTooLarge
+	}
+	w.remaining -= int64(len(data))
+
+	return w.writer.Write(data)
+}
+
+/*
+ * Defining checksums
+ */
+
+var errChecksumMismatch = errors.New("checksum of the retrieved file does not match")
+
+// Get the (hex encoded) SHA-256 checksum of the data read from the given reader
+func sha256Checksum(reader io.Reader) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 /*
@@ -72,26 +194,102 @@ func (r *tModellingBusRepositoryConnector) ftpEnvironmentTopicRootFor(environmen
 	return r.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID
 }
 
-// Get the topic path for the given agent and topic path
+// Get the topic root for the given namespace (if any) of our modelling environment
+func (r *tModellingBusRepositoryConnector) ftpNamespaceTopicRootFor(namespace string) string {
+	return r.ftpNamespaceTopicRootIn(r.environmentID, namespace)
+}
+
+// Get the topic root for the given namespace (if any) of the given modelling environment
+func (r *tModellingBusRepositoryConnector) ftpNamespaceTopicRootIn(environmentID, namespace string) string {
+	if namespace == "" {
+		return r.ftpEnvironmentTopicRootFor(environmentID)
+	}
+
+	return r.ftpEnvironmentTopicRootFor(environmentID) + "/" + namespace
+}
+
+// Get the topic path for the given agent and topic path, within our namespace (if any)
+func (r *tModellingBusRepositoryConnector) ftpAgentTopicPath(agentID, topicPath string) string {
+	return r.ftpAgentTopicPathIn(r.environmentID, agentID, topicPath)
+}
+
+// Get the topic path for the given agent and topic path, within our namespace (if any) of the given modelling environment
+func (r *tModellingBusRepositoryConnector) ftpAgentTopicPathIn(environmentID, agentID, topicPath string) string {
+	return r.ftpNamespaceTopicRootIn(environmentID, r.namespace) + "/" + agentID + "/" + topicPath
+}
+
+// Get the topic path for our own agent and the given topic path
 func (r *tModellingBusRepositoryConnector) ftpTopicPath(topicPath string) string {
-	return r.prefix + "/" + generics.ModellingBusVersion + "/" + r.environmentID + "/" + r.agentID + "/" + topicPath
+	return r.ftpTopicPathIn(r.environmentID, topicPath)
+}
+
+// Get the topic path for our own agent and the given topic path, in the given modelling environment
+func (r *tModellingBusRepositoryConnector) ftpTopicPathIn(environmentID, topicPath string) string {
+	return r.ftpAgentTopicPathIn(environmentID, r.agentID, topicPath)
 }
 
 /*
  * FTP connection and operations
  */
 
-// Connecting to the FTP server
-func (r *tModellingBusRepositoryConnector) ftpConnect() (*goftp.Client, bool) {
-	// Define the FTP connection configuration
+// Get the credentials for our own FTP server
+func (r *tModellingBusRepositoryConnector) ftpCredentials() (string, string) {
+	if r.anonymous {
+		return "anonymous", "anonymous"
+	}
+
+	return r.user, r.password
+}
+
+// Get the FTP configuration for our own FTP server
+func (r *tModellingBusRepositoryConnector) ftpConfig() goftp.Config {
 	config := goftp.Config{}
-	config.User = r.user
-	config.Password = r.password
+	config.User, config.Password = r.ftpCredentials()
 	config.ActiveTransfers = r.activeTransfers
-	serverDefinition := r.server + ":" + r.port
+	config.Timeout = r.timeout
+
+	return config
+}
+
+// Keep track of whether the FTP server is reachable, given the outcome of an operation on it.
+// Connecting alone does not tell, as connections to the FTP server are only made once an operation needs them.
+func (r *tModellingBusRepositoryConnector) trackReachability(err error) {
+	r.reachabilityMutex.Lock()
+	defer r.reachabilityMutex.Unlock()
+
+	var ftpError goftp.Error
+	switch {
+	case err != nil && !errors.As(err, &ftpError):
+		// Other errors, such as from reading the content to be stored, do not tell whether the FTP server is reachable
+
+	case err != nil && ftpError.Code() == 0:
+		// The FTP server did not reply at all
+		r.unreachableSince = time.Now()
+
+	case !r.unreachableSince.IsZero():
+		r.unreachableSince = time.Time{}
+		r.reporter.Progress(generics.ProgressLevelBasic, "The FTP server is reachable again.")
+	}
+}
+
+// Check whether the FTP server is (presumed to be) reachable. After the FTP server has been found to be unreachable,
+// it is presumed to remain so for a while, after which it is tried again.
+func (r *tModellingBusRepositoryConnector) isReachable() bool {
+	r.reachabilityMutex.Lock()
+	defer r.reachabilityMutex.Unlock()
+
+	return r.unreachableSince.IsZero() || time.Since(r.unreachableSince) >= unreachableRecheckDelay
+}
+
+// Check whether JSON of the given size may be posted inline while the FTP server is unreachable
+func (r *tModellingBusRepositoryConnector) allowsFallback(size int) bool {
+	return size > 0 && size <= r.maxFallbackBytes && !r.isReachable()
+}
 
-	// Finally, connect to the FTP server
-	client, err := goftp.DialConfig(config, serverDefinition)
+// Connecting to the FTP server
+func (r *tModellingBusRepositoryConnector) ftpConnect() (*goftp.Client, bool) {
+	// Get a (pooled) connection to the FTP server
+	client, err := r.connectionPool.acquire(r.ftpConfig(), r.server+":"+r.port)
 	if err != nil {
 		r.reporter.ReportError("Error connecting to the FTP server:", err)
 		return client, false
@@ -101,10 +299,63 @@ func (r *tModellingBusRepositoryConnector) ftpConnect() (*goftp.Client, bool) {
 	return client, true
 }
 
+// Checking whether we can connect, and log in, to the FTP server, using a fresh (not pooled) connection
+func (r *tModellingBusRepositoryConnector) checkConnection() error {
+	client, err := goftp.DialConfig(r.ftpConfig(), r.server+":"+r.port)
+	if err == nil {
+		_, err = client.Getwd()
+		client.Close()
+	}
+	r.trackReachability(err)
+
+	return err
+}
+
+// Releasing a connection to the FTP server, so it can be reused when the operation did not fail
+func (r *tModellingBusRepositoryConnector) ftpRelease(client *goftp.Client, err error) {
+	r.connectionPool.release(client, err == nil)
+}
+
+// Check whether an FTP error signals that the remote path does not exist (or is not accessible)
+func isFTPPathNotFound(err error) bool {
+	var ftpError goftp.Error
+
+	return errors.As(err, &ftpError) && ftpError.Code() == 550
+}
+
+// Check whether the given repository file path has already been created on the FTP server
+func (r *tModellingBusRepositoryConnector) isCreatedPath(remoteFilePath string) bool {
+	r.createdPathsMutex.Lock()
+	defer r.createdPathsMutex.Unlock()
+
+	return r.createdPaths[remoteFilePath]
+}
+
+// Mark the given repository file path as created on the FTP server
+func (r *tModellingBusRepositoryConnector) markCreatedPath(remoteFilePath string) {
+	r.createdPathsMutex.Lock()
+	defer r.createdPathsMutex.Unlock()
+
+	r.createdPaths[remoteFilePath] = true
+}
+
+// Forget the created repository file paths underneath a deleted path, so they will be created again when needed
+func (r *tModellingBusRepositoryConnector) forgetCreatedPaths(deletedPath string) {
+	r.createdPathsMutex.Lock()
+	defer r.createdPathsMutex.Unlock()
+
+	for createdPath := range r.createdPaths {
+		if createdPath == deletedPath || strings.HasPrefix(createdPath, deletedPath+"/") {
+			delete(r.createdPaths, createdPath)
+		}
+	}
+}
+
 // Make sure the given repository file path exists on the FTP server
 func (r *tModellingBusRepositoryConnector) mkRepositoryFilePath(remoteFilePath string) {
 	// Create the path on the FTP server, if not already done
-	if !r.createdPaths[remoteFilePath] {
+	// Concurrent postings may both create the path, which is harmless
+	if !r.isCreatedPath(remoteFilePath) {
 		// Connect to the FTP server
 		if client, ok := r.ftpConnect(); ok {
 			pathCovered := ""
@@ -114,20 +365,94 @@ func (r *tModellingBusRepositoryConnector) mkRepositoryFilePath(remoteFilePath s
 				client.Mkdir(pathCovered)
 			}
 
-			// Close the FTP connection
-			client.Close()
+			// Release the FTP connection
+			r.ftpRelease(client, nil)
 
 			// Mark the path as created
-			r.createdPaths[remoteFilePath] = true
+			r.markCreatedPath(remoteFilePath)
 		}
 	}
 }
 
-// Add a file to the repository
-func (r *tModellingBusRepositoryConnector) addFile(topicPath, localFilePath, timestamp string) tRepositoryEvent {
+// Remove the postings under a topic path that fall outside the retention window.
+// Each posting is stored in a folder named after its timestamp, and the latest posting is always kept.
+func (r *tModellingBusRepositoryConnector) pruneRepositoryFilePath(client *goftp.Client, remoteFilePath, latestTimestamp string) {
+	// No retention limits means nothing needs to be pruned
+	if r.retentionCount <= 0 && r.retentionPeriod <= 0 {
+		return
+	}
+
+	// Get the existing postings
+	fileInfos, err := client.ReadDir(remoteFilePath)
+	if err != nil {
+		return
+	}
+
+	// Only consider the timestamp named folders, other than the latest one
+	timestamps := []string{}
+	for _, fileInfo := range fileInfos {
+		if _, isTimestamp := generics.TimestampTime(fileInfo.Name()); isTimestamp && fileInfo.Name() != latestTimestamp {
+			timestamps = append(timestamps, fileInfo.Name())
+		}
+	}
+
+	// Sort them from old to new
+	slices.SortFunc(timestamps, generics.CompareTimestamps)
+
+	// Determine which postings have been superseded
+	superseded := map[string]bool{}
+	if r.retentionCount > 0 {
+		// The latest posting counts as one of the retained ones
+		for len(timestamps)-len(superseded) > r.retentionCount-1 {
+			superseded[timestamps[len(superseded)]] = true
+		}
+	}
+	if r.retentionPeriod > 0 {
+		retentionStart := time.Now().Add(-r.retentionPeriod)
+		for _, timestamp := range timestamps {
+			if timestampTime, _ := generics.TimestampTime(timestamp); timestampTime.Before(retentionStart) {
+				superseded[timestamp] = true
+			}
+		}
+	}
+
+	// Delete the superseded postings
+	for timestamp := range superseded {
+		deleteRepositoryPath(client, remoteFilePath+"/"+timestamp)
+	}
+
+	// Report on the pruning
+	if len(superseded) > 0 {
+		r.reporter.Progress(generics.ProgressLevelDetailed, "Removed %d superseded posting(s) from: %s", len(superseded), remoteFilePath)
+	}
+}
+
+// Add a file to the repository, in the given modelling environment on behalf of the given agent, using the given name for the payload file
+func (r *tModellingBusRepositoryConnector) addFileAs(environmentID, agentID, topicPath, localFilePath, payloadFileName, timestamp string) (tRepositoryEvent, error) {
+	// Open the local file for reading
+	file, err := os.Open(filepath.FromSlash(localFilePath))
+
+	// Handle potential errors
+	if err != nil {
+		r.reporter.ReportError("Error opening File for reading:", err)
+		return tRepositoryEvent{Timestamp: timestamp}, err
+	}
+
+	// Close the local file afterwards
+	defer file.Close()
+
+	// Add the content of the file to the repository
+	return r.addReaderAs(environmentID, agentID, topicPath, file, payloadFileName, timestamp)
+}
+
+// Add the content read from the given reader to the repository, in the given modelling environment on behalf of the given agent,
+// using the given name for the payload file
+func (r *tModellingBusRepositoryConnector) addReaderAs(environmentID, agentID, topicPath string, reader io.Reader, payloadFileName, timestamp string) (tRepositoryEvent, error) {
 	// Define the remote file path
-	remoteFilePath := r.ftpTopicPath(topicPath)
-	remotePayloadFileNamePath := remoteFilePath + "/" + generics.PayloadFileName
+	// Each posting gets its own folder, named after its timestamp
+	remoteFilePath := r.ftpAgentTopicPathIn(environmentID, agentID, topicPath)
+	remotePostingPath := remoteFilePath + "/" + timestamp
+	remotePayloadFileNamePath := remotePostingPath + "/" + payloadFileName
 
 	// Make sure the path exists on the FTP server
 	r.mkRepositoryFilePath(remoteFilePath)
@@ -136,47 +461,69 @@ func (r *tModellingBusRepositoryConnector) addFile(topicPath, localFilePath, tim
 	repositoryEvent := tRepositoryEvent{}
 	repositoryEvent.Timestamp = timestamp
 
-	// Open the local file for reading
-	file, err := os.Open(filepath.FromSlash(localFilePath))
+	// When the content can be re-read, compute the checksum up front, so the FTP package can resume failed uploads.
+	// Otherwise, compute the checksum while uploading.
+	checksum := ""
+	hasher := sha256.New()
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		var err error
+		checksum, err = sha256Checksum(seeker)
+		if err == nil {
+			_, err = seeker.Seek(0, io.SeekStart)
+		}
 
-	// Handle potential errors
-	if err != nil {
-		r.reporter.ReportError("Error opening File for reading:", err)
-		return repositoryEvent
+		// Handle potential errors
+		if err != nil {
+			r.reporter.ReportError("Error computing checksum of file:", err)
+			return repositoryEvent, err
+		}
+	} else {
+		reader = io.TeeReader(reader, hasher)
 	}
 
 	// Connect to the FTP server
 	client, ok := r.ftpConnect()
 	if !ok {
-		return repositoryEvent
+		return repositoryEvent, fmt.Errorf("%w: could not connect to the FTP server", ErrFTPUpload)
 	}
 
-	// Store the file on the FTP server
-	err = client.Store(remotePayloadFileNamePath, file)
+	// Create the folder for this posting, and store the file on the FTP server
+	client.Mkdir(remotePostingPath)
+	err := client.Store(remotePayloadFileNamePath, reader)
+	r.trackReachability(err)
 
-	// Handle potential errors when opening the file
+	// Remove the postings that are now outside of the retention window
+	if err == nil {
+		r.pruneRepositoryFilePath(client, remoteFilePath, timestamp)
+	}
+
+	// Release the FTP connection
+	r.ftpRelease(client, err)
+
+	// Handle potential errors when uploading the file, including errors from the reader
 	if err != nil {
+		err = wrapFTPError(FTPOperationStore, r.server+":"+r.port, remotePayloadFileNamePath, err)
 		r.reporter.ReportError("Error uploading file to ftp server:", err)
-		r.reporter.Error("For remote file path: %s", remotePayloadFileNamePath)
-		return repositoryEvent
+		return repositoryEvent, fmt.Errorf("%w: %w", ErrFTPUpload, err)
 	}
 
-	// Close the local file
-	client.Close()
-
 	// Define the repository event
 	if !r.singleServerMode {
 		repositoryEvent.Server = r.server
 		repositoryEvent.Port = r.port
 	}
 	repositoryEvent.FilePath = remotePayloadFileNamePath
+	if checksum == "" {
+		checksum = hex.EncodeToString(hasher.Sum(nil))
+	}
+	repositoryEvent.Checksum = checksum
 
 	// Return the repository event
-	return repositoryEvent
+	return repositoryEvent, nil
 }
 
 // Delete a path from the repository
-func deleteRepositoryPath(client *goftp.Client, deletePath string) {
+func deleteRepositoryPath(client *goftp.Client, deletePath string) error {
 	// We're not certain if deletePath refers to a file or a directory.
 
 	// So first, we try to read it as a directory.
@@ -184,22 +531,120 @@ func deleteRepositoryPath(client *goftp.Client, deletePath string) {
 	if len(fileInfos) > 0 {
 		// If it works, we delete all contents recursively, then remove the directory itself.
 		for _, fileInfo := range fileInfos {
-			deleteRepositoryPath(client, deletePath+"/"+fileInfo.Name())
+			if err := deleteRepositoryPath(client, deletePath+"/"+fileInfo.Name()); err != nil {
+				return err
+			}
 		}
-		client.Rmdir(deletePath)
-	} else {
-		// If it fails, we assume it's a file and delete it directly.
-		client.Delete(deletePath)
+
+		return client.Rmdir(deletePath)
+	}
+
+	// If it fails, we assume it's a file and delete it directly.
+	// A path that does not exist (anymore) has nothing to be deleted.
+	if err := client.Delete(deletePath); err != nil && !isFTPPathNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List the names of the entries underneath the given topic path of the given agent.
+// A topic path that does not exist (yet) has no entries.
+func (r *tModellingBusRepositoryConnector) listTopicPath(agentID, topicPath string) ([]string, error) {
+	return r.listTopicPathIn(r.environmentID, agentID, topicPath)
+}
+
+// List the names of the entries underneath the given topic path of the given agent, in the given modelling environment
+func (r *tModellingBusRepositoryConnector) listTopicPathIn(environmentID, agentID, topicPath string) ([]string, error) {
+	return r.listRemotePath(r.ftpAgentTopicPathIn(environmentID, agentID, topicPath))
+}
+
+// List the names of the agents that have posted in our modelling environment (within our namespace, if any).
+// A modelling environment that does not exist (yet) has no agents.
+func (r *tModellingBusRepositoryConnector) listAgents() ([]string, error) {
+	return r.listRemotePath(r.ftpNamespaceTopicRootFor(r.namespace))
+}
+
+// List the names of the entries underneath the given remote path.
+// A remote path that does not exist (yet) has no entries.
+func (r *tModellingBusRepositoryConnector) listRemotePath(remotePath string) ([]string, error) {
+	// Connect to the FTP server
+	client, err := r.connectionPool.acquire(r.ftpConfig(), r.server+":"+r.port)
+	if err != nil {
+		return nil, wrapFTPError(FTPOperationList, r.server+":"+r.port, remotePath, err)
+	}
+
+	// Read the entries
+	fileInfos, err := client.ReadDir(remotePath)
+	r.trackReachability(err)
+
+	// Release the FTP connection
+	r.ftpRelease(client, err)
+
+	// Handle potential errors
+	if isFTPPathNotFound(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, wrapFTPError(FTPOperationList, r.server+":"+r.port, remotePath, err)
+	}
+
+	// Collect the names of the entries
+	names := []string{}
+	for _, fileInfo := range fileInfos {
+		names = append(names, fileInfo.Name())
+	}
+
+	// Return the names in a predictable order
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Get the repository event for the posting with the given timestamp, on the given topic path of the given agent, in the given modelling environment.
+// This assumes the posting was made on the FTP server this connector is configured with.
+func (r *tModellingBusRepositoryConnector) postingEventFor(environmentID, agentID, topicPath, timestamp string) (tRepositoryEvent, error) {
+	// Find the payload file in the folder of the posting
+	payloadFileNames, err := r.listTopicPathIn(environmentID, agentID, topicPath+"/"+timestamp)
+	if err != nil {
+		return tRepositoryEvent{}, err
 	}
+	if len(payloadFileNames) == 0 {
+		return tRepositoryEvent{}, fmt.Errorf("no posting found on %s with timestamp %s", topicPath, timestamp)
+	}
+
+	// Define the repository event
+	repositoryEvent := tRepositoryEvent{}
+	repositoryEvent.Server = r.server
+	repositoryEvent.Port = r.port
+	repositoryEvent.FilePath = r.ftpAgentTopicPathIn(environmentID, agentID, topicPath) + "/" + timestamp + "/" + payloadFileNames[0]
+	repositoryEvent.Compressed = strings.HasSuffix(payloadFileNames[0], generics.GZipExtension)
+	repositoryEvent.Timestamp = timestamp
+
+	return repositoryEvent, nil
 }
 
-// Delete a given path from the repository
-func (r *tModellingBusRepositoryConnector) deletePath(deletePath string) {
+// Delete a given path from the repository, returning the error (if any) that made the deletion fail
+func (r *tModellingBusRepositoryConnector) deletePath(deletePath string) error {
 	// Connect to the FTP server
-	if client, ok := r.ftpConnect(); ok {
-		// Then, delete the given path from the FTP server
-		deleteRepositoryPath(client, deletePath)
+	client, ok := r.ftpConnect()
+	if !ok {
+		return fmt.Errorf("%w: could not connect to the FTP server", ErrDelete)
 	}
+
+	// Then, delete the given path from the FTP server
+	err := deleteRepositoryPath(client, deletePath)
+	r.trackReachability(err)
+	r.forgetCreatedPaths(deletePath)
+
+	// Release the FTP connection
+	r.ftpRelease(client, err)
+
+	if err != nil {
+		r.reporter.ReportError("Error deleting "+deletePath+" from the FTP server:", err)
+		return fmt.Errorf("%w: %w", ErrDelete, err)
+	}
+
+	return nil
 }
 
 // Delete the posting path for the given topic path
@@ -209,81 +654,304 @@ func (r *tModellingBusRepositoryConnector) deletePostingPath(topicPath string) {
 }
 
 // Delete an entire environment from the repository
-func (r *tModellingBusRepositoryConnector) deleteEnvironment(environment string) {
+func (r *tModellingBusRepositoryConnector) deleteEnvironment(environment string) error {
 	// Delete the entere file tree from the FTP server for the given environment
-	r.deletePath(r.ftpEnvironmentTopicRootFor(environment))
+	return r.deletePath(r.ftpEnvironmentTopicRootFor(environment))
+}
+
+// Get JSON content in the form in which it is stored in the repository, i.e. pretty-printed when configured so
+func (r *tModellingBusRepositoryConnector) storedJSON(content []byte) []byte {
+	if !r.prettyJSON {
+		return content
+	}
+
+	indented := bytes.Buffer{}
+	if err := json.Indent(&indented, content, "", prettyJSONIndent); err != nil {
+		return content
+	}
+
+	return indented.Bytes()
+}
+
+// Delete a namespace of our modelling environment from the repository
+func (r *tModellingBusRepositoryConnector) deleteNamespace(namespace string) error {
+	// Delete the entire file tree from the FTP server for the given namespace
+	return r.deletePath(r.ftpNamespaceTopicRootFor(namespace))
 }
 
 // Add JSON content as a file to the repository
-func (r *tModellingBusRepositoryConnector) addJSONAsFile(topicPath string, json []byte, timestamp string) tRepositoryEvent {
-	// Define the temporary local file path
-	localFilePath := r.localFilePathFor(generics.JSONFileName)
+func (r *tModellingBusRepositoryConnector) addJSONAsFile(topicPath string, json []byte, timestamp string) (tRepositoryEvent, error) {
+	return r.addJSONAsFileAs(r.environmentID, r.agentID, topicPath, "", json, timestamp)
+}
 
+// Add JSON content as a file to the repository, in the given modelling environment on behalf of the given agent,
+// using the given name for the payload file (empty means the default name)
+func (r *tModellingBusRepositoryConnector) addJSONAsFileAs(environmentID, agentID, topicPath, payloadFileName string, json []byte, timestamp string) (tRepositoryEvent, error) {
 	// Validate that the content is a valid JSON
 	if !generics.IsJSON(json) {
 		r.reporter.Error("Provided content is not a valid JSON.")
-		return tRepositoryEvent{}
+		return tRepositoryEvent{}, fmt.Errorf("%w: provided content is not a valid JSON", ErrMarshal)
 	}
 
-	// Create a temporary local file with the JSON record
-	if err := os.WriteFile(localFilePath, json, 0644); err != nil {
-		r.reporter.ReportError("Error writing to temporary file:", err)
-		return tRepositoryEvent{}
+	// Create a uniquely named temporary local file, so concurrent postings do not collide
+	localFile, err := os.CreateTemp(r.localWorkDirectory, generics.TemporaryJSONFilePattern)
+	if err != nil {
+		r.reporter.ReportError("Error creating temporary file:", err)
+		return tRepositoryEvent{}, err
 	}
+	localFilePath := localFile.Name()
 
 	// Cleanup the temporary file afterwards
 	defer os.Remove(localFilePath)
 
+	// Write the JSON record to the temporary local file, in the form in which it is stored, compressing it if needed
+	json = r.storedJSON(json)
+	if r.compress {
+		compressor := gzip.NewWriter(localFile)
+		_, err = compressor.Write(json)
+		if err == nil {
+			err = compressor.Close()
+		}
+	} else {
+		_, err = localFile.Write(json)
+	}
+	localFile.Close()
+	if err != nil {
+		r.reporter.ReportError("Error writing to temporary file:", err)
+		return tRepositoryEvent{}, err
+	}
+
 	// Add the file to the repository
-	return r.addFile(topicPath, localFilePath, timestamp)
+	if r.compress {
+		if payloadFileName == "" {
+			payloadFileName = generics.PayloadFileName + generics.JSONExtension
+		}
+		repositoryEvent, err := r.addFileAs(environmentID, agentID, topicPath, localFilePath, payloadFileName+generics.GZipExtension, timestamp)
+		repositoryEvent.Compressed = err == nil
+
+		return repositoryEvent, err
+	}
+
+	if payloadFileName == "" {
+		payloadFileName = generics.PayloadFileName
+	}
+
+	return r.addFileAs(environmentID, agentID, topicPath, localFilePath, payloadFileName, timestamp)
 }
 
-// Get a file from the repository
-func (r *tModellingBusRepositoryConnector) getFile(repositoryEvent tRepositoryEvent, fileName string) string {
+// Get the FTP server (with port) holding the file of a given repository event
+func (r *tModellingBusRepositoryConnector) serverConnectionFor(repositoryEvent tRepositoryEvent) string {
+	if r.singleServerMode {
+		return r.server + ":" + r.port
+	}
+
+	return repositoryEvent.Server + ":" + repositoryEvent.Port
+}
+
+// Connecting to the FTP server holding the file of a given repository event
+func (r *tModellingBusRepositoryConnector) ftpConnectFor(repositoryEvent tRepositoryEvent) (*goftp.Client, error) {
 	// Configure FTP connection
 	config := goftp.Config{}
 	config.ActiveTransfers = r.activeTransfers
-	serverConnection := ""
+	config.Timeout = r.timeout
 
-	// Determine server connection details
-	if r.singleServerMode {
-		serverConnection = r.server + ":" + r.port
+	// Use our credentials on our own FTP server, and log in anonymously on the FTP servers of others
+	if r.serverConnectionFor(repositoryEvent) == r.server+":"+r.port {
+		config.User, config.Password = r.ftpCredentials()
+	}
 
-		config.User = r.user
-		config.Password = r.password
-	} else {
-		serverConnection = repositoryEvent.Server + ":" + repositoryEvent.Port
+	// Connect to the FTP server, reusing a pooled connection to this server when possible
+	return r.connectionPool.acquire(config, r.serverConnectionFor(repositoryEvent))
+}
+
+// Retrieve the file of a given repository event, writing it to the given writer.
+// Compressed files are first downloaded into a temporary file, so their checksum can be verified, and are then
+// decompressed while streaming them to the writer, so large files need not be kept in memory.
+func (r *tModellingBusRepositoryConnector) retrieveToWriter(repositoryEvent tRepositoryEvent, writer io.Writer) error {
+	// Uncompressed files can be retrieved as is
+	if !repositoryEvent.Compressed {
+		return r.retrieveRawToWriter(repositoryEvent, writer)
+	}
+
+	// Retrieve the compressed file into a temporary file
+	compressedFile, err := os.CreateTemp(r.localWorkDirectory, generics.TemporaryJSONFilePattern)
+	if err != nil {
+		return err
 	}
+	defer os.Remove(compressedFile.Name())
+	defer compressedFile.Close()
 
+	if err := r.retrieveRawToWriter(repositoryEvent, compressedFile); err != nil {
+		return err
+	}
+
+	// Decompress it from the start of the file
+	if _, err := compressedFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return r.decompressToWriter(compressedFile, writer)
+}
+
+// Decompress gzip compressed content, while streaming it to the given writer, guarding the maximum file size if needed
+func (r *tModellingBusRepositoryConnector) decompressToWriter(compressed io.Reader, writer io.Writer) error {
+	decompressor, err := gzip.NewReader(compressed)
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close()
+
+	if r.maxFileBytes > 0 {
+		writer = &tLimitedWriter{writer: writer, remaining: r.maxFileBytes}
+	}
+	_, err = io.Copy(writer, decompressor)
+
+	return err
+}
+
+// Retrieve the file of a given repository event as is, writing it to the given writer
+func (r *tModellingBusRepositoryConnector) retrieveRawToWriter(repositoryEvent tRepositoryEvent, writer io.Writer) error {
 	// Connect to the FTP server
-	client, err := goftp.DialConfig(config, serverConnection)
+	client, err := r.ftpConnectFor(repositoryEvent)
+	if err != nil {
+		return wrapFTPError(FTPOperationRetrieve, r.serverConnectionFor(repositoryEvent), repositoryEvent.FilePath, err)
+	}
+
+	// Compute the checksum while retrieving, when it can be verified
+	hasher := sha256.New()
+	verifyChecksum := r.verifyChecksums && repositoryEvent.Checksum != ""
+	if verifyChecksum {
+		writer = io.MultiWriter(writer, hasher)
+	}
+
+	// Retrieve the file from the FTP server, guarding the maximum file size if needed
+	if r.maxFileBytes > 0 {
+		err = client.Retrieve(repositoryEvent.FilePath, &tLimitedWriter{writer: writer, remaining: r.maxFileBytes})
+	} else {
+		err = client.Retrieve(repositoryEvent.FilePath, writer)
+	}
+
+	// Release the FTP connection
+	r.ftpRelease(client, err)
+	err = wrapFTPError(FTPOperationRetrieve, r.serverConnectionFor(repositoryEvent), repositoryEvent.FilePath, err)
+
+	// Verify the checksum
+	if err == nil && verifyChecksum && hex.EncodeToString(hasher.Sum(nil)) != repositoryEvent.Checksum {
+		return wrapFTPError(FTPOperationRetrieve, r.serverConnectionFor(repositoryEvent), repositoryEvent.FilePath, errChecksumMismatch)
+	}
+
+	return err
+}
+
+// Retrieve a file from the repository into the given (newly created) local file
+func (r *tModellingBusRepositoryConnector) retrieveFile(repositoryEvent tRepositoryEvent, localFile *os.File) string {
+	// Ensure the file is closed after operation
+	defer localFile.Close()
+
+	// Retrieve the file from the FTP server
+	err := r.retrieveToWriter(repositoryEvent, localFile)
+
+	// Handle potential errors
 	if err != nil {
-		r.reporter.ReportError("Something went wrong connecting to the FTP server:", err)
+		// Get rid of the partially retrieved file
+		localFile.Close()
+		os.Remove(localFile.Name())
+
+		// The posting may have been deleted, while a (stale) link to it was still around
+		if isFTPPathNotFound(err) {
+			r.reporter.Progress(generics.ProgressLevelDetailed, "The linked file is no longer available: %s", repositoryEvent.FilePath)
+			return ""
+		}
+
+		r.reporter.ReportError("Something went wrong retrieving file:", err)
 		return ""
 	}
 
+	// Return the local file name
+	return localFile.Name()
+}
+
+// Get the content of the file of a given repository event
+func (r *tModellingBusRepositoryConnector) getFileContent(repositoryEvent tRepositoryEvent) ([]byte, error) {
+	content := bytes.Buffer{}
+	if err := r.retrieveToWriter(repositoryEvent, &content); err != nil {
+		return nil, err
+	}
+
+	return content.Bytes(), nil
+}
+
+// Get a file from the repository
+func (r *tModellingBusRepositoryConnector) getFile(repositoryEvent tRepositoryEvent, fileName string) string {
 	// Set local file path
 	localFileName := r.localFilePathFor(fileName)
 
 	// Download file to local storage
-	File, err := os.Create(localFileName)
+	localFile, err := os.Create(localFileName)
 	if err != nil {
 		r.reporter.ReportError("Something went wrong creating local file:", err)
 		return ""
 	}
 
-	// Ensure the file is closed after operation
-	defer File.Close()
+	// Retrieve the file
+	return r.retrieveFile(repositoryEvent, localFile)
+}
 
-	// Retrieve the file from the FTP server
-	if err = client.Retrieve(repositoryEvent.FilePath, File); err != nil {
-		r.reporter.ReportError("Something went wrong retrieving file:", err)
-		r.reporter.Error("Was trying to retrieve: %s", repositoryEvent.FilePath)
+// Get a file from the repository into a uniquely named temporary file, so concurrent retrievals do not collide.
+// The caller is responsible for removing the temporary file.
+func (r *tModellingBusRepositoryConnector) getTemporaryFile(repositoryEvent tRepositoryEvent) string {
+	// Create the temporary file
+	localFile, err := os.CreateTemp(r.localWorkDirectory, generics.TemporaryJSONFilePattern)
+	if err != nil {
+		r.reporter.ReportError("Something went wrong creating temporary file:", err)
 		return ""
 	}
 
-	// Return the local file name
-	return localFileName
+	// Retrieve the file
+	return r.retrieveFile(repositoryEvent, localFile)
+}
+
+// Close the repository connector, closing idle FTP connections and cleaning up temporary files
+func (r *tModellingBusRepositoryConnector) close() {
+	// Close the idle FTP connections
+	r.connectionPool.closeIdle()
+
+	// Remove temporary files that may have been left behind
+	os.Remove(r.localFilePathFor(generics.JSONFileName))
+}
+
+// Check whether a file in the local work directory is a temporary file we create
+func isTemporaryFileName(fileName string) bool {
+	// The fixed name temporary file
+	if fileName == generics.JSONFileName {
+		return true
+	}
+
+	// The uniquely named temporary files, for which os.CreateTemp replaces the "*" by digits
+	prefix, suffix, _ := strings.Cut(generics.TemporaryJSONFilePattern, "*")
+	unique, isTemporary := strings.CutPrefix(fileName, prefix)
+	unique, hasSuffix := strings.CutSuffix(unique, suffix)
+
+	return isTemporary && hasSuffix && unique != "" && strings.Trim(unique, "0123456789") == ""
+}
+
+// Remove the temporary files left behind in the local work directory, e.g. by crashed processes.
+// Only files with the names of the temporary files we create are removed.
+// Note that this should not be done when other processes use the same work directory at the same time.
+func (r *tModellingBusRepositoryConnector) cleanLocalWorkDirectory() {
+	// Get the files in the local work directory
+	entries, err := os.ReadDir(r.localWorkDirectory)
+	if r.reporter.MaybeReportError("Something went wrong listing the work folder:", err) {
+		return
+	}
+
+	// Remove the temporary files
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isTemporaryFileName(entry.Name()) {
+			r.reporter.Progress(generics.ProgressLevelDetailed, "Removing left behind temporary file: %s", entry.Name())
+			r.reporter.MaybeReportError("Something went wrong removing a left behind temporary file:", os.Remove(r.localFilePathFor(entry.Name())))
+		}
+	}
 }
 
 // Create the modelling bus repository connector
@@ -297,29 +965,73 @@ func createModellingBusRepositoryConnector(environmentID, agentID string, config
 	r.user = configData.GetValue("ftp", "user").String()
 	r.server = configData.GetValue("ftp", "server").String()
 	r.password = configData.GetValue("ftp", "password").String()
+	r.anonymous = configData.GetValue("ftp", "anonymous").BoolWithDefault(false)
 	r.singleServerMode = configData.GetValue("ftp", "single_server_mode").BoolWithDefault(false)
 	r.activeTransfers = configData.GetValue("ftp", "active_transfers").BoolWithDefault(false)
 	r.prefix = configData.GetValue("ftp", "prefix").String()
+	maxIdleConnections := configData.GetValue("ftp", "max_idle_conns").IntWithDefault(2)
+	retention := configData.GetValue("ftp", "retention").StringWithDefault(defaultRetention)
+	r.maxFileBytes = int64(configData.GetValue("ftp", "max_file_bytes").IntWithDefault(0))
+	r.timeout = time.Duration(configData.GetValue("ftp", "timeout").IntWithDefault(0)) * time.Second
+	r.compress = configData.GetValue("ftp", "compress").BoolWithDefault(false)
+	r.prettyJSON = configData.GetValue("ftp", "pretty_json").BoolWithDefault(false)
+	r.verifyChecksums = configData.GetValue("ftp", "verify_checksums").BoolWithDefault(true)
+	r.maxFallbackBytes = configData.GetValue("ftp", "max_fallback_bytes").IntWithDefault(0)
 
 	// Initialising other data
 	r.reporter = reporter
 	r.agentID = agentID
 	r.environmentID = environmentID
+	r.namespace = generics.SanitizeTopicElement(configData.GetValue("", "namespace").String())
 	r.reporter = reporter
 	r.createdPaths = map[string]bool{}
+	r.connectionPool = createFTPConnectionPool(maxIdleConnections)
+
+	// Check the credentials. As the FTP package does not support SFTP, there is no key based authentication (yet)
+	if configData.GetValue("ftp", "private_key_file").String() != "" {
+		r.reporter.Error("SFTP is not supported, so the FTP private_key_file is ignored.")
+	}
+	if !r.anonymous && r.password == "" {
+		r.reporter.Error("No FTP credentials configured. Set a password, or set anonymous to true, in the ftp section of the config file.")
+	}
+
+	// Clean the work folder, if needed, before doing anything else
+	if configData.GetValue("ftp", "clean_work_on_start").BoolWithDefault(false) {
+		r.cleanLocalWorkDirectory()
+	}
+
+	// The retention is either a number of postings, or a period such as "24h".
+	// By default, the latest postings are kept, which bounds the history of states, updates, and observations.
+	// A retention of 0 keeps all postings.
+	if retentionCount, err := strconv.Atoi(retention); err == nil && retentionCount >= 0 {
+		r.retentionCount = retentionCount
+	} else if retentionPeriod, err := time.ParseDuration(retention); err == nil && retentionPeriod > 0 {
+		r.retentionPeriod = retentionPeriod
+	} else {
+		r.reporter.Error("Invalid FTP retention: %s. Keeping %s posting(s) per topic path instead.", retention, defaultRetention)
+		r.retentionCount, _ = strconv.Atoi(defaultRetention)
+	}
 
 	// Reporting on the configuration
 	if r.singleServerMode {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in single server mode.")
+		r.reporter.Detail("Running the FTP connection in single server mode.")
 	} else {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in multi server mode.")
+		r.reporter.Detail("Running the FTP connection in multi server mode.")
 	}
 
 	// Reporting on the transfer mode
 	if r.activeTransfers {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in active transfer mode.")
+		r.reporter.Detail("Running the FTP connection in active transfer mode.")
 	} else {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in passive transfer mode.")
+		r.reporter.Detail("Running the FTP connection in passive transfer mode.")
+	}
+
+	// Reporting on the retention of postings
+	if r.retentionCount > 0 {
+		r.reporter.Detail("Keeping at most %d posting(s) per topic path.", r.retentionCount)
+	}
+	if r.retentionPeriod > 0 {
+		r.reporter.Detail("Keeping postings for %s per topic path.", r.retentionPeriod)
 	}
 
 	// Return the created repository connector
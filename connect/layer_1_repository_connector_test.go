@@ -0,0 +1,374 @@
+package connect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestGetTemporaryFileRemovesFailedRetrievals(t *testing.T) {
+	reporter := createTestReporter()
+	r, workFolder := createUnreachableRepositoryConnector(t, reporter.TReporter)
+
+	// Retrieve concurrently, as listeners may do
+	wg := sync.WaitGroup{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if localFilePath := r.getTemporaryFile(tRepositoryEvent{FilePath: "some/path/payload.json"}); localFilePath != "" {
+				t.Errorf("getTemporaryFile() = %q, want \"\" for an unreachable FTP server", localFilePath)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// No temporary files should be left behind
+	entries, err := os.ReadDir(workFolder)
+	if err != nil {
+		t.Fatalf("reading the work folder: %v", err)
+	}
+	for _, entry := range entries {
+		t.Errorf("temporary file %s was left behind", entry.Name())
+	}
+	if len(reporter.reportedErrors()) == 0 {
+		t.Errorf("failed retrievals were not reported")
+	}
+}
+
+func TestGetJSONFromTemporaryFile(t *testing.T) {
+	workFolder := t.TempDir()
+	existingFilePath := filepath.Join(workFolder, "message-1.json")
+	if err := os.WriteFile(existingFilePath, []byte(`{"a":1}`), 0o644); err != nil {
+		t.Fatalf("writing the temporary file: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		tempFilePath  string
+		wantJSON      string
+		wantTimestamp string
+		wantErrors    bool
+	}{
+		{"retrieved file", existingFilePath, `{"a":1}`, "2026-10-15-13-04-05-00", false},
+		{"failed retrieval", "", "", "", false},
+		{"missing file", filepath.Join(workFolder, "message-2.json"), "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			b := TModellingBusConnector{Reporter: reporter.TReporter}
+
+			gotJSON, gotTimestamp := b.getJSONFromTemporaryFile(test.tempFilePath, "2026-10-15-13-04-05-00")
+			if string(gotJSON) != test.wantJSON || gotTimestamp != test.wantTimestamp {
+				t.Errorf("getJSONFromTemporaryFile() = %q, %q, want %q, %q", gotJSON, gotTimestamp, test.wantJSON, test.wantTimestamp)
+			}
+			if gotErrors := len(reporter.reportedErrors()) > 0; gotErrors != test.wantErrors {
+				t.Errorf("reported errors = %v, want errors: %v", reporter.reportedErrors(), test.wantErrors)
+			}
+
+			// The temporary file should be gone afterwards
+			if test.tempFilePath != "" {
+				if _, err := os.Stat(test.tempFilePath); !os.IsNotExist(err) {
+					t.Errorf("temporary file %s was not removed", test.tempFilePath)
+				}
+			}
+		})
+	}
+}
+
+func TestForgetCreatedPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		deletedPath string
+		wantKept    []string
+	}{
+		{"path itself", "prefix/environment/agent/a", []string{"prefix/environment/agent/ab", "prefix/environment/agent/b/c"}},
+		{"path with sub paths", "prefix/environment/agent/b", []string{"prefix/environment/agent/a", "prefix/environment/agent/ab"}},
+		{"whole tree", "prefix/environment", []string{}},
+		{"unknown path", "prefix/other", []string{"prefix/environment/agent/a", "prefix/environment/agent/ab", "prefix/environment/agent/b/c"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := tModellingBusRepositoryConnector{createdPaths: map[string]bool{}}
+			r.markCreatedPath("prefix/environment/agent/a")
+			r.markCreatedPath("prefix/environment/agent/ab")
+			r.markCreatedPath("prefix/environment/agent/b/c")
+
+			r.forgetCreatedPaths(test.deletedPath)
+
+			if len(r.createdPaths) != len(test.wantKept) {
+				t.Errorf("forgetCreatedPaths(%q) kept %v, want %v", test.deletedPath, r.createdPaths, test.wantKept)
+			}
+			for _, keptPath := range test.wantKept {
+				if !r.isCreatedPath(keptPath) {
+					t.Errorf("forgetCreatedPaths(%q) forgot %q", test.deletedPath, keptPath)
+				}
+			}
+		})
+	}
+}
+
+// Run with -race, to check that concurrent postings can safely keep track of the created paths
+func TestCreatedPathsConcurrently(t *testing.T) {
+	r := tModellingBusRepositoryConnector{createdPaths: map[string]bool{}}
+
+	wg := sync.WaitGroup{}
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range 100 {
+				remoteFilePath := fmt.Sprintf("prefix/environment/agent-%d/topic-%d", i%4, j)
+				r.markCreatedPath(remoteFilePath)
+				if !r.isCreatedPath(remoteFilePath) && i%4 != 0 {
+					t.Errorf("path %s was not marked as created", remoteFilePath)
+				}
+				if i%4 == 0 {
+					r.forgetCreatedPaths("prefix/environment/agent-0")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only the paths of the agent whose paths were forgotten may be missing
+	for i := 1; i < 4; i++ {
+		for j := range 100 {
+			if remoteFilePath := fmt.Sprintf("prefix/environment/agent-%d/topic-%d", i, j); !r.isCreatedPath(remoteFilePath) {
+				t.Errorf("path %s was lost", remoteFilePath)
+			}
+		}
+	}
+}
+
+func TestFTPErrorContext(t *testing.T) {
+	r, _ := createUnreachableRepositoryConnector(t, createTestReporter().TReporter)
+	server := r.server + ":" + r.port
+
+	tests := []struct {
+		name          string
+		operate       func() error
+		wantOperation string
+		wantPath      string
+	}{
+		{
+			"retrieving a file",
+			func() error {
+				_, err := r.getFileContent(tRepositoryEvent{Server: r.server, Port: r.port, FilePath: "some/path/payload.json"})
+				return err
+			},
+			FTPOperationRetrieve, "some/path/payload.json",
+		},
+		{
+			"listing a topic path",
+			func() error {
+				_, err := r.listTopicPath("other", "some/path")
+				return err
+			},
+			FTPOperationList, r.ftpAgentTopicPath("other", "some/path"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.operate()
+
+			ftpError := &TFTPError{}
+			if !errors.As(err, &ftpError) {
+				t.Fatalf("error %v is not a TFTPError", err)
+			}
+			if ftpError.Operation != test.wantOperation || ftpError.RemotePath != test.wantPath || ftpError.Server != server {
+				t.Errorf("TFTPError = %s of %s on %s, want %s of %s on %s", ftpError.Operation, ftpError.RemotePath, ftpError.Server, test.wantOperation, test.wantPath, server)
+			}
+			if !strings.Contains(err.Error(), test.wantPath) {
+				t.Errorf("error %q does not mention the path %s", err, test.wantPath)
+			}
+		})
+	}
+}
+
+func TestWrapFTPError(t *testing.T) {
+	underlying := errors.New("550 no such file")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage string
+	}{
+		{"no error", nil, ""},
+		{"error", underlying, "FTP retrieve of some/path/payload.json on localhost:21 failed: 550 no such file"},
+		{"checksum mismatch", errChecksumMismatch, "FTP retrieve of some/path/payload.json on localhost:21 failed: " + errChecksumMismatch.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := wrapFTPError(FTPOperationRetrieve, "localhost:21", "some/path/payload.json", test.err)
+			if test.err == nil {
+				if err != nil {
+					t.Errorf("wrapFTPError() = %v, want nil", err)
+				}
+				return
+			}
+
+			if err.Error() != test.wantMessage {
+				t.Errorf("wrapFTPError() = %q, want %q", err, test.wantMessage)
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("wrapFTPError() = %v, which does not wrap %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRetentionFromConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    []string
+		wantCount  int
+		wantPeriod time.Duration
+		wantErrors bool
+	}{
+		{"default", nil, 10, 0, false},
+		{"number of postings", []string{"retention = 3"}, 3, 0, false},
+		{"keeping all postings", []string{"retention = 0"}, 0, 0, false},
+		{"period", []string{"retention = 24h"}, 0, 24 * time.Hour, false},
+		{"negative number", []string{"retention = -1"}, 10, 0, true},
+		{"invalid", []string{"retention = forever"}, 10, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			r, _ := createUnreachableRepositoryConnector(t, reporter.TReporter, test.content...)
+
+			if r.retentionCount != test.wantCount || r.retentionPeriod != test.wantPeriod {
+				t.Errorf("retention = %d posting(s), %s, want %d posting(s), %s", r.retentionCount, r.retentionPeriod, test.wantCount, test.wantPeriod)
+			}
+			if gotErrors := len(reporter.reportedErrors()) > 0; gotErrors != test.wantErrors {
+				t.Errorf("reported errors = %q, want errors: %v", reporter.reportedErrors(), test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestDecompressToWriter(t *testing.T) {
+	compressed := bytes.Buffer{}
+	compressor := gzip.NewWriter(&compressed)
+	compressor.Write([]byte(`{"model name":"Births"}`))
+	compressor.Close()
+
+	tests := []struct {
+		name         string
+		compressed   []byte
+		maxFileBytes int64
+		wantContent  string
+		wantErr      bool
+	}{
+		{"compressed", compressed.Bytes(), 0, `{"model name":"Births"}`, false},
+		{"within the maximum size", compressed.Bytes(), 23, `{"model name":"Births"}`, false},
+		{"beyond the maximum size", compressed.Bytes(), 10, "", true},
+		{"not compressed", []byte(`{"model name":"Births"}`), 0, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &tModellingBusRepositoryConnector{maxFileBytes: test.maxFileBytes}
+
+			content := bytes.Buffer{}
+			err := r.decompressToWriter(bytes.NewReader(test.compressed), &content)
+			if content.String() != test.wantContent || (err != nil) != test.wantErr {
+				t.Errorf("decompressToWriter() wrote %q, %v, want %q, error: %v", content.String(), err, test.wantContent, test.wantErr)
+			}
+			if test.maxFileBytes > 0 && test.wantErr && !errors.Is(err, errFileTooLarge) {
+				t.Errorf("decompressToWriter() = %v, want %v", err, errFileTooLarge)
+			}
+		})
+	}
+}
+
+func TestRetrieveCompressedFileRemovesTemporaryFile(t *testing.T) {
+	r, workFolder := createUnreachableRepositoryConnector(t, createTestReporter().TReporter)
+
+	_, err := r.getFileContent(tRepositoryEvent{Server: r.server, Port: r.port, FilePath: "some/path/payload.json.gz", Compressed: true})
+	if err == nil {
+		t.Errorf("getFileContent() from an unreachable FTP server gave no error")
+	}
+
+	// The temporary file for the compressed file should be gone
+	entries, err := os.ReadDir(workFolder)
+	if err != nil {
+		t.Fatalf("reading the work folder: %v", err)
+	}
+	for _, entry := range entries {
+		t.Errorf("temporary file %s was left behind", entry.Name())
+	}
+}
+
+func TestFailedStoreAllowsFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		wantFallback bool
+	}{
+		{"small enough", 100, true},
+		{"maximum size", 1000, true},
+		{"too large", 1001, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, _ := createUnreachableRepositoryConnector(t, createTestReporter().TReporter, "max_fallback_bytes = 1000")
+			if r.allowsFallback(test.size) {
+				t.Fatalf("allowsFallback(%d) = true before the FTP server was found to be unreachable", test.size)
+			}
+
+			// Connecting succeeds, as connections are only made once needed, so only storing finds the FTP server unreachable
+			if _, err := r.addJSONAsFileAs("environment", "agent", "some/path", "", []byte(`{"a":1}`), "2026-10-15-13-04-05-00"); err == nil {
+				t.Fatalf("addJSONAsFileAs() on an unreachable FTP server gave no error")
+			}
+			if gotFallback := r.allowsFallback(test.size); gotFallback != test.wantFallback {
+				t.Errorf("allowsFallback(%d) = %v, want %v", test.size, gotFallback, test.wantFallback)
+			}
+		})
+	}
+}
+
+func TestFTPTopicPathIn(t *testing.T) {
+	r, _ := createUnreachableRepositoryConnector(t, createTestReporter().TReporter)
+
+	tests := []struct {
+		name          string
+		environmentID string
+		namespace     string
+		wantSuffix    string
+	}{
+		{"own environment", "environment", "", "/environment/agent/some/path"},
+		{"other environment", "other", "", "/other/agent/some/path"},
+		{"other environment with namespace", "other", "team", "/other/team/agent/some/path"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r.namespace = test.namespace
+
+			wantPath := r.prefix + "/" + generics.ModellingBusVersion + test.wantSuffix
+			if gotPath := r.ftpTopicPathIn(test.environmentID, "some/path"); gotPath != wantPath {
+				t.Errorf("ftpTopicPathIn(%q) = %s, want %s", test.environmentID, gotPath, wantPath)
+			}
+		})
+	}
+}
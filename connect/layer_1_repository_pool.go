@@ -0,0 +1,158 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 1 - Repository Connection Pool
+ *
+ * This component provides a small pool of FTP connections, so that the repository connector does not need to
+ * dial the FTP server for each and every operation.
+ * Idle connections are kept per server address (and user), and are validated before they are reused.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"sync"
+
+	"github.com/secsy/goftp"
+)
+
+/*
+ * Defining the connection pool
+ */
+
+type (
+	tFTPConnectionPool struct {
+		maxIdle int // Maximum number of idle connections to keep per server address
+
+		idleClients map[string][]*goftp.Client // The idle connections, per pool key
+		clientKeys  map[*goftp.Client]string   // The pool key of each handed out connection
+
+		mutex sync.Mutex // Guards the idle connections and pool keys
+	}
+)
+
+/*
+ * Defining pool keys
+ */
+
+// Get the pool key for a given FTP configuration and server address
+func ftpPoolKey(config goftp.Config, serverAddress string) string {
+	return config.User + "@" + serverAddress
+}
+
+/*
+ * Acquiring and releasing connections
+ */
+
+// Pop an idle connection for the given pool key, if any
+func (p *tFTPConnectionPool) popIdleClient(poolKey string) *goftp.Client {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Check if there are any idle connections left
+	idleClients := p.idleClients[poolKey]
+	if len(idleClients) == 0 {
+		return nil
+	}
+
+	// Take the most recently used one, as it is the most likely to still be alive
+	client := idleClients[len(idleClients)-1]
+	p.idleClients[poolKey] = idleClients[:len(idleClients)-1]
+
+	return client
+}
+
+// Acquire a connection to the given FTP server, reusing an idle one when it is still alive
+func (p *tFTPConnectionPool) acquire(config goftp.Config, serverAddress string) (*goftp.Client, error) {
+	poolKey := ftpPoolKey(config, serverAddress)
+
+	// First, try the idle connections
+	for client := p.popIdleClient(poolKey); client != nil; client = p.popIdleClient(poolKey) {
+		// Validate the connection before reusing it
+		if _, err := client.Getwd(); err == nil {
+			p.registerClient(client, poolKey)
+
+			return client, nil
+		}
+
+		// The connection did not survive, so get rid of it
+		client.Close()
+	}
+
+	// No (live) idle connections, so dial a new one
+	client, err := goftp.DialConfig(config, serverAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep track of the pool key of the new connection
+	p.registerClient(client, poolKey)
+
+	return client, nil
+}
+
+// Register the pool key of a handed out connection
+func (p *tFTPConnectionPool) registerClient(client *goftp.Client, poolKey string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.clientKeys[client] = poolKey
+}
+
+// Release a connection back to the pool.
+// When the connection is not reusable (e.g. since an operation failed), or the pool is full, it is closed instead.
+func (p *tFTPConnectionPool) release(client *goftp.Client, reusable bool) {
+	if client == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	poolKey := p.clientKeys[client]
+	delete(p.clientKeys, client)
+
+	// Keep the connection, if we can
+	if reusable && len(p.idleClients[poolKey]) < p.maxIdle {
+		p.idleClients[poolKey] = append(p.idleClients[poolKey], client)
+		p.mutex.Unlock()
+
+		return
+	}
+	p.mutex.Unlock()
+
+	// Otherwise, close it
+	client.Close()
+}
+
+// Close all idle connections in the pool
+func (p *tFTPConnectionPool) closeIdle() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for poolKey, idleClients := range p.idleClients {
+		for _, client := range idleClients {
+			client.Close()
+		}
+		delete(p.idleClients, poolKey)
+	}
+}
+
+/*
+ * Creating connection pools
+ */
+
+// Create a connection pool keeping at most maxIdle idle connections per server address
+func createFTPConnectionPool(maxIdle int) *tFTPConnectionPool {
+	p := tFTPConnectionPool{}
+
+	p.maxIdle = maxIdle
+	p.idleClients = map[string][]*goftp.Client{}
+	p.clientKeys = map[*goftp.Client]string{}
+
+	return &p
+}
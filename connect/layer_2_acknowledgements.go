@@ -0,0 +1,132 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Acknowledgements
+ *
+ * This component provides (opt-in) acknowledgements of postings. A posting that asks for acknowledgements
+ * carries an ack ID in its link, and listeners receiving it post an acknowledgement on a topic path dedicated to
+ * this ack ID. This allows a poster to find out whether anyone is listening. Postings that do not ask for
+ * acknowledgements remain fire-and-forget.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	acknowledgementsPathElement = "acknowledgements" // Acknowledgements path element
+)
+
+/*
+ * Defining acknowledgements
+ */
+
+type (
+	tAcknowledgement struct {
+		AckID   string `json:"ack id"`   // The ack ID of the acknowledged posting
+		AgentID string `json:"agent id"` // The agent acknowledging the posting
+	}
+)
+
+/*
+ * Defining topic paths
+ */
+
+// Defining the topic path for the acknowledgements of a posting, with a given ack ID, by a given agent on a given topic path.
+// As each posting gets its own topic path, concurrent postings do not interfere with each other's subscriptions.
+func acknowledgementsTopicPath(posterAgentID, topicPath, ackID string) string {
+	return acknowledgementsPathElement +
+		"/" + posterAgentID +
+		"/" + topicPath +
+		"/" + ackID
+}
+
+/*
+ * Acknowledging postings
+ */
+
+// Acknowledge the receipt of a posting by a given agent in a given modelling environment, when the poster asked for it
+func (b *TModellingBusConnector) acknowledgePosting(environmentID, agentID, topicPath string, message []byte) {
+	// Only acknowledge postings that ask for it
+	event := tRepositoryEvent{}
+	if json.Unmarshal(message, &event) != nil || event.AckID == "" || b.dryRun {
+		return
+	}
+
+	// Create the acknowledgement
+	acknowledgement := tAcknowledgement{}
+	acknowledgement.AckID = event.AckID
+	acknowledgement.AgentID = b.agentID
+
+	// Convert the acknowledgement to JSON
+	acknowledgementJSON, err := json.Marshal(acknowledgement)
+	if b.Reporter.MaybeReportError("Something went wrong JSONing the acknowledgement:", err) {
+		return
+	}
+
+	// Post the acknowledgement, without retaining it
+	b.modellingBusEventsConnector.postTransientEventIn(environmentID, acknowledgementsTopicPath(agentID, topicPath, event.AckID), acknowledgementJSON)
+}
+
+// Post something on a given topic path, using the given posting function, asking for acknowledgements.
+// Returns the number of (distinct) agents that acknowledged the posting within the timeout. Returns as soon as
+// the expected number of agents acknowledged the posting, or only at the timeout when this number is 0.
+func (b *TModellingBusConnector) postWithAcknowledgements(topicPath string, expectedAcks int, timeout time.Duration, post func(string) error) (int, error) {
+	ackID := b.GetNewID()
+
+	// Keep track of the agents that acknowledged the posting, and signal when the expected number is reached
+	acknowledgingAgents := map[string]bool{}
+	acknowledgingAgentsMutex := sync.Mutex{}
+	allAcknowledged := make(chan bool)
+
+	// Listen for the acknowledgements by any agent before posting, so none are missed
+	acknowledgementsTopic := acknowledgementsTopicPath(b.agentID, topicPath, ackID)
+	b.modellingBusEventsConnector.listenForEvents("+", acknowledgementsTopic, func(message []byte) {
+		acknowledgement := tAcknowledgement{}
+		if json.Unmarshal(message, &acknowledgement) == nil && acknowledgement.AckID == ackID {
+			acknowledgingAgentsMutex.Lock()
+			defer acknowledgingAgentsMutex.Unlock()
+
+			if !acknowledgingAgents[acknowledgement.AgentID] {
+				acknowledgingAgents[acknowledgement.AgentID] = true
+				if len(acknowledgingAgents) == expectedAcks {
+					close(allAcknowledged)
+				}
+			}
+		}
+	})
+	defer b.modellingBusEventsConnector.stopListeningForEvents("+", acknowledgementsTopic)
+
+	// Post, asking for acknowledgements
+	if err := post(ackID); err != nil {
+		return 0, err
+	}
+
+	// Give the listeners the time to acknowledge the posting
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-allAcknowledged:
+	case <-timer.C:
+	}
+
+	acknowledgingAgentsMutex.Lock()
+	defer acknowledgingAgentsMutex.Unlock()
+
+	return len(acknowledgingAgents), nil
+}
@@ -0,0 +1,87 @@
+package connect
+
+import (
+	"testing"
+	"time"
+)
+
+// Acknowledge a posting, asking for acknowledgements with the given ack ID, on behalf of the given agent
+func acknowledgeTestPosting(b TModellingBusConnector, acknowledgingAgentID, topicPath, ackID string) {
+	b.agentID = acknowledgingAgentID
+	b.acknowledgePosting("environment", "agent", topicPath, []byte(`{"ack id": "`+ackID+`"}`))
+}
+
+func TestPostWithAcknowledgements(t *testing.T) {
+	tests := []struct {
+		name         string
+		ackingAgents []string
+		expectedAcks int
+		timeout      time.Duration
+		wantAcks     int
+		wantEarly    bool
+	}{
+		{"all expected acknowledgements", []string{"one", "two"}, 2, time.Minute, 2, true},
+		{"repeated acknowledgements", []string{"one", "one", "two"}, 2, time.Minute, 2, true},
+		{"missing acknowledgements", []string{"one"}, 2, 50 * time.Millisecond, 1, false},
+		{"no expected acknowledgements", []string{"one", "two"}, 0, 50 * time.Millisecond, 2, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := createOfflineModellingBusConnector(t, createTestReporter().TReporter)
+			connectToFakeMQTTBroker(b)
+
+			start := time.Now()
+			gotAcks, err := b.postWithAcknowledgements("some/path", test.expectedAcks, test.timeout, func(ackID string) error {
+				for _, agentID := range test.ackingAgents {
+					acknowledgeTestPosting(b, agentID, "some/path", ackID)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("postWithAcknowledgements() = %v, want no error", err)
+			}
+			if gotAcks != test.wantAcks {
+				t.Errorf("postWithAcknowledgements() = %d, want %d", gotAcks, test.wantAcks)
+			}
+			if gotEarly := time.Since(start) < test.timeout; gotEarly != test.wantEarly {
+				t.Errorf("returned before the timeout: %v, want %v", gotEarly, test.wantEarly)
+			}
+		})
+	}
+}
+
+func TestConcurrentPostsWithAcknowledgements(t *testing.T) {
+	b := createOfflineModellingBusConnector(t, createTestReporter().TReporter)
+	connectToFakeMQTTBroker(b)
+
+	// The first posting is only acknowledged after the second posting is done, and stopped listening
+	release := make(chan bool)
+	firstAcks := make(chan int)
+	go func() {
+		acks, _ := b.postWithAcknowledgements("some/path", 1, time.Minute, func(ackID string) error {
+			go func() {
+				<-release
+				acknowledgeTestPosting(b, "one", "some/path", ackID)
+			}()
+
+			return nil
+		})
+		firstAcks <- acks
+	}()
+
+	secondAcks, _ := b.postWithAcknowledgements("some/path", 1, time.Minute, func(ackID string) error {
+		acknowledgeTestPosting(b, "two", "some/path", ackID)
+
+		return nil
+	})
+	if secondAcks != 1 {
+		t.Errorf("second postWithAcknowledgements() = %d, want 1", secondAcks)
+	}
+
+	close(release)
+	if gotAcks := <-firstAcks; gotAcks != 1 {
+		t.Errorf("first postWithAcknowledgements() = %d, want 1", gotAcks)
+	}
+}
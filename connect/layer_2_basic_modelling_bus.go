@@ -12,7 +12,7 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 12.12.2025
+ * Version of: 15.10.2026
  *
  */
 
@@ -20,7 +20,13 @@ package connect
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
@@ -37,6 +43,22 @@ type (
 		agentID       string // The Agent ID to be used in postings on the BIG Modelling Bus
 		environmentID string // The Modelling environment ID
 
+		postingAgentID       string // The agent on whose behalf postings are made, e.g. when mirroring (empty means our own agent)
+		postingEnvironmentID string // The modelling environment in which postings are made (empty means our own environment)
+
+		dryRun bool // Whether to only report what would be posted, without actually posting it
+
+		metrics *tMetricsCollector // The collected metrics (nil when metrics are not collected)
+
+		rateLimiter *tRateLimiter // The rate limiter for postings (nil when postings are not rate limited)
+
+		contentCache *tContentCache // The cache of retrieved content (nil when retrieved content is not cached)
+
+		outbox *tOutbox // The outbox of postings to be retried while the FTP server is unavailable (nil when there is no outbox)
+
+		stopCleaningUpOnPanic func() // Deregisters the clean up before panicking from the Reporter
+		stopCountingErrors    func() // Deregisters the counting of errors from the Reporter (nil when metrics are not collected)
+
 		Reporter   *generics.TReporter   // The Reporter to be used to report progress, error, and panics
 		configData *generics.TConfigData // The configuration data to be used
 	}
@@ -48,73 +70,379 @@ type (
 
 type (
 	tStreamedEvent struct {
-		Timestamp string          `json:"timestamp"` // Timestamp of the event
-		Payload   json.RawMessage `json:"payload"`   // The actual payload of the streamed event
+		Timestamp   string          `json:"timestamp"`              // Timestamp of the event
+		Payload     json.RawMessage `json:"payload"`                // The actual payload of the streamed event
+		JSONVersion string          `json:"json version,omitempty"` // JSON version of the payload, for versioned JSON postings
+	}
+)
+
+/*
+ * Defining posting information
+ */
+
+type (
+	// Information on where a received posting came from, e.g. for diagnosing provenance in multi-server setups
+	TPostingInfo struct {
+		Server     string // The FTP server the posting was retrieved from (empty for postings made inline)
+		Port       string // The port on the FTP server
+		RemotePath string // The path of the posting on the FTP server
+		Timestamp  string // The timestamp of the posting
+		Format     string // The format of the posting (such as "png" or "xml"), for raw postings that mention it
+		Size       int64  // The size of the posting (in bytes)
 	}
 )
 
+/*
+ * Defining errors
+ */
+
+// The errors that posting may fail with, so callers can check for them using errors.Is
+var (
+	ErrFTPUpload   = errors.New("uploading to the FTP repository failed")
+	ErrMQTTPublish = errors.New("publishing on the MQTT broker failed")
+	ErrMarshal     = errors.New("JSONing the posting failed")
+	ErrRateLimited = errors.New("posting rate limit exceeded")
+)
+
+// The errors that retrieving may fail with, so callers can check for them using errors.Is
+var (
+	ErrTimeout   = errors.New("timed out waiting for a posting")
+	ErrRetrieve  = errors.New("retrieving the posting failed")
+	ErrNoPosting = errors.New("nothing has been posted (yet)")
+)
+
+// The errors that deleting may fail with, so callers can check for them using errors.Is
+var (
+	ErrDelete = errors.New("deleting from the FTP repository failed")
+)
+
+/*
+ * Topic elements
+ */
+
+// Get the (sanitised) topic element for an ID, such as an artefact or observation ID, reporting empty IDs
+func (b *TModellingBusConnector) topicElement(kind, id string) string {
+	if id == "" {
+		b.Reporter.Error("The %s ID should not be empty.", kind)
+	}
+
+	return generics.SanitizeTopicElement(id)
+}
+
+/*
+ * Dry runs
+ */
+
+// Report what would have been posted, when doing a dry run
+func (b *TModellingBusConnector) reportDryRunPosting(kind, topicPath string, size int64, timestamp string) bool {
+	if b.dryRun {
+		b.Reporter.Progress(generics.ProgressLevelBasic, "Dry run: would post %s on %s (%d bytes, timestamp %s).", kind, topicPath, size, timestamp)
+	}
+
+	return b.dryRun
+}
+
+// Report what would have been deleted, when doing a dry run
+func (b *TModellingBusConnector) reportDryRunDeletion(kind, path string) bool {
+	if b.dryRun {
+		b.Reporter.Progress(generics.ProgressLevelBasic, "Dry run: would delete %s %s.", kind, path)
+	}
+
+	return b.dryRun
+}
+
 /*
  * Posting things
  */
 
+// Get the agent on whose behalf postings are made
+func (b *TModellingBusConnector) postingAgent() string {
+	if b.postingAgentID != "" {
+		return b.postingAgentID
+	}
+
+	return b.agentID
+}
+
+// Get a copy of the connector that posts on behalf of the given agent (empty means our own agent)
+func (b TModellingBusConnector) postingAs(agentID string) TModellingBusConnector {
+	b.postingAgentID = agentID
+
+	return b
+}
+
+// Get the modelling environment in which postings are made
+func (b *TModellingBusConnector) postingEnvironment() string {
+	if b.postingEnvironmentID != "" {
+		return b.postingEnvironmentID
+	}
+
+	return b.environmentID
+}
+
+// Get the format of a file (such as "png" or "xml"), as derived from its extension
+func formatOfExtension(extension string) string {
+	return strings.ToLower(strings.TrimPrefix(extension, "."))
+}
+
 // Posting a file to the repository and announcing it on the modelling bus
-func (b *TModellingBusConnector) postFile(topicPath, localFilePath, timestamp string) {
+func (b *TModellingBusConnector) postFile(topicPath, localFilePath, timestamp string) error {
+	return b.postFormattedFile(topicPath, localFilePath, "", "", timestamp)
+}
+
+// Posting a file to the repository, using the given name for the payload file (empty means the default name), and announcing it,
+// including its format (if given), on the modelling bus
+func (b *TModellingBusConnector) postFormattedFile(topicPath, localFilePath, payloadFileName, format, timestamp string) error {
+	// When doing a dry run, only report on the posting
+	if b.dryRun {
+		fileSize := int64(0)
+		if fileInfo, err := os.Stat(localFilePath); err == nil {
+			fileSize = fileInfo.Size()
+		}
+		b.reportDryRunPosting("file", topicPath, fileSize, timestamp)
+
+		return nil
+	}
+
+	// Respect the rate limit
+	if err := b.checkRateLimit(topicPath); err != nil {
+		return err
+	}
+
 	// First, add the file to the repository
-	event := b.modellingBusRepositoryConnector.addFile(topicPath, localFilePath, timestamp)
+	if payloadFileName == "" {
+		payloadFileName = generics.PayloadFileName
+	}
+	event, err := b.modellingBusRepositoryConnector.addFileAs(b.postingEnvironment(), b.postingAgent(), topicPath, localFilePath, payloadFileName, timestamp)
+	if err != nil {
+		return err
+	}
+	event.Format = format
 
 	// Then convert the event to JSON
 	message, err := json.Marshal(event)
 
 	// Post the event, if no error occurred during marshalling
-	b.modellingBusEventsConnector.maybePostEvent(topicPath, message, "Something went wrong JSONing the file link data:", err)
+	err = b.modellingBusEventsConnector.maybePostEventIn(b.postingEnvironment(), b.postingAgent(), topicPath, message, "Something went wrong JSONing the file link data:", err)
+	if err == nil && b.metrics != nil {
+		if fileInfo, statErr := os.Stat(localFilePath); statErr == nil {
+			b.metrics.countPosting(topicPath, fileInfo.Size())
+		}
+	}
+
+	return err
+}
+
+// Posting the content read from a reader to the repository, as a payload file with the given name (empty means the default name with
+// the given extension), and announcing it, including its format (if given), on the modelling bus
+func (b *TModellingBusConnector) postFormattedReader(topicPath string, reader io.Reader, payloadFileName, extension, format, timestamp string) error {
+	// When doing a dry run, only report on the posting
+	if b.dryRun {
+		size, err := io.Copy(io.Discard, reader)
+		if b.Reporter.MaybeReportError("Error reading the content to be posted:", err) {
+			return err
+		}
+		b.reportDryRunPosting("file", topicPath, size, timestamp)
+
+		return nil
+	}
+
+	// Respect the rate limit
+	if err := b.checkRateLimit(topicPath); err != nil {
+		return err
+	}
+
+	// Count the bytes posted, when collecting metrics
+	countingReader := &tCountingReader{reader: reader}
+	if b.metrics != nil {
+		reader = countingReader
+	}
+
+	// First, add the content to the repository
+	if payloadFileName == "" {
+		payloadFileName = generics.PayloadFileName + extension
+	}
+	event, err := b.modellingBusRepositoryConnector.addReaderAs(b.postingEnvironment(), b.postingAgent(), topicPath, reader, payloadFileName, timestamp)
+	if err != nil {
+		return err
+	}
+	event.Format = format
+
+	// Then convert the event to JSON
+	message, err := json.Marshal(event)
+
+	// Post the event, if no error occurred during marshalling
+	err = b.modellingBusEventsConnector.maybePostEventIn(b.postingEnvironment(), b.postingAgent(), topicPath, message, "Something went wrong JSONing the file link data:", err)
+	if err == nil {
+		b.metrics.countPosting(topicPath, countingReader.count)
+	}
+
+	return err
 }
 
 // Posting a JSON message as a file to the repository and announcing it on the modelling bus
-func (b *TModellingBusConnector) postJSONAsFile(topicPath string, jsonMessage []byte, timestamp string) {
+func (b *TModellingBusConnector) postJSONAsFile(topicPath string, jsonMessage []byte, timestamp string) error {
+	return b.postVersionedJSONAsFile(topicPath, "", jsonMessage, timestamp)
+}
+
+// Posting a JSON message in a given JSON version as a file to the repository and announcing it, including the JSON version, on the modelling bus
+func (b *TModellingBusConnector) postVersionedJSONAsFile(topicPath, jsonVersion string, jsonMessage []byte, timestamp string) error {
+	return b.postVersionedJSONAsFileWithAckID(topicPath, jsonVersion, "", jsonMessage, timestamp)
+}
+
+// Posting a JSON message in a given JSON version as a file to the repository and announcing it, including the JSON version
+// and the ack ID with which listeners should acknowledge receipt (if not empty), on the modelling bus
+func (b *TModellingBusConnector) postVersionedJSONAsFileWithAckID(topicPath, jsonVersion, ackID string, jsonMessage []byte, timestamp string) error {
+	return b.postNamedVersionedJSONAsFile(topicPath, "", jsonVersion, ackID, jsonMessage, timestamp)
+}
+
+// Posting a JSON message in a given JSON version as a file, using the given name for the payload file (empty means the default name),
+// to the repository and announcing it, including the JSON version and the ack ID (if not empty), on the modelling bus
+func (b *TModellingBusConnector) postNamedVersionedJSONAsFile(topicPath, payloadFileName, jsonVersion, ackID string, jsonMessage []byte, timestamp string) error {
+	// When doing a dry run, only report on the posting
+	if b.reportDryRunPosting("JSON file", topicPath, int64(len(jsonMessage)), timestamp) {
+		return nil
+	}
+
+	// While the FTP server is unreachable, small JSON messages are posted inline instead
+	if b.fallsBackToInline(topicPath, jsonMessage) {
+		return b.postVersionedJSONAsStreamed(topicPath, jsonVersion, jsonMessage, timestamp)
+	}
+
+	// Respect the rate limit
+	if err := b.checkRateLimit(topicPath); err != nil {
+		return err
+	}
+
+	// Keep the order of the postings, by queueing behind the postings still waiting in the outbox
+	outboxPosting := tOutboxPosting{EnvironmentID: b.postingEnvironmentID, AgentID: b.postingAgentID, TopicPath: topicPath, PayloadFileName: payloadFileName, JSONVersion: jsonVersion, AckID: ackID, Payload: jsonMessage, Timestamp: timestamp}
+	if b.outbox.hasPending() {
+		return b.outbox.enqueue(outboxPosting)
+	}
+
+	// Add the JSON as a file to the repository, and announce it on the modelling bus
+	err := b.addAndAnnounceJSONAsFile(topicPath, payloadFileName, jsonVersion, ackID, jsonMessage, timestamp)
+	if errors.Is(err, ErrFTPUpload) {
+		// The FTP server may just have become unreachable
+		if b.fallsBackToInline(topicPath, jsonMessage) {
+			return b.postVersionedJSONAsStreamed(topicPath, jsonVersion, jsonMessage, timestamp)
+		}
+
+		// Keep the posting in the outbox, to be retried later
+		if b.outbox != nil {
+			return b.outbox.enqueue(outboxPosting)
+		}
+	}
+
+	return err
+}
+
+// Adding a JSON message in a given JSON version as a file, using the given name for the payload file (empty means the default name),
+// to the repository and announcing it, including the JSON version and the ack ID (if not empty), on the modelling bus
+func (b *TModellingBusConnector) addAndAnnounceJSONAsFile(topicPath, payloadFileName, jsonVersion, ackID string, jsonMessage []byte, timestamp string) error {
 	// First, add the JSON as a file to the repository
-	event := b.modellingBusRepositoryConnector.addJSONAsFile(topicPath, jsonMessage, timestamp)
+	event, err := b.modellingBusRepositoryConnector.addJSONAsFileAs(b.postingEnvironment(), b.postingAgent(), topicPath, payloadFileName, jsonMessage, timestamp)
+	if err != nil {
+		return err
+	}
+	event.JSONVersion = jsonVersion
+	event.AckID = ackID
 
 	// Then convert the event to JSON
 	message, err := json.Marshal(event)
 
 	// Post the event, if no error occurred during marshalling
-	b.modellingBusEventsConnector.maybePostEvent(topicPath, message, "Something went wrong JSONing the file link data:", err)
+	err = b.modellingBusEventsConnector.maybePostEventIn(b.postingEnvironment(), b.postingAgent(), topicPath, message, "Something went wrong JSONing the file link data:", err)
+	if err == nil {
+		b.metrics.countPosting(topicPath, int64(len(jsonMessage)))
+	}
+
+	return err
+}
+
+// Check whether a JSON message should be posted inline, as the FTP server is unreachable and the message is small enough
+// (see "max_fallback_bytes" in the "ftp" section of the config file). Larger messages still fail while the FTP server is unreachable.
+func (b *TModellingBusConnector) fallsBackToInline(topicPath string, jsonMessage []byte) bool {
+	if !b.modellingBusRepositoryConnector.allowsFallback(len(jsonMessage)) || !b.PayloadFitsInline(jsonMessage) {
+		return false
+	}
+
+	b.Reporter.Progress(generics.ProgressLevelBasic, "Warning: the FTP server is unreachable. Posting on %s inline instead.", topicPath)
+
+	return true
+}
+
+// Posting a JSON message in a given JSON version to the modelling bus, inline when it is small enough, and as a file otherwise
+func (b *TModellingBusConnector) postVersionedJSON(topicPath, jsonVersion string, jsonMessage []byte, timestamp string) error {
+	return b.postNamedVersionedJSON(topicPath, "", jsonVersion, jsonMessage, timestamp)
 }
 
-// Posting a JSON message as a file to the modelling bus
-func (b *TModellingBusConnector) maybePostJSONAsFile(topicPath string, jsonMessage []byte, timestamp, errorMessage string, err error) {
+// Posting a JSON message in a given JSON version to the modelling bus, inline when it is small enough, and as a file otherwise,
+// using the given name for the payload file (empty means the default name)
+func (b *TModellingBusConnector) postNamedVersionedJSON(topicPath, payloadFileName, jsonVersion string, jsonMessage []byte, timestamp string) error {
+	// Small messages are posted inline, skipping the repository
+	if b.modellingBusEventsConnector.allowsInline(len(jsonMessage)) {
+		return b.postVersionedJSONAsStreamed(topicPath, jsonVersion, jsonMessage, timestamp)
+	}
+
+	return b.postNamedVersionedJSONAsFile(topicPath, payloadFileName, jsonVersion, "", jsonMessage, timestamp)
+}
+
+// Posting a JSON message in a given JSON version to the modelling bus, inline when it is small enough, and as a file otherwise
+func (b *TModellingBusConnector) maybePostVersionedJSON(topicPath, payloadFileName, jsonVersion string, jsonMessage []byte, timestamp, errorMessage string, err error) error {
 	// Handle potential errors
 	if b.Reporter.MaybeReportError(errorMessage, err) {
-		return
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
 	}
 
-	// Post JSON as a file
-	b.postJSONAsFile(topicPath, jsonMessage, timestamp)
+	// Post the JSON
+	return b.postNamedVersionedJSON(topicPath, payloadFileName, jsonVersion, jsonMessage, timestamp)
 }
 
 // Posting a JSON message as a streamed event on the modelling bus
-func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessage []byte, timestamp string) {
+func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessage []byte, timestamp string) error {
+	return b.postVersionedJSONAsStreamed(topicPath, "", jsonMessage, timestamp)
+}
+
+// Posting a JSON message in a given JSON version as a streamed event on the modelling bus
+func (b *TModellingBusConnector) postVersionedJSONAsStreamed(topicPath, jsonVersion string, jsonMessage []byte, timestamp string) error {
+	// When doing a dry run, only report on the posting
+	if b.reportDryRunPosting("streamed JSON", topicPath, int64(len(jsonMessage)), timestamp) {
+		return nil
+	}
+
+	// Respect the rate limit
+	if err := b.checkRateLimit(topicPath); err != nil {
+		return err
+	}
+
 	// Create the streamed event
 	event := tStreamedEvent{}
 	event.Timestamp = timestamp
 	event.Payload = jsonMessage
+	event.JSONVersion = jsonVersion
 
 	// Convert the event to JSON
 	message, err := json.Marshal(event)
 
 	// Post the event, if no error occurred during marshalling
-	b.modellingBusEventsConnector.maybePostEvent(topicPath, message, "Something went wrong JSONing the file link data:", err)
+	err = b.modellingBusEventsConnector.maybePostEventIn(b.postingEnvironment(), b.postingAgent(), topicPath, message, "Something went wrong JSONing the file link data:", err)
+	if err == nil {
+		b.metrics.countPosting(topicPath, int64(len(jsonMessage)))
+	}
+
+	return err
 }
 
 /*
  * Retrieving things
  */
 
-// Get a linked file from the repository, given the message from the modelling bus
-func (b *TModellingBusConnector) getLinkedFileFromRepository(message []byte, localFileName string) (string, string) {
-	// If no message is given, return empty values
+// Get the repository event from a message from the modelling bus
+func (b *TModellingBusConnector) repositoryEventFromMessage(message []byte) (tRepositoryEvent, bool) {
+	// If no message is given, there is no event
 	if len(message) == 0 {
-		return "", ""
+		return tRepositoryEvent{}, false
 	}
 
 	// Unmarshal the message to get the repository event
@@ -123,6 +451,17 @@ func (b *TModellingBusConnector) getLinkedFileFromRepository(message []byte, loc
 
 	// Handle potential errors
 	if b.Reporter.MaybeReportError("Something went wrong unmarshalling the repository event:", err) {
+		return tRepositoryEvent{}, false
+	}
+
+	return event, true
+}
+
+// Get a linked file from the repository, given the message from the modelling bus
+func (b *TModellingBusConnector) getLinkedFileFromRepository(message []byte, localFileName string) (string, string) {
+	// Get the repository event
+	event, ok := b.repositoryEventFromMessage(message)
+	if !ok {
 		return "", ""
 	}
 
@@ -135,8 +474,46 @@ func (b *TModellingBusConnector) getFileFromPosting(agentID, topicPath, localFil
 	return b.getLinkedFileFromRepository(b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath), localFileName)
 }
 
+// Stream a linked file from a posting on the modelling bus to the given writer, without keeping the whole file in memory.
+// Returns the timestamp of the posting, ErrNoPosting when nothing has been posted (yet), and reports (and returns) the
+// error when retrieving the posting failed.
+func (b *TModellingBusConnector) getFileFromPostingToWriter(agentID, topicPath string, writer io.Writer) (string, error) {
+	// Get the message from the modelling bus
+	message := b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath)
+
+	// Without a message, nothing has been posted
+	if len(message) == 0 {
+		return "", ErrNoPosting
+	}
+
+	// Get the repository event
+	event, ok := b.repositoryEventFromMessage(message)
+	if !ok {
+		return "", ErrRetrieve
+	}
+
+	// Stream the file from the repository to the writer, counting the bytes retrieved
+	countingWriter := &tCountingWriter{writer: writer}
+	err := b.modellingBusRepositoryConnector.retrieveToWriter(event, countingWriter)
+
+	// Handle potential errors
+	if b.Reporter.MaybeReportError("Something went wrong retrieving the posted file:", err) {
+		return "", fmt.Errorf("%w: %w", ErrRetrieve, err)
+	}
+
+	// Count the retrieval
+	b.metrics.countRetrieval(countingWriter.count)
+
+	return event.Timestamp, nil
+}
+
 // Get JSON from a temporary file
 func (b *TModellingBusConnector) getJSONFromTemporaryFile(tempFilePath, timestamp string) ([]byte, string) {
+	// Without a temporary file, retrieving the file failed, which has been reported already
+	if tempFilePath == "" {
+		return []byte{}, ""
+	}
+
 	// Read the JSON payload from the temporary file
 	jsonPayload, err := os.ReadFile(tempFilePath)
 	os.Remove(tempFilePath)
@@ -152,22 +529,130 @@ func (b *TModellingBusConnector) getJSONFromTemporaryFile(tempFilePath, timestam
 	return jsonPayload, timestamp
 }
 
-// Get JSON from the repository, given a posting on the modelling bus
+// Get the JSON that has been posted inline in a message from the modelling bus, if any
+func (b *TModellingBusConnector) inlineJSONFromMessage(message []byte) (tStreamedEvent, bool) {
+	// Inline postings carry the JSON as their payload, while other postings link to a file in the repository
+	event := tStreamedEvent{}
+	if json.Unmarshal(message, &event) != nil || len(event.Payload) == 0 {
+		return tStreamedEvent{}, false
+	}
+
+	return event, true
+}
+
+// Get JSON, its timestamp, and its JSON version, from a message on the modelling bus, be it posted inline or as a file in the repository
+func (b *TModellingBusConnector) getJSONFromMessage(message []byte) ([]byte, string, string) {
+	// Get inline JSON directly from the message
+	if event, isInline := b.inlineJSONFromMessage(message); isInline {
+		return event.Payload, event.Timestamp, event.JSONVersion
+	}
+
+	// Get the repository event
+	event, ok := b.repositoryEventFromMessage(message)
+	if !ok {
+		return []byte{}, "", ""
+	}
+
+	// Files that have been retrieved before may be cached
+	if jsonPayload, cached := b.contentCache.get(cacheKeyOf(event)); cached {
+		b.metrics.countCacheHit()
+
+		return jsonPayload, event.Timestamp, event.JSONVersion
+	}
+
+	// Get the JSON from the repository, and cache it
+	jsonPayload, timestamp := b.getJSONFromTemporaryFile(b.modellingBusRepositoryConnector.getTemporaryFile(event), event.Timestamp)
+	if len(jsonPayload) > 0 {
+		b.contentCache.put(cacheKeyOf(event), jsonPayload)
+	}
+
+	return jsonPayload, timestamp, event.JSONVersion
+}
+
+// Get JSON from the repository, given a posting on the modelling bus.
+// Returns empty JSON when nothing has been posted, or when retrieving the posting failed.
 func (b *TModellingBusConnector) getJSON(agentID, topicPath string) ([]byte, string) {
-	// Get the linked file from the repository
-	tempFilePath, timestamp := b.getLinkedFileFromRepository(b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath), generics.JSONFileName)
+	jsonPayload, timestamp, err := b.getJSONE(agentID, topicPath)
+	if err != nil {
+		return []byte{}, ""
+	}
+
+	return jsonPayload, timestamp
+}
+
+// Get JSON from the repository, given a posting on the modelling bus.
+// Returns ErrNoPosting when nothing has been posted (yet), and reports (and returns) the error when retrieving the posting failed.
+func (b *TModellingBusConnector) getJSONE(agentID, topicPath string) ([]byte, string, error) {
+	// Get the message from the modelling bus
+	message := b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath)
+
+	// Without a message, nothing has been posted
+	if len(message) == 0 {
+		return nil, "", ErrNoPosting
+	}
+
+	// Get inline JSON directly from the message
+	if event, isInline := b.inlineJSONFromMessage(message); isInline {
+		b.metrics.countRetrieval(int64(len(event.Payload)))
+
+		return event.Payload, event.Timestamp, nil
+	}
+
+	// Get the repository event
+	event, ok := b.repositoryEventFromMessage(message)
+	if !ok {
+		return nil, "", ErrRetrieve
+	}
+
+	// Files that have been retrieved before may be cached
+	if jsonPayload, cached := b.contentCache.get(cacheKeyOf(event)); cached {
+		b.metrics.countCacheHit()
+
+		return jsonPayload, event.Timestamp, nil
+	}
+
+	// Get the linked file from the repository.
+	// Without a temporary file, retrieving the file failed, which has been reported already.
+	tempFilePath := b.modellingBusRepositoryConnector.getTemporaryFile(event)
+	if tempFilePath == "" {
+		return nil, "", ErrRetrieve
+	}
 
 	// Read the JSON payload from the temporary file
 	jsonPayload, err := os.ReadFile(tempFilePath)
 	os.Remove(tempFilePath)
 
-	// Handle potential errors silently
-	if err != nil {
-		return []byte{}, ""
+	// Handle potential errors
+	if b.Reporter.MaybeReportError("Something went wrong retrieving the posted JSON:", err) {
+		return nil, "", fmt.Errorf("%w: %w", ErrRetrieve, err)
 	}
 
+	// Count, and cache, the retrieval
+	b.metrics.countRetrieval(int64(len(jsonPayload)))
+	b.contentCache.put(cacheKeyOf(event), jsonPayload)
+
 	// Return the JSON payload and timestamp
-	return jsonPayload, timestamp
+	return jsonPayload, event.Timestamp, nil
+}
+
+// Await JSON from the repository, given the (first) posting on the modelling bus
+func (b *TModellingBusConnector) awaitJSON(agentID, topicPath string, timeout time.Duration) ([]byte, string, error) {
+	// Await the posting
+	message, ok := b.modellingBusEventsConnector.awaitMessage(agentID, topicPath, timeout)
+	if !ok {
+		return nil, "", ErrTimeout
+	}
+
+	// Get the JSON payload
+	jsonPayload, timestamp, _ := b.getJSONFromMessage(message)
+	if len(jsonPayload) == 0 {
+		return nil, "", ErrRetrieve
+	}
+
+	// Count the retrieval
+	b.metrics.countRetrieval(int64(len(jsonPayload)))
+
+	return jsonPayload, timestamp, nil
 }
 
 // Split a streamed event from the message into Payload and Timestamp
@@ -190,23 +675,130 @@ func (b *TModellingBusConnector) getStreamedEvent(agentID, topicPath string) ([]
 	return b.splitStreamedEventFromMessage(b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath))
 }
 
+// Get the timestamps of the postings (still) stored in the repository for a given agent and topic path, from old to new.
+// This assumes the agent posts on the same FTP server as we do (e.g. in single server mode).
+func (b *TModellingBusConnector) getPostingTimestamps(agentID, topicPath string) ([]string, error) {
+	return b.getPostingTimestampsIn(b.environmentID, agentID, topicPath)
+}
+
+// Get the timestamps of the postings (still) stored in the repository for a given agent and topic path, in a given modelling environment
+func (b *TModellingBusConnector) getPostingTimestampsIn(environmentID, agentID, topicPath string) ([]string, error) {
+	// Get the entries of the topic path
+	entries, err := b.modellingBusRepositoryConnector.listTopicPathIn(environmentID, agentID, topicPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each posting is stored in a folder named after its timestamp
+	timestamps := []string{}
+	for _, entry := range entries {
+		if _, isTimestamp := generics.TimestampTime(entry); isTimestamp {
+			timestamps = append(timestamps, entry)
+		}
+	}
+
+	// Return them from old to new
+	slices.SortFunc(timestamps, generics.CompareTimestamps)
+
+	return timestamps, nil
+}
+
+// Get the content of the posting with the given timestamp, for a given agent and topic path
+func (b *TModellingBusConnector) getPostingContent(agentID, topicPath, timestamp string) ([]byte, error) {
+	return b.getPostingContentIn(b.environmentID, agentID, topicPath, timestamp)
+}
+
+// Get the content of the posting with the given timestamp, for a given agent and topic path, in a given modelling environment
+func (b *TModellingBusConnector) getPostingContentIn(environmentID, agentID, topicPath, timestamp string) ([]byte, error) {
+	// Get the repository event for the posting
+	event, err := b.modellingBusRepositoryConnector.postingEventFor(environmentID, agentID, topicPath, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the content of the posting
+	return b.modellingBusRepositoryConnector.getFileContent(event)
+}
+
 /*
  * Listening for postings
  */
 
+// Get the information on where a posting came from, given the message from the modelling bus
+func (b *TModellingBusConnector) postingInfoFromMessage(message []byte) TPostingInfo {
+	// Both file links and inline postings have a timestamp, while only file links refer to a server
+	event := tRepositoryEvent{}
+	json.Unmarshal(message, &event)
+
+	postingInfo := TPostingInfo{}
+	postingInfo.Server = event.Server
+	postingInfo.Port = event.Port
+	postingInfo.RemotePath = event.FilePath
+	postingInfo.Timestamp = event.Timestamp
+	postingInfo.Format = event.Format
+
+	return postingInfo
+}
+
 // Listen for raw file postings on the modelling bus
-func (b *TModellingBusConnector) listenForFilePostings(agentID, topicPath, localFileName string, postingHandler func(string, string)) {
+func (b *TModellingBusConnector) listenForFilePostings(agentID, topicPath string, postingHandler func(string, string)) {
+	b.listenForFilePostingsWithInfo(agentID, topicPath, func(localFilePath string, postingInfo TPostingInfo) {
+		postingHandler(localFilePath, postingInfo.Timestamp)
+	})
+}
+
+// Listen for raw file postings on the modelling bus, also passing on where the posting came from.
+// Each posting is retrieved into a uniquely named temporary file, so concurrent postings do not overwrite each other's file.
+// The temporary file is removed once the posting handler returns.
+func (b *TModellingBusConnector) listenForFilePostingsWithInfo(agentID, topicPath string, postingHandler func(string, TPostingInfo)) {
 	// Listen for raw file related events on the modelling bus
 	b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(message []byte) {
-		postingHandler(b.getLinkedFileFromRepository(message, localFileName))
+		localFilePath := ""
+		if event, ok := b.repositoryEventFromMessage(message); ok {
+			localFilePath = b.modellingBusRepositoryConnector.getTemporaryFile(event)
+		}
+		if localFilePath != "" {
+			defer os.Remove(localFilePath)
+		}
+
+		// Determine where the posting came from
+		postingInfo := b.postingInfoFromMessage(message)
+		if fileInfo, err := os.Stat(localFilePath); err == nil {
+			postingInfo.Size = fileInfo.Size()
+		}
+
+		postingHandler(localFilePath, postingInfo)
 	})
 }
 
 // Listen for JSON file postings on the modelling bus
 func (b *TModellingBusConnector) listenForJSONFilePostings(agentID, topicPath string, postingHandler func([]byte, string)) {
+	b.listenForJSONFilePostingsWithInfo(agentID, topicPath, func(jsonPayload []byte, postingInfo TPostingInfo) {
+		postingHandler(jsonPayload, postingInfo.Timestamp)
+	})
+}
+
+// Listen for JSON file postings on the modelling bus, also passing on where the posting came from
+func (b *TModellingBusConnector) listenForJSONFilePostingsWithInfo(agentID, topicPath string, postingHandler func([]byte, TPostingInfo)) {
 	// Listen for JSON file related events on the modelling bus
 	b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(message []byte) {
-		postingHandler(b.getJSONFromTemporaryFile(b.getLinkedFileFromRepository(message, generics.JSONFileName)))
+		jsonPayload, _, _ := b.getJSONFromMessage(message)
+
+		// Determine where the posting came from
+		postingInfo := b.postingInfoFromMessage(message)
+		postingInfo.Size = int64(len(jsonPayload))
+
+		postingHandler(jsonPayload, postingInfo)
+	})
+}
+
+// Listen for JSON file postings in a given modelling environment, also passing on the JSON version mentioned in the link to the file.
+// As the links refer to the files on the repository by their full path, this works for any modelling environment.
+func (b *TModellingBusConnector) listenForVersionedJSONFilePostingsIn(environmentID, agentID, topicPath string, postingHandler func([]byte, string, string)) {
+	// Listen for JSON file related events on the modelling bus
+	b.modellingBusEventsConnector.listenForEventsIn(environmentID, agentID, topicPath, func(message []byte) {
+		postingHandler(b.getJSONFromMessage(message))
+		b.acknowledgePosting(environmentID, agentID, topicPath, message)
 	})
 }
 
@@ -224,19 +816,113 @@ func (b *TModellingBusConnector) listenForStreamedPostings(agentID, topicPath st
 
 // Delete postings
 func (b *TModellingBusConnector) deletePosting(topicPath string) {
+	// When doing a dry run, only report on the deletion
+	if b.reportDryRunDeletion("posting", topicPath) {
+		return
+	}
+
 	// Delete the posting both from the modelling bus and the repository
 	b.modellingBusEventsConnector.deletePostingPath(topicPath)
 	b.modellingBusRepositoryConnector.deletePostingPath(topicPath)
 }
 
+// Delete all postings underneath a topic path
+func (b *TModellingBusConnector) deletePostingTree(topicPath string) {
+	// When doing a dry run, only report on the deletion
+	if b.reportDryRunDeletion("postings underneath", topicPath) {
+		return
+	}
+
+	// Delete the postings both from the modelling bus and the repository
+	b.modellingBusEventsConnector.deletePostingPathTree(topicPath)
+	b.modellingBusRepositoryConnector.deletePostingPath(topicPath)
+}
+
+// Delete the postings on a topic path, from the repository, that were made before the given timestamp.
+// The latest (retained) posting on the modelling bus is not affected.
+func (b *TModellingBusConnector) deletePostingsBefore(topicPath, timestamp string) error {
+	// Get the postings still kept in the repository
+	postingTimestamps, err := b.getPostingTimestamps(b.agentID, topicPath)
+	if err != nil {
+		return err
+	}
+
+	// Delete the ones from before the timestamp
+	for _, postingTimestamp := range postingTimestamps {
+		if generics.CompareTimestamps(postingTimestamp, timestamp) < 0 && !b.reportDryRunDeletion("posting", topicPath+"/"+postingTimestamp) {
+			b.modellingBusRepositoryConnector.deletePostingPath(topicPath + "/" + postingTimestamp)
+		}
+	}
+
+	return nil
+}
+
 /*
  *
  * Externally visible functionality
  *
  */
 
-// Delete a given environment
+// Get a new ID that is unique across agents, as it is prefixed by the ID of our agent.
+// For a given agent, the IDs are ordered (lexicographically) in the order in which they were created.
+func (b *TModellingBusConnector) GetNewID() string {
+	return b.agentID + "-" + generics.GetTimestamp()
+}
+
+// Set whether postings by our own agent should be ignored when listening for postings.
+// This avoids feedback loops for agents that both post and listen on the same artefacts.
+// As the setting concerns the connection to the modelling bus, it is shared by all copies of this connector.
+func (b *TModellingBusConnector) SetIgnoreOwnPostings(ignore bool) {
+	b.modellingBusEventsConnector.ignoreOwnPostings.Store(ignore)
+}
+
+// Check whether a payload fits in an MQTT message, so it could be posted inline rather than as a file in the repository.
+// The maximum size is set by "max_payload_bytes" in the "mqtt" section of the config file. Whether payloads that fit are
+// actually posted inline is set by "max_inline_bytes" (and "max_fallback_bytes" in the "ftp" section).
+func (b *TModellingBusConnector) PayloadFitsInline(payload []byte) bool {
+	return b.modellingBusEventsConnector.fitsInline(len(payload))
+}
+
+// Get a copy of the connector that posts in the given modelling environment, sharing the connections to the MQTT broker
+// and FTP server. Artefact connectors created from the copy post in that environment as well.
+// Listening in other modelling environments is done using the ...InEnv variants of the listening functions.
+func (b TModellingBusConnector) PostingInEnvironment(environmentID string) TModellingBusConnector {
+	b.postingEnvironmentID = environmentID
+
+	return b
+}
+
+// Register a handler to be called after the connection to the MQTT broker has been restored (when "auto_reconnect" is set).
+// Subscriptions do not survive a lost connection, so listeners should use this to re-establish them.
+func (b *TModellingBusConnector) OnReconnect(reconnectHandler func()) {
+	b.modellingBusEventsConnector.onReconnect(reconnectHandler)
+}
+
+// Listen for the presence status (PresenceOnline or PresenceOffline) of the agents in a given modelling environment.
+// The presence statuses are retained, so the current status of each agent is passed on when starting to listen.
+func (b *TModellingBusConnector) ListenForAgentPresence(environmentID string, presenceHandler func(agentID, status string)) {
+	b.modellingBusEventsConnector.listenForPresenceIn(environmentID, presenceHandler)
+}
+
+// List the agents that have posted in our modelling environment (within our namespace, if any), as found in the repository.
+// This assumes the agents post on the same FTP server as we do (e.g. in single server mode).
+// This includes agents that are no longer connected. Use ListenForAgentPresence to learn which of them are online.
+// When nothing has been posted in the modelling environment yet, the list is empty.
+func (b *TModellingBusConnector) ListAgents() ([]string, error) {
+	agentIDs, err := b.modellingBusRepositoryConnector.listAgents()
+	b.Reporter.MaybeReportError("Something went wrong listing the agents:", err)
+
+	return agentIDs, err
+}
+
+// Delete a given environment, i.e. the postings of all agents in it, in all namespaces, both from the repository
+// and the modelling bus
 func (b *TModellingBusConnector) DeleteEnvironment(environment ...string) {
+	b.DeleteEnvironmentE(environment...)
+}
+
+// Delete a given environment, returning the error (if any) that made the deletion fail
+func (b *TModellingBusConnector) DeleteEnvironmentE(environment ...string) error {
 	// Determine the environment to delete
 	// This could be the present environment, or the specified one
 	environmentToDelete := b.environmentID
@@ -244,23 +930,103 @@ func (b *TModellingBusConnector) DeleteEnvironment(environment ...string) {
 		environmentToDelete = environment[0]
 	}
 
+	// When doing a dry run, only report on the deletion
+	if b.reportDryRunDeletion("environment", environmentToDelete) {
+		return nil
+	}
+
 	// Report on the deletion
 	b.Reporter.Progress(1, "Deleting environment: %s", environmentToDelete)
 
 	// Delete the environment both from the modelling bus and the repository
-	b.modellingBusEventsConnector.deleteEnvironment(environmentToDelete)
-	b.modellingBusRepositoryConnector.deleteEnvironment(environmentToDelete)
+	return errors.Join(
+		b.modellingBusEventsConnector.deleteEnvironment(environmentToDelete),
+		b.modellingBusRepositoryConnector.deleteEnvironment(environmentToDelete))
+}
+
+// Delete a given namespace of our modelling environment, i.e. the postings of all agents in it, both from the
+// repository and the modelling bus
+func (b *TModellingBusConnector) DeleteNamespace(namespace string) {
+	// An empty namespace would be the entire environment
+	if namespace == "" {
+		b.Reporter.Error("The namespace to be deleted should not be empty.")
+		return
+	}
+	namespace = generics.SanitizeTopicElement(namespace)
+
+	// When doing a dry run, only report on the deletion
+	if b.reportDryRunDeletion("namespace", namespace) {
+		return
+	}
+
+	// Report on the deletion
+	b.Reporter.Progress(generics.ProgressLevelBasic, "Deleting namespace: %s", namespace)
+
+	// Delete the namespace both from the modelling bus and the repository
+	b.modellingBusEventsConnector.deleteNamespace(namespace)
+	b.modellingBusRepositoryConnector.deleteNamespace(namespace)
+}
+
+// Close the connection to the modelling bus.
+// This unsubscribes from all topics, disconnects from the MQTT broker, and cleans up temporary files.
+// It is safe to call this more than once.
+func (b *TModellingBusConnector) Close() {
+	// Stop retrying the postings in the outbox, which are kept for a restart
+	b.outbox.stop()
+
+	// Once closed, there is nothing to clean up before panicking anymore, nor are there errors to be counted
+	if b.stopCleaningUpOnPanic != nil {
+		b.stopCleaningUpOnPanic()
+	}
+	if b.stopCountingErrors != nil {
+		b.stopCountingErrors()
+	}
+
+	// Disconnect from the MQTT broker, if not done before
+	b.modellingBusEventsConnector.disconnect()
+
+	// Clean up on the repository side
+	b.modellingBusRepositoryConnector.close()
 }
 
 // Create the modelling bus connector
 func CreateModellingBusConnector(configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) TModellingBusConnector {
+	// Fail fast when required configuration is missing, rather than failing obscurely when connecting
+	missing := configData.Require(
+		[2]string{"", "environment"},
+		[2]string{"", "agent"},
+		[2]string{"", "work_folder"},
+		[2]string{"ftp", "server"},
+		[2]string{"ftp", "port"},
+		[2]string{"mqtt", "broker"},
+		[2]string{"mqtt", "port"})
+	if len(missing) > 0 {
+		reporter.Panic("Missing required configuration: %s", strings.Join(missing, ", "))
+	}
+
 	// Create the modelling bus connector struct
 	modellingBusConnector := TModellingBusConnector{}
 	modellingBusConnector.environmentID = configData.GetValue("", "environment").String()
 	modellingBusConnector.agentID = configData.GetValue("", "agent").String()
+	modellingBusConnector.dryRun = configData.GetValue("", "dry_run").BoolWithDefault(false)
+	if configData.GetValue("", "metrics").BoolWithDefault(false) {
+		modellingBusConnector.metrics = createMetricsCollector()
+		modellingBusConnector.stopCountingErrors = reporter.OnError(modellingBusConnector.metrics.countError)
+	}
+	if postingsPerSecond := configData.GetValue("limits", "postings_per_second").IntWithDefault(0); postingsPerSecond > 0 {
+		modellingBusConnector.rateLimiter = createRateLimiter(postingsPerSecond, configData.GetValue("limits", "burst").IntWithDefault(postingsPerSecond))
+	}
+	if maxCacheEntries := configData.GetValue("cache", "max_entries").IntWithDefault(0); maxCacheEntries > 0 {
+		modellingBusConnector.contentCache = createContentCache(maxCacheEntries, time.Duration(configData.GetValue("cache", "ttl").IntWithDefault(0))*time.Second)
+	}
 	modellingBusConnector.configData = configData
 	modellingBusConnector.Reporter = reporter
 
+	// Report on doing a dry run
+	if modellingBusConnector.dryRun {
+		modellingBusConnector.Reporter.Progress(generics.ProgressLevelBasic, "Doing a dry run. Postings will only be reported.")
+	}
+
 	// Create the repository connector
 	modellingBusConnector.modellingBusRepositoryConnector =
 		createModellingBusRepositoryConnector(
@@ -269,6 +1035,14 @@ func CreateModellingBusConnector(configData *generics.TConfigData, reporter *gen
 			modellingBusConnector.configData,
 			modellingBusConnector.Reporter)
 
+	// Clean up before panicking, including during the creation of the events connector
+	modellingBusConnector.stopCleaningUpOnPanic = reporter.OnPanic(func() {
+		if modellingBusConnector.modellingBusEventsConnector != nil {
+			modellingBusConnector.modellingBusEventsConnector.disconnect()
+		}
+		modellingBusConnector.modellingBusRepositoryConnector.close()
+	})
+
 	// Create the events connector
 	modellingBusConnector.modellingBusEventsConnector =
 		createModellingBusEventsConnector(
@@ -278,6 +1052,16 @@ func CreateModellingBusConnector(configData *generics.TConfigData, reporter *gen
 			modellingBusConnector.Reporter,
 			postingOnly)
 
+	// Create the outbox, if needed, which retries the postings kept from before a restart right away
+	if modellingBusConnector.configData.GetValue("ftp", "outbox").BoolWithDefault(false) {
+		modellingBusConnector.outbox = createOutbox(
+			modellingBusConnector.configData,
+			modellingBusConnector.environmentID,
+			modellingBusConnector.agentID,
+			modellingBusConnector.Reporter,
+			modellingBusConnector.postOutboxPosting)
+	}
+
 	// Return the created modelling bus connector
 	return modellingBusConnector
 }
@@ -0,0 +1,102 @@
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestCreateModellingBusConnectorMissingConfiguration(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   []string
+		wantPanic string
+	}{
+		{
+			"nothing configured", nil,
+			"Missing required configuration: environment, agent, [ftp] server, [ftp] port, [mqtt] broker, [mqtt] port",
+		},
+		{
+			"only the MQTT broker missing",
+			[]string{"environment = test", "agent = agent", "[ftp]", "server = localhost", "port = 21", "[mqtt]", "port = 1883"},
+			"Missing required configuration: [mqtt] broker",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			configData, _ := loadTestConfig(t, reporter.TReporter, test.content...)
+
+			defer func() {
+				if gotPanic := fmt.Sprint(recover()); gotPanic != test.wantPanic {
+					t.Errorf("CreateModellingBusConnector() panicked with %q, want %q", gotPanic, test.wantPanic)
+				}
+			}()
+			CreateModellingBusConnector(configData, reporter.TReporter, true)
+		})
+	}
+}
+
+func TestGetNewID(t *testing.T) {
+	generics.SetClock(tFixedClock{now: time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local)})
+	t.Cleanup(func() { generics.SetClock(nil) })
+
+	tests := []struct {
+		agentID string
+		wantIDs []string
+	}{
+		{"modeller", []string{"modeller-2026-10-15-13-04-05-00", "modeller-2026-10-15-13-04-05-01"}},
+		{"validator", []string{"validator-2026-10-15-13-04-05-02", "validator-2026-10-15-13-04-05-03"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.agentID, func(t *testing.T) {
+			b := TModellingBusConnector{agentID: test.agentID}
+
+			for _, wantID := range test.wantIDs {
+				if gotID := b.GetNewID(); gotID != wantID {
+					t.Errorf("GetNewID() = %q, want %q", gotID, wantID)
+				}
+			}
+		})
+	}
+}
+
+func TestPostingFallsBackToInline(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		wantPosted bool
+	}{
+		{"small enough", `{"a":1}`, true},
+		{"too large", `{"a":"` + strings.Repeat("x", 100) + `"}`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := createOfflineModellingBusConnector(t, createTestReporter().TReporter, "max_fallback_bytes = 50")
+			client := connectToFakeMQTTBroker(b)
+			publishedBefore := len(client.published())
+
+			err := b.postVersionedJSON("some/path", "1.0", []byte(test.message), "2026-10-15-13-04-05-00")
+			if gotPosted := err == nil; gotPosted != test.wantPosted {
+				t.Fatalf("postVersionedJSON() = %v, want posted: %v", err, test.wantPosted)
+			}
+			if !test.wantPosted && !errors.Is(err, ErrFTPUpload) {
+				t.Errorf("postVersionedJSON() = %v, want %v", err, ErrFTPUpload)
+			}
+
+			// When posted, it should have been posted inline
+			wantTopic := b.modellingBusEventsConnector.mqttAgentTopicPath("agent", "some/path")
+			if gotPosted := slices.Contains(client.published()[publishedBefore:], wantTopic); gotPosted != test.wantPosted {
+				t.Errorf("posted on %s: %v, want %v", wantTopic, gotPosted, test.wantPosted)
+			}
+		})
+	}
+}
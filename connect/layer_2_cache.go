@@ -0,0 +1,136 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Cache
+ *
+ * This component provides an (opt-in) cache of the JSON content retrieved from the repository. As each posting
+ * is stored in its own timestamped file, a link to an already retrieved file can be served from the cache, saving
+ * an FTP round-trip. Caching is only done when "max_entries" is set in the "cache" section of the config file.
+ * When metrics are collected, the number of retrievals served from the cache is counted as "cache hits".
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+ * Defining the content cache
+ */
+
+type (
+	// A cached content
+	tCacheEntry struct {
+		content []byte    // The cached content
+		fetched time.Time // The time the content was retrieved from the repository
+	}
+
+	// A cache of content retrieved from the repository, keyed by the file the content was retrieved from
+	tContentCache struct {
+		maxEntries int                    // The maximum number of cached contents
+		ttl        time.Duration          // The time a cached content remains valid (0 means no limit)
+		entries    map[string]tCacheEntry // The cached contents
+		mutex      sync.Mutex             // Guards the cached contents
+	}
+)
+
+/*
+ * Caching content
+ */
+
+// Get the cache key for the file of a repository event
+func cacheKeyOf(event tRepositoryEvent) string {
+	return event.Server + ":" + event.Port + "/" + event.FilePath + "@" + event.Timestamp
+}
+
+// Get the cached content for the given key, if available and still valid
+func (c *tContentCache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Check whether the content is cached
+	entry, cached := c.entries[key]
+	if !cached {
+		return nil, false
+	}
+
+	// Drop expired content
+	if c.ttl > 0 && time.Since(entry.fetched) > c.ttl {
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	return slices.Clone(entry.content), true
+}
+
+// Cache the content for the given key, evicting the oldest content when the cache is full
+func (c *tContentCache) put(key string, content []byte) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Make room, if needed
+	for len(c.entries) >= c.maxEntries {
+		oldestKey := ""
+		oldestFetched := time.Time{}
+		for entryKey, entry := range c.entries {
+			if oldestKey == "" || entry.fetched.Before(oldestFetched) {
+				oldestKey = entryKey
+				oldestFetched = entry.fetched
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+
+	c.entries[key] = tCacheEntry{content: slices.Clone(content), fetched: time.Now()}
+}
+
+// Clear the cache
+func (c *tContentCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = map[string]tCacheEntry{}
+}
+
+// Create a content cache
+func createContentCache(maxEntries int, ttl time.Duration) *tContentCache {
+	c := tContentCache{}
+	c.maxEntries = max(maxEntries, 1)
+	c.ttl = ttl
+	c.entries = map[string]tCacheEntry{}
+
+	return &c
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Clear the cache of retrieved content (if any)
+func (b *TModellingBusConnector) ClearCache() {
+	b.contentCache.clear()
+}
@@ -0,0 +1,69 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Health
+ *
+ * This component provides a health check of the connectivity of an agent to the modelling bus, allowing
+ * orchestration platforms to gate readiness and liveness on the actual reachability of the MQTT broker and
+ * the FTP server, rather than on the mere existence of the agent's process.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+/*
+ * Defining health statuses
+ */
+
+type (
+	// The status of one of the transports of the modelling bus
+	TTransportStatus struct {
+		Healthy bool   `json:"healthy"`         // Whether the transport can be used
+		Error   string `json:"error,omitempty"` // The reason why the transport cannot be used (if any)
+	}
+
+	// The health status of a modelling bus connector
+	THealthStatus struct {
+		EnvironmentID string           `json:"environment id"` // The modelling environment ID
+		AgentID       string           `json:"agent id"`       // The agent ID
+		MQTT          TTransportStatus `json:"mqtt"`           // The status of the connection to the MQTT broker
+		FTP           TTransportStatus `json:"ftp"`            // The status of the connection to the FTP server
+		Healthy       bool             `json:"healthy"`        // Whether all transports can be used
+	}
+)
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Check the health of the connector, i.e. whether it is connected to the MQTT broker, and can connect to the FTP server.
+// To check the FTP server, a connection is made, and closed again.
+func (b *TModellingBusConnector) HealthCheck() THealthStatus {
+	healthStatus := THealthStatus{}
+	healthStatus.EnvironmentID = b.environmentID
+	healthStatus.AgentID = b.agentID
+
+	// Check the connection to the MQTT broker
+	healthStatus.MQTT.Healthy = b.modellingBusEventsConnector.isConnected()
+	if !healthStatus.MQTT.Healthy {
+		healthStatus.MQTT.Error = "not connected to the MQTT broker"
+	}
+
+	// Check the connection to the FTP server
+	if err := b.modellingBusRepositoryConnector.checkConnection(); err != nil {
+		healthStatus.FTP.Error = err.Error()
+	} else {
+		healthStatus.FTP.Healthy = true
+	}
+
+	healthStatus.Healthy = healthStatus.MQTT.Healthy && healthStatus.FTP.Healthy
+
+	return healthStatus
+}
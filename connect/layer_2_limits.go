@@ -0,0 +1,99 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Limits
+ *
+ * This component provides (opt-in) rate limiting of postings per class of topic, so a misbehaving agent that
+ * posts in a tight loop cannot saturate the FTP server or flood the MQTT broker. Rate limiting is only done
+ * when "postings_per_second" is set in the "limits" section of the config file.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+ * Defining rate limiters
+ */
+
+type (
+	// A token bucket for one class of topics
+	tTokenBucket struct {
+		tokens     float64   // The tokens currently in the bucket
+		lastRefill time.Time // The last time the bucket was refilled
+	}
+
+	// A rate limiter, with a token bucket per class of topics
+	tRateLimiter struct {
+		postingsPerSecond float64                  // The rate at which the buckets are refilled
+		burst             float64                  // The maximum number of tokens in a bucket
+		buckets           map[string]*tTokenBucket // The token buckets, per class of topics
+		mutex             sync.Mutex               // Guards the buckets
+	}
+)
+
+/*
+ * Limiting the rate of postings
+ */
+
+// Take a token for a posting on the given topic path, if available
+func (l *tRateLimiter) allow(topicPath string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// Get the bucket for the class of the topic, starting with a full bucket
+	now := time.Now()
+	bucket, exists := l.buckets[topicClass(topicPath)]
+	if !exists {
+		bucket = &tTokenBucket{tokens: l.burst, lastRefill: now}
+		l.buckets[topicClass(topicPath)] = bucket
+	}
+
+	// Refill the bucket for the time that has passed
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*l.postingsPerSecond)
+	bucket.lastRefill = now
+
+	// Take a token, if available
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
+
+// Check whether a posting on the given topic path is allowed, reporting (and returning) an error if not
+func (b *TModellingBusConnector) checkRateLimit(topicPath string) error {
+	if b.rateLimiter.allow(topicPath) {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: more than %g postings per second on %s", ErrRateLimited, b.rateLimiter.postingsPerSecond, topicClass(topicPath))
+	b.Reporter.ReportError("Not posting:", err)
+
+	return err
+}
+
+// Create a rate limiter, allowing bursts of the given size
+func createRateLimiter(postingsPerSecond, burst int) *tRateLimiter {
+	l := tRateLimiter{}
+	l.postingsPerSecond = float64(postingsPerSecond)
+	l.burst = float64(max(burst, 1))
+	l.buckets = map[string]*tTokenBucket{}
+
+	return &l
+}
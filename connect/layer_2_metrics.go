@@ -0,0 +1,187 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Metrics
+ *
+ * This component provides (opt-in) metrics on the use of the modelling bus connector, such as the number of postings
+ * per class of topic, the number of retrievals, the number of bytes transferred, and the number of reported errors.
+ * Metrics are only collected when "metrics = true" is set in the config file, so there is no overhead otherwise.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"expvar"
+	"io"
+	"strings"
+	"sync"
+)
+
+/*
+ * Defining metrics
+ */
+
+type (
+	// A snapshot of the metrics of a modelling bus connector
+	TMetrics struct {
+		Postings       map[string]int64 `json:"postings"`        // Number of postings, per class of topic (e.g. "artefacts/state")
+		Retrievals     int64            `json:"retrievals"`      // Number of retrievals from the bus
+		CacheHits      int64            `json:"cache hits"`      // Number of retrievals served from the cache, rather than the repository
+		BytesPosted    int64            `json:"bytes posted"`    // Number of bytes posted
+		BytesRetrieved int64            `json:"bytes retrieved"` // Number of bytes retrieved
+		Errors         int64            `json:"errors"`          // Number of errors reported on the Reporter while the connector was open
+	}
+
+	// A reader counting the bytes read through it
+	tCountingReader struct {
+		reader io.Reader // The reader to read from
+		count  int64     // The number of bytes read so far
+	}
+
+	// A writer counting the bytes written through it
+	tCountingWriter struct {
+		writer io.Writer // The writer to write to
+		count  int64     // The number of bytes written so far
+	}
+
+	// The metrics as they are being collected
+	tMetricsCollector struct {
+		metrics TMetrics   // The metrics collected so far
+		mutex   sync.Mutex // Guards the metrics
+	}
+)
+
+/*
+ * Collecting metrics
+ */
+
+// Read, while counting the bytes read
+func (r *tCountingReader) Read(data []byte) (int, error) {
+	n, err := r.reader.Read(data)
+	r.count += int64(n)
+
+	return n, err
+}
+
+// Write, while counting the bytes written
+func (w *tCountingWriter) Write(data []byte) (int, error) {
+	n, err := w.writer.Write(data)
+	w.count += int64(n)
+
+	return n, err
+}
+
+// Get the class of a topic path, i.e. its first path element, combined with its kind of posting (when relevant)
+func topicClass(topicPath string) string {
+	pathElements := strings.Split(topicPath, "/")
+
+	class := pathElements[0]
+	switch kind := pathElements[len(pathElements)-1]; kind {
+	case artefactStatePathElement, artefactUpdatePathElement, artefactConsideringPathElement:
+		class += "/" + kind
+	}
+
+	return class
+}
+
+// Count a posting of the given size on the given topic path
+func (m *tMetricsCollector) countPosting(topicPath string, size int64) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metrics.Postings[topicClass(topicPath)]++
+	m.metrics.BytesPosted += size
+}
+
+// Count a retrieval of the given size
+func (m *tMetricsCollector) countRetrieval(size int64) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metrics.Retrievals++
+	m.metrics.BytesRetrieved += size
+}
+
+// Count a retrieval served from the cache
+func (m *tMetricsCollector) countCacheHit() {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metrics.CacheHits++
+}
+
+// Count a reported error
+func (m *tMetricsCollector) countError() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metrics.Errors++
+}
+
+// Take a snapshot of the metrics
+func (m *tMetricsCollector) snapshot() TMetrics {
+	metrics := TMetrics{}
+	metrics.Postings = map[string]int64{}
+
+	if m == nil {
+		return metrics
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for class, count := range m.metrics.Postings {
+		metrics.Postings[class] = count
+	}
+	metrics.Retrievals = m.metrics.Retrievals
+	metrics.CacheHits = m.metrics.CacheHits
+	metrics.BytesPosted = m.metrics.BytesPosted
+	metrics.BytesRetrieved = m.metrics.BytesRetrieved
+	metrics.Errors = m.metrics.Errors
+
+	return metrics
+}
+
+// Create a metrics collector
+func createMetricsCollector() *tMetricsCollector {
+	m := tMetricsCollector{}
+	m.metrics.Postings = map[string]int64{}
+
+	return &m
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Get a snapshot of the metrics of the connector (all zero when metrics are not collected)
+func (b *TModellingBusConnector) Metrics() TMetrics {
+	return b.metrics.snapshot()
+}
+
+// Publish the metrics of the connector as an expvar variable with the given name, e.g. to be served on /debug/vars
+func (b *TModellingBusConnector) PublishMetrics(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return b.metrics.snapshot()
+	}))
+}
@@ -0,0 +1,320 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 2 - Outbox
+ *
+ * This component provides an (opt-in) durable outbox for JSON postings. When a JSON file cannot be uploaded to the
+ * FTP server, the posting is kept in the outbox, rather than being lost, and it is retried in the background until
+ * the FTP server is available again. While postings are waiting in the outbox, new postings are queued behind them,
+ * so the order of the postings, and thus the delta chains of artefacts, remain valid. The outbox is stored in the
+ * work folder, so postings still waiting when the agent stops are retried after a restart. While the FTP server
+ * remains unavailable, the time between retries backs off, up to a maximum.
+ * The outbox is only used when "outbox = true" is set in the "ftp" section of the config file.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	defaultOutboxRetryDelay        = 10  // The default time (in seconds) before retrying the postings in the outbox
+	defaultOutboxRetryMaximumDelay = 300 // The default maximum time (in seconds) between retries of the postings in the outbox
+)
+
+/*
+ * Defining the outbox
+ */
+
+type (
+	// A posting waiting in the outbox
+	tOutboxPosting struct {
+		EnvironmentID   string          `json:"environment id,omitempty"`    // The modelling environment to post in (empty means our own environment)
+		AgentID         string          `json:"agent id,omitempty"`          // The agent on whose behalf to post (empty means our own agent)
+		TopicPath       string          `json:"topic path"`                  // The topic path to post on
+		PayloadFileName string          `json:"payload file name,omitempty"` // The name of the payload file (empty means the default name)
+		JSONVersion     string          `json:"json version,omitempty"`      // The JSON version of the posting
+		AckID           string          `json:"ack id,omitempty"`            // The ack ID with which listeners should acknowledge receipt
+		Payload         json.RawMessage `json:"payload"`                     // The JSON to be posted
+		Timestamp       string          `json:"timestamp"`                   // The timestamp of the posting
+	}
+
+	// The outbox of postings that could not be made yet
+	tOutbox struct {
+		filePath   string                     // The file in which the outbox is stored
+		retryDelay time.Duration              // The time before retrying the postings in the outbox
+		maxDelay   time.Duration              // The maximum time between retries, while the postings keep failing
+		postings   []tOutboxPosting           // The postings waiting in the outbox, in the order in which they were made
+		post       func(tOutboxPosting) error // The function making a posting
+		mutex      sync.Mutex                 // Guards the postings
+		flushMutex sync.Mutex                 // Ensures the postings are flushed by one at a time, so their order is kept
+		stopping   chan bool                  // Closed to stop retrying the postings
+		stopOnce   sync.Once                  // Ensures retrying is stopped only once
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, errors, and panics
+	}
+)
+
+/*
+ * Managing the outbox
+ */
+
+// Check whether there are postings waiting in the outbox
+func (o *tOutbox) hasPending() bool {
+	return o.pending() > 0
+}
+
+// Get the number of postings waiting in the outbox
+func (o *tOutbox) pending() int {
+	if o == nil {
+		return 0
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return len(o.postings)
+}
+
+// Add a posting to the outbox
+func (o *tOutbox) enqueue(posting tOutboxPosting) error {
+	// Only valid JSON can be kept
+	if !generics.IsJSON(posting.Payload) {
+		o.reporter.Error("Provided content is not a valid JSON.")
+		return fmt.Errorf("%w: provided content is not a valid JSON", ErrMarshal)
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.postings = append(o.postings, posting)
+	o.reporter.Progress(generics.ProgressLevelBasic, "Keeping the posting on %s in the outbox, with %d posting(s) waiting.", posting.TopicPath, len(o.postings))
+
+	return o.save()
+}
+
+// Store the outbox in its file, replacing the file as a whole, so a crash does not leave a partially written outbox.
+// The caller should hold the mutex.
+func (o *tOutbox) save() error {
+	// Without waiting postings, there is no need for the file
+	if len(o.postings) == 0 {
+		if err := os.Remove(o.filePath); err != nil && !os.IsNotExist(err) {
+			o.reporter.ReportError("Something went wrong removing the outbox file:", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Convert the postings to JSON
+	postingsJSON, err := json.Marshal(o.postings)
+	if o.reporter.MaybeReportError("Something went wrong JSONing the outbox:", err) {
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	// Write them to a temporary file, which then replaces the outbox file
+	temporaryFilePath := o.filePath + ".tmp"
+	err = os.WriteFile(temporaryFilePath, postingsJSON, 0o644)
+	if err == nil {
+		err = os.Rename(temporaryFilePath, o.filePath)
+	}
+	o.reporter.MaybeReportError("Something went wrong storing the outbox:", err)
+
+	return err
+}
+
+// Load the postings kept in the outbox file, e.g. from before a restart
+func (o *tOutbox) load() {
+	postingsJSON, err := os.ReadFile(o.filePath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if o.reporter.MaybeReportError("Something went wrong reading the outbox:", err) {
+		return
+	}
+
+	// Get the postings
+	postings := []tOutboxPosting{}
+	if o.reporter.MaybeReportError("Something went wrong unJSONing the outbox:", json.Unmarshal(postingsJSON, &postings)) {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.postings = postings
+	if len(o.postings) > 0 {
+		o.reporter.Progress(generics.ProgressLevelBasic, "Found %d posting(s) waiting in the outbox.", len(o.postings))
+	}
+}
+
+// Make the postings waiting in the outbox, in order, until one of them fails
+func (o *tOutbox) flush() error {
+	if o == nil {
+		return nil
+	}
+
+	// Only one flush at a time, so the order of the postings is kept
+	o.flushMutex.Lock()
+	defer o.flushMutex.Unlock()
+
+	for {
+		// Take the first waiting posting, if any
+		o.mutex.Lock()
+		if len(o.postings) == 0 {
+			o.mutex.Unlock()
+
+			return nil
+		}
+		posting := o.postings[0]
+		o.mutex.Unlock()
+
+		// Make the posting, keeping it in the outbox when the FTP server or the MQTT broker is unavailable.
+		// Postings that fail for other reasons would never succeed, so they are dropped.
+		postErr := o.post(posting)
+		if errors.Is(postErr, ErrFTPUpload) || errors.Is(postErr, ErrMQTTPublish) {
+			return postErr
+		}
+		if postErr != nil {
+			o.reporter.ReportError("Dropping the posting on "+posting.TopicPath+" from the outbox:", postErr)
+		}
+
+		// Remove it from the outbox
+		o.mutex.Lock()
+		o.postings = o.postings[1:]
+		err := o.save()
+		o.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+
+		if postErr == nil {
+			o.reporter.Progress(generics.ProgressLevelDetailed, "Made the posting on %s from the outbox.", posting.TopicPath)
+		}
+	}
+}
+
+// Retry the postings waiting in the outbox, starting right away, until stopped.
+// While the postings keep failing, the time between retries backs off.
+func (o *tOutbox) retry() {
+	backoff := generics.CreateBackoff(o.retryDelay, o.maxDelay)
+
+	for {
+		delay := o.retryDelay
+		if o.hasPending() {
+			if o.flush() == nil {
+				backoff.Reset()
+			} else {
+				delay = backoff.Next()
+				o.reporter.Progress(generics.ProgressLevelDetailed, "Retrying the postings in the outbox in %s.", delay.Round(time.Second))
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+
+		case <-o.stopping:
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+// Stop retrying the postings waiting in the outbox. They remain stored, so they are retried after a restart.
+func (o *tOutbox) stop() {
+	if o == nil {
+		return
+	}
+
+	o.stopOnce.Do(func() {
+		close(o.stopping)
+	})
+}
+
+// Get the name of the outbox file of a given agent in a given modelling environment under a given prefix.
+// As "-" is percent-encoded in the elements, different combinations of them cannot get the same name.
+func outboxFileName(prefix, environmentID, agentID string) string {
+	fileName := "outbox"
+	for _, element := range []string{prefix, environmentID, agentID} {
+		fileName += "-" + strings.ReplaceAll(generics.SanitizeTopicElement(element), "-", "%2D")
+	}
+
+	return fileName + generics.JSONExtension
+}
+
+// Get a retry delay for the outbox from the config file, using the default when it is not positive
+func outboxRetryDelayFromConfig(configData *generics.TConfigData, reporter *generics.TReporter, key string, defaultDelay int) time.Duration {
+	delay := configData.GetValue("ftp", key).IntWithDefault(defaultDelay)
+	if delay <= 0 {
+		reporter.Error("Invalid FTP %s: %d. It should be positive. Using %d seconds instead.", key, delay, defaultDelay)
+
+		return time.Duration(defaultDelay) * time.Second
+	}
+
+	return time.Duration(delay) * time.Second
+}
+
+// Create an outbox for a given agent in a given modelling environment, loading the postings kept from before a restart,
+// and start retrying them
+func createOutbox(configData *generics.TConfigData, environmentID, agentID string, reporter *generics.TReporter, post func(tOutboxPosting) error) *tOutbox {
+	o := tOutbox{}
+	o.filePath = filepath.Join(configData.GetValue("", "work_folder").String(), outboxFileName(configData.GetValue("ftp", "prefix").String(), environmentID, agentID))
+	o.retryDelay = outboxRetryDelayFromConfig(configData, reporter, "outbox_retry_delay", defaultOutboxRetryDelay)
+	o.maxDelay = outboxRetryDelayFromConfig(configData, reporter, "outbox_retry_max_delay", defaultOutboxRetryMaximumDelay)
+	o.post = post
+	o.stopping = make(chan bool)
+	o.reporter = reporter
+
+	// Load the postings kept from before a restart, and retry them
+	o.load()
+	go o.retry()
+
+	return &o
+}
+
+// Make a posting from the outbox
+func (b *TModellingBusConnector) postOutboxPosting(posting tOutboxPosting) error {
+	postingConnector := b.postingAs(posting.AgentID)
+	postingConnector.postingEnvironmentID = posting.EnvironmentID
+
+	return postingConnector.addAndAnnounceJSONAsFile(posting.TopicPath, posting.PayloadFileName, posting.JSONVersion, posting.AckID, posting.Payload, posting.Timestamp)
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Get the number of postings waiting in the outbox (always 0 when there is no outbox)
+func (b *TModellingBusConnector) PendingPostings() int {
+	return b.outbox.pending()
+}
+
+// Make the postings waiting in the outbox right away, rather than waiting for the next retry.
+// Returns the error (if any) that made a posting fail, in which case it, and the ones after it, remain in the outbox.
+func (b *TModellingBusConnector) FlushOutbox() error {
+	return b.outbox.flush()
+}
@@ -0,0 +1,122 @@
+package connect
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutboxFileName(t *testing.T) {
+	tests := []struct {
+		name          string
+		prefix        string
+		environmentID string
+		agentID       string
+		want          string
+	}{
+		{"plain", "prefix", "environment", "agent", "outbox-prefix-environment-agent.json"},
+		{"no prefix", "", "environment", "agent", "outbox--environment-agent.json"},
+		{"unsafe characters", "some/prefix", "environment", "agent 1", "outbox-some%2Fprefix-environment-agent%201.json"},
+		{"dashes", "a-b", "c", "agent", "outbox-a%2Db-c-agent.json"},
+		{"dashes elsewhere", "a", "b-c", "agent", "outbox-a-b%2Dc-agent.json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := outboxFileName(test.prefix, test.environmentID, test.agentID); got != test.want {
+				t.Errorf("outboxFileName() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCreateOutbox(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      []string
+		wantFileName string
+		wantDelay    time.Duration
+		wantMaxDelay time.Duration
+		wantErrors   int
+	}{
+		{
+			"default", nil, "outbox--environment-agent.json",
+			defaultOutboxRetryDelay * time.Second, defaultOutboxRetryMaximumDelay * time.Second, 0,
+		},
+		{
+			"configured",
+			[]string{"[ftp]", "prefix = prefix", "outbox_retry_delay = 2", "outbox_retry_max_delay = 30"},
+			"outbox-prefix-environment-agent.json", 2 * time.Second, 30 * time.Second, 0,
+		},
+		{
+			"not positive",
+			[]string{"[ftp]", "outbox_retry_delay = 0", "outbox_retry_max_delay = -1"},
+			"outbox--environment-agent.json",
+			defaultOutboxRetryDelay * time.Second, defaultOutboxRetryMaximumDelay * time.Second, 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			configData, workFolder := loadTestConfig(t, reporter.TReporter, test.content...)
+
+			o := createOutbox(configData, "environment", "agent", reporter.TReporter, func(tOutboxPosting) error { return nil })
+			defer o.stop()
+
+			if want := filepath.Join(workFolder, test.wantFileName); o.filePath != want {
+				t.Errorf("filePath = %q, want %q", o.filePath, want)
+			}
+			if o.retryDelay != test.wantDelay || o.maxDelay != test.wantMaxDelay {
+				t.Errorf("delays = %v, %v, want %v, %v", o.retryDelay, o.maxDelay, test.wantDelay, test.wantMaxDelay)
+			}
+			if gotErrors := len(reporter.reportedErrors()); gotErrors != test.wantErrors {
+				t.Errorf("createOutbox() reported %d error(s), want %d", gotErrors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestOutboxRetryBacksOff(t *testing.T) {
+	// Keep track of the times at which the posting is tried, which always fails as the FTP server is unavailable
+	attempts := []time.Time{}
+	attemptsMutex := sync.Mutex{}
+	fourthAttempt := make(chan bool)
+
+	o := tOutbox{}
+	o.filePath = filepath.Join(t.TempDir(), outboxFileName("prefix", "environment", "agent"))
+	o.retryDelay = 100 * time.Millisecond
+	o.maxDelay = time.Second
+	o.stopping = make(chan bool)
+	o.reporter = createTestReporter().TReporter
+	o.postings = []tOutboxPosting{{TopicPath: "some/path", Payload: []byte(`{}`)}}
+	o.post = func(tOutboxPosting) error {
+		attemptsMutex.Lock()
+		defer attemptsMutex.Unlock()
+
+		attempts = append(attempts, time.Now())
+		if len(attempts) == 4 {
+			close(fourthAttempt)
+		}
+
+		return ErrFTPUpload
+	}
+
+	go o.retry()
+	defer o.stop()
+
+	select {
+	case <-fourthAttempt:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the posting was not retried 3 times")
+	}
+
+	// The delays between the retries should grow
+	attemptsMutex.Lock()
+	defer attemptsMutex.Unlock()
+
+	if firstDelay, thirdDelay := attempts[1].Sub(attempts[0]), attempts[3].Sub(attempts[2]); thirdDelay <= firstDelay {
+		t.Errorf("delay before the third retry = %v, want more than the delay before the first retry (%v)", thirdDelay, firstDelay)
+	}
+}
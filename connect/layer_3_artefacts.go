@@ -8,14 +8,25 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 15.10.2026
  *
  */
 
 package connect
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
@@ -31,6 +42,8 @@ const (
 	artefactStatePathElement       = "state"       // Artefact state path element
 	artefactConsideringPathElement = "considering" // Artefact considering path element
 	artefactUpdatePathElement      = "update"      // Artefact update path element
+
+	defaultConcurrentRetrievals = 4 // The default number of artefact states retrieved concurrently in bulk retrievals
 )
 
 /*
@@ -40,17 +53,60 @@ const (
 type (
 	TModellingBusArtefactConnector struct {
 		ModellingBusConnector TModellingBusConnector // The modelling bus connector to be used
-		JSONVersion           string                 `json:"json version, omitempty"`      // The JSON version to be used
-		ArtefactID            string                 `json:"artefact id"`                  // The artefact ID
-		CurrentTimestamp      string                 `json:"current timestamp, omitempty"` // The current timestamp
+		JSONVersion           string                 `json:"json version,omitempty"`      // The JSON version to be used
+		ArtefactID            string                 `json:"artefact id"`                 // The artefact ID
+		CurrentTimestamp      string                 `json:"current timestamp,omitempty"` // The current timestamp
+
+		CurrentContent    json.RawMessage `json:"content,omitempty"` // The current content of the artefact
+		UpdatedContent    json.RawMessage `json:"-"`                 // The updated content of the artefact
+		ConsideredContent json.RawMessage `json:"-"`                 // The considered content of the artefact
+
+		// The operations of the most recently received deltas, so the changes can be shown as such
+		lastUpdateOperations      json.RawMessage `json:"-"` // The operations of the last received update
+		lastConsideringOperations json.RawMessage `json:"-"` // The operations of the last received considering
+
+		// Updates made against the previous state, concurrently with the posting of the current state, are merged into the current state
+		previousContent    json.RawMessage          `json:"-"` // The content of the state before the current one
+		previousTimestamp  string                   `json:"-"` // The timestamp of the state before the current one
+		lastMergeConflicts []generics.TJSONConflict `json:"-"` // The conflicts of the last merged update
 
-		CurrentContent    json.RawMessage `json:"content, omitempty"` // The current content of the artefact
-		UpdatedContent    json.RawMessage `json:"-"`                  // The updated content of the artefact
-		ConsideredContent json.RawMessage `json:"-"`                  // The considered content of the artefact
+		// The JSON version mentioned in the last posting received, so handlers can see what version was posted
+		ReceivedJSONVersion string `json:"-"` // The JSON version of the last received posting
+
+		// Next to the JSON version, the artefact's states can also be posted in additional JSON versions
+		versionChannels     []string `json:"-"` // The additional JSON versions, in order of preference
+		receivedVersionRank int      `json:"-"` // The preference rank of the JSON version of the current content
 
 		// Before we can communicate updates or considering postings, we must have
 		// communicated the state of the model first
 		stateCommunicated bool `json:"-"` // Identenfies whether the state has been communicated
+
+		// The hash of the last posted state, so re-posting the very same state can be skipped
+		lastStateHash string `json:"-"` // The SHA-256 hash of the last posted state
+
+		// Changes to the parts of the artefact under these JSON pointers never appear in a delta
+		deltaIgnoredPaths []string `json:"-"` // The JSON pointers of the parts to be ignored in deltas
+
+		// When coalescing updates, updates posted within the coalescing window are combined into one update posting
+		updateCoalescingWindow time.Duration `json:"-"` // The window within which updates are coalesced (0 means no coalescing)
+		updatePending          bool          `json:"-"` // Whether there is an update that still needs to be posted
+		updateTimer            *time.Timer   `json:"-"` // The timer that will flush the pending update
+		updateMutex            *sync.Mutex   `json:"-"` // Guards the content, and the pending update administration
+
+		// The name of the payload files of our postings in the repository, such as "payload.json" (empty means the default name)
+		payloadFileName string `json:"-"`
+
+		// While the handlers are paused, postings are still applied, but calling the handlers is deferred
+		handlerPause *tHandlerPause `json:"-"` // The administration of paused handlers
+	}
+
+	// The administration of paused handlers
+	tHandlerPause struct {
+		paused           bool         // Whether the handlers are paused
+		handlerCount     int          // The number of handlers that can be paused, used to identify them
+		deferredIDs      map[int]bool // The handlers whose calls have been deferred
+		deferredHandlers []func()     // The handlers whose calls have been deferred, in the order of their first deferral
+		mutex            sync.Mutex   // Guards the administration of paused handlers
 	}
 )
 
@@ -61,19 +117,34 @@ type (
 // Defining topic paths for raw artefacts
 func (b *TModellingBusArtefactConnector) rawArtefactsTopicPath(artefactID string) string {
 	return rawArtefactsPathElement +
-		"/" + artefactID
+		"/" + b.ModellingBusConnector.topicElement("artefact", artefactID)
 }
 
 // Defining topic paths for json artefacts
 func (b *TModellingBusArtefactConnector) jsonArtefactsTopicPath(artefactID string) string {
+	return b.jsonArtefactsTopicPathIn(b.JSONVersion, artefactID)
+}
+
+// Defining topic paths for json artefacts across all JSON versions
+func (b *TModellingBusArtefactConnector) jsonArtefactsTreeTopicPath(artefactID string) string {
 	return jsonArtefactsPathElement +
-		"/" + artefactID +
-		"/" + b.JSONVersion
+		"/" + b.ModellingBusConnector.topicElement("artefact", artefactID)
+}
+
+// Defining topic paths for json artefacts in a given JSON version
+func (b *TModellingBusArtefactConnector) jsonArtefactsTopicPathIn(jsonVersion, artefactID string) string {
+	return b.jsonArtefactsTreeTopicPath(artefactID) +
+		"/" + jsonVersion
 }
 
 // Defining topic paths for json artefact states
 func (b *TModellingBusArtefactConnector) jsonArtefactsStateTopicPath(artefactID string) string {
-	return b.jsonArtefactsTopicPath(artefactID) +
+	return b.jsonArtefactsStateTopicPathIn(b.JSONVersion, artefactID)
+}
+
+// Defining topic paths for json artefact states in a given JSON version
+func (b *TModellingBusArtefactConnector) jsonArtefactsStateTopicPathIn(jsonVersion, artefactID string) string {
+	return b.jsonArtefactsTopicPathIn(jsonVersion, artefactID) +
 		"/" + artefactStatePathElement
 }
 
@@ -100,44 +171,81 @@ type TJSONDelta struct {
 	CurrentTimestamp string          `json:"current timestamp"` // The current timestamp at the sender side
 }
 
-// Posting JSON delta
-func (b *TModellingBusArtefactConnector) postJSONDelta(deltaTopicPath string, oldStateJSON, newStateJSON []byte) {
+// Posting JSON delta, relative to the state with the given (current) timestamp
+func (b *TModellingBusArtefactConnector) postJSONDelta(deltaTopicPath, currentTimestamp string, oldStateJSON, newStateJSON []byte) error {
+	// Canonicalize both states, so formatting differences (e.g. from externally produced JSON) do not show up in the delta
+	canonicalOldStateJSON, err := generics.CanonicalizeJSON(oldStateJSON)
+	if err == nil {
+		newStateJSON, err = generics.CanonicalizeJSON(newStateJSON)
+	}
+	oldStateJSON = canonicalOldStateJSON
+
+	// Handle potential errors
+	if b.ModellingBusConnector.Reporter.MaybeReportError("Something went wrong canonicalizing the JSON states:", err) {
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
 	// Create the delta
-	deltaOperationsJSON, err := generics.JSONDiff(oldStateJSON, newStateJSON)
+	deltaOperationsJSON, err := generics.JSONDiffWithOptions(oldStateJSON, newStateJSON, generics.TDiffOptions{IgnoredPaths: b.deltaIgnoredPaths})
 
 	// Handle potential errors
 	if b.ModellingBusConnector.Reporter.MaybeReportError("Something went wrong running the JSON diff:", err) {
-		return
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
 	}
 
 	// Create the delta object
 	delta := TJSONDelta{}
 	delta.Timestamp = generics.GetTimestamp()
-	delta.CurrentTimestamp = b.CurrentTimestamp
+	delta.CurrentTimestamp = currentTimestamp
 	delta.Operations = deltaOperationsJSON
 
 	// Convert the delta to JSON
 	deltaJSON, err := json.Marshal(delta)
 
 	// Post the delta JSON, if no error occurred during marshalling
-	b.ModellingBusConnector.maybePostJSONAsFile(deltaTopicPath, deltaJSON, delta.Timestamp, "Something went wrong JSONing the diff patch:", err)
+	return b.ModellingBusConnector.maybePostVersionedJSON(deltaTopicPath, b.payloadFileName, b.JSONVersion, deltaJSON, delta.Timestamp, "Something went wrong JSONing the diff patch:", err)
+}
+
+// Checking whether there are any changes (that would appear in a delta) between two JSON states
+func (b *TModellingBusArtefactConnector) hasJSONChanges(oldStateJSON, newStateJSON []byte) bool {
+	deltaOperationsJSON, err := generics.JSONDiffWithOptions(oldStateJSON, newStateJSON, generics.TDiffOptions{IgnoredPaths: b.deltaIgnoredPaths})
+	if err != nil {
+		// When in doubt, there are changes
+		return true
+	}
+
+	// Check for an empty list of operations
+	deltaOperations := []json.RawMessage{}
+	if err := json.Unmarshal(deltaOperationsJSON, &deltaOperations); err != nil {
+		return true
+	}
+
+	return len(deltaOperations) > 0
 }
 
 // Applying a JSON delta to a given current JSON state
-func (b *TModellingBusArtefactConnector) applyJSONDelta(currentJSONState json.RawMessage, deltaJSON []byte) (json.RawMessage, bool) {
+// Next to the new state, it returns the operations of the delta.
+// When mayMerge is set, a delta against the previous state is merged into the given state.
+// Should only be called while holding the update mutex, as the delta should refer to the current timestamp.
+func (b *TModellingBusArtefactConnector) applyJSONDelta(currentJSONState json.RawMessage, deltaJSON []byte, mayMerge bool) (json.RawMessage, json.RawMessage, bool) {
 	// Unmarshal the delta
 	delta := TJSONDelta{}
 	err := json.Unmarshal(deltaJSON, &delta)
 
 	// Handle potential errors
 	if b.ModellingBusConnector.Reporter.MaybeReportError("Something went wrong unJSONing the received diff patch:", err) {
-		return currentJSONState, false
+		return currentJSONState, nil, false
 	}
 
 	// Check whether the delta can be applied
 	if delta.CurrentTimestamp != b.CurrentTimestamp {
-		// When the timestamps don't match, we cannot apply the delta
-		return currentJSONState, false
+		// A delta against the previous state can still be merged into the current state
+		if mayMerge && delta.CurrentTimestamp != "" && delta.CurrentTimestamp == b.previousTimestamp {
+			return b.mergeJSONDelta(currentJSONState, delta.Operations)
+		}
+
+		// Otherwise, when the timestamps don't match, we cannot apply the delta
+		return currentJSONState, nil, false
 	}
 
 	// Apply the delta
@@ -145,37 +253,164 @@ func (b *TModellingBusArtefactConnector) applyJSONDelta(currentJSONState json.Ra
 
 	// Handle potential errors
 	if b.ModellingBusConnector.Reporter.MaybeReportError("Applying the diff patch did not work:", err) {
-		return currentJSONState, false
+		return currentJSONState, nil, false
 	}
 
 	// Return the new state
-	return newJSONState, true
+	return newJSONState, delta.Operations, true
+}
+
+// Merging a delta against the previous state into the given JSON state, which is based on the current state.
+// Next to the merged state, it returns the operations that lead from the given state to the merged state.
+// Where the delta conflicts with the changes in the given state, the given state is kept and the conflicts are reported.
+// Should only be called while holding the update mutex.
+func (b *TModellingBusArtefactConnector) mergeJSONDelta(currentJSONState, deltaOperations json.RawMessage) (json.RawMessage, json.RawMessage, bool) {
+	// The state as intended by the sender of the delta
+	deltaJSONState, err := generics.JSONApplyPatch(b.previousContent, deltaOperations)
+
+	// Handle potential errors
+	if b.ModellingBusConnector.Reporter.MaybeReportError("Applying the diff patch to the previous state did not work:", err) {
+		return currentJSONState, nil, false
+	}
+
+	// Merge the changes of the sender of the delta with ours
+	mergedJSONState, conflicts, err := generics.JSONThreeWayMerge(b.previousContent, currentJSONState, deltaJSONState)
+
+	// Handle potential errors
+	if b.ModellingBusConnector.Reporter.MaybeReportError("Merging the diff patch with the current state did not work:", err) {
+		return currentJSONState, nil, false
+	}
+
+	// Determine the operations of the merged delta
+	mergedOperations, err := generics.JSONDiff(currentJSONState, mergedJSONState)
+
+	// Handle potential errors
+	if b.ModellingBusConnector.Reporter.MaybeReportError("Something went wrong running the JSON diff:", err) {
+		return currentJSONState, nil, false
+	}
+
+	for _, conflict := range conflicts {
+		b.ModellingBusConnector.Reporter.Error("Conflicting concurrent update of artefact %s at %s. Keeping %s instead of %s.", b.ArtefactID, conflict.Path, conflict.Theirs, conflict.Mine)
+	}
+	b.lastMergeConflicts = conflicts
+
+	return mergedJSONState, mergedOperations, true
+}
+
+// Get the hash of a JSON artefact state, in the same form as the checksums of (uncompressed) files in the repository
+func stateHashOf(stateJSON []byte) string {
+	stateHash := sha256.Sum256(stateJSON)
+
+	return hex.EncodeToString(stateHash[:])
+}
+
+// Check whether the given state is the state currently posted, without any updates or considerings on top of it.
+// If so, the timestamp of its posting is returned as well.
+func (b *TModellingBusArtefactConnector) postedStateTimestamp(stateJSON []byte) (string, bool) {
+	// Within a session, we know the last posted state
+	b.updateMutex.Lock()
+	knowsPostedState := b.lastStateHash != ""
+	isPostedState := stateHashOf(stateJSON) == b.lastStateHash &&
+		bytes.Equal(b.UpdatedContent, b.CurrentContent) &&
+		bytes.Equal(b.ConsideredContent, b.CurrentContent)
+	currentTimestamp := b.CurrentTimestamp
+	b.updateMutex.Unlock()
+
+	if knowsPostedState {
+		return currentTimestamp, isPostedState
+	}
+
+	// Otherwise, e.g. after a restart, check the link to our posted state on the modelling bus.
+	// The checksum in the link is of the state as stored, which may be pretty-printed.
+	eventsConnector := b.ModellingBusConnector.modellingBusEventsConnector
+	event := tRepositoryEvent{}
+	message := eventsConnector.messageFromEvent(b.ModellingBusConnector.agentID, b.jsonArtefactsStateTopicPath(b.ArtefactID))
+	if json.Unmarshal(message, &event) != nil || event.Compressed || event.Checksum != stateHashOf(b.ModellingBusConnector.modellingBusRepositoryConnector.storedJSON(stateJSON)) {
+		return "", false
+	}
+
+	// Updates or considerings on top of the posted state need to be superseded by a new posting of the state
+	if len(eventsConnector.messageFromEvent(b.ModellingBusConnector.agentID, b.jsonArtefactsUpdateTopicPath(b.ArtefactID))) > 0 ||
+		len(eventsConnector.messageFromEvent(b.ModellingBusConnector.agentID, b.jsonArtefactsConsideringTopicPath(b.ArtefactID))) > 0 {
+		return "", false
+	}
+
+	return event.Timestamp, true
+}
+
+// Checking whether a received posting is in the JSON version we expect, reporting a version mismatch if not
+func (b *TModellingBusArtefactConnector) acceptsJSONVersion(artefactID, jsonVersion string) bool {
+	// Postings from before JSON versions were mentioned in the links are assumed to be in the expected version
+	if jsonVersion == "" {
+		jsonVersion = b.JSONVersion
+	}
+	b.ReceivedJSONVersion = jsonVersion
+
+	// Do not apply postings in another JSON version
+	if jsonVersion != b.JSONVersion {
+		b.ModellingBusConnector.Reporter.Error("JSON version mismatch for artefact %s: received version %s, while expecting version %s. Ignoring the posting.", artefactID, jsonVersion, b.JSONVersion)
+
+		return false
+	}
+
+	return true
 }
 
 // Updating the current JSON artefact state
 func (b *TModellingBusArtefactConnector) updateCurrentJSONArtefact(json []byte, currentTimestamp string) {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	b.setCurrentJSONArtefact(json, currentTimestamp)
+}
+
+// Setting the current JSON artefact state.
+// Should only be called while holding the update mutex.
+func (b *TModellingBusArtefactConnector) setCurrentJSONArtefact(json []byte, currentTimestamp string) {
+	// States may have been stored pretty-printed, so compact them, as the content should not depend on how it was stored
+	json = generics.CompactJSON(json)
+
+	// Keep the state this state replaces, so updates made against it can still be merged
+	if currentTimestamp != b.CurrentTimestamp {
+		b.previousContent = b.CurrentContent
+		b.previousTimestamp = b.CurrentTimestamp
+	}
+
 	// Update the current JSON artefact state
 	b.CurrentContent = json
 	b.UpdatedContent = json
 	b.ConsideredContent = json
 	b.CurrentTimestamp = currentTimestamp
+
+	// A new state makes earlier deltas irrelevant
+	b.lastUpdateOperations = nil
+	b.lastConsideringOperations = nil
+	b.lastMergeConflicts = nil
 }
 
 // Updating the updated JSON artefact state
 func (b *TModellingBusArtefactConnector) updateUpdatedJSONArtefact(json []byte, _ ...string) bool {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
 	// If the json is empty, then the updated state, and considered state, are the same as the current state
 	if len(json) == 0 {
 		b.UpdatedContent = b.CurrentContent
 		b.ConsideredContent = b.CurrentContent
+		b.lastUpdateOperations = nil
+		b.lastConsideringOperations = nil
 
 		return true
 	}
 
 	// Apply the delta to the current content
-	ok := false
-	b.UpdatedContent, ok = b.applyJSONDelta(b.CurrentContent, json)
+	b.lastMergeConflicts = nil
+	updatedContent, operations, ok := b.applyJSONDelta(b.CurrentContent, json, true)
+	b.UpdatedContent = updatedContent
 	if ok {
 		b.ConsideredContent = b.UpdatedContent
+		b.lastUpdateOperations = operations
+		b.lastConsideringOperations = nil
 	}
 
 	// Return whether the update was successful
@@ -184,16 +419,23 @@ func (b *TModellingBusArtefactConnector) updateUpdatedJSONArtefact(json []byte,
 
 // Updating the considered JSON artefact state
 func (b *TModellingBusArtefactConnector) updateConsideringJSONArtefact(json []byte, _ ...string) bool {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
 	// If the json is empty, then the considered state is the same as the updated state
 	if len(json) == 0 {
 		b.ConsideredContent = b.UpdatedContent
+		b.lastConsideringOperations = nil
 
 		return true
 	}
 
 	// Apply the delta to the updated content
-	ok := false
-	b.ConsideredContent, ok = b.applyJSONDelta(b.UpdatedContent, json)
+	consideredContent, operations, ok := b.applyJSONDelta(b.UpdatedContent, json, false)
+	b.ConsideredContent = consideredContent
+	if ok {
+		b.lastConsideringOperations = operations
+	}
 
 	// Return whether the update was successful
 	return ok
@@ -211,91 +453,618 @@ func (b *TModellingBusArtefactConnector) updateConsideringJSONArtefact(json []by
 
 // Posting raw artefact state
 func (b *TModellingBusArtefactConnector) PostRawArtefactState(localFilePath string) {
+	b.PostRawArtefactStateE(localFilePath)
+}
+
+// Posting raw artefact state, returning the error (if any) that made the posting fail.
+// The format of the raw artefact state is derived from the extension of the file.
+func (b *TModellingBusArtefactConnector) PostRawArtefactStateE(localFilePath string) error {
+	return b.PostRawArtefactStateWithFormatE(localFilePath, "")
+}
+
+// Posting raw artefact state in a given format (such as "png" or "xml"), which listeners can find in the posting information
+func (b *TModellingBusArtefactConnector) PostRawArtefactStateWithFormat(localFilePath, format string) {
+	b.PostRawArtefactStateWithFormatE(localFilePath, format)
+}
+
+// Posting raw artefact state in a given format, returning the error (if any) that made the posting fail.
+// When no format is given, it is derived from the extension of the file.
+func (b *TModellingBusArtefactConnector) PostRawArtefactStateWithFormatE(localFilePath, format string) error {
+	if format == "" {
+		format = formatOfExtension(filepath.Ext(localFilePath))
+	}
+
+	// Post the raw artefact state
+	return b.ModellingBusConnector.postFormattedFile(b.rawArtefactsTopicPath(b.ArtefactID), localFilePath, b.payloadFileName, format, generics.GetTimestamp())
+}
+
+// Posting raw artefact state, reading its content from the given reader (e.g. an in-memory rendering).
+// The extension (such as ".svg") is used for the name of the payload file in the repository.
+func (b *TModellingBusArtefactConnector) PostRawArtefactStateFromReader(reader io.Reader, extension string) {
+	b.PostRawArtefactStateFromReaderE(reader, extension)
+}
+
+// Posting raw artefact state from a reader, returning the error (if any) that made the posting fail
+func (b *TModellingBusArtefactConnector) PostRawArtefactStateFromReaderE(reader io.Reader, extension string) error {
 	// Post the raw artefact state
-	b.ModellingBusConnector.postFile(b.rawArtefactsTopicPath(b.ArtefactID), localFilePath, generics.GetTimestamp())
+	return b.ModellingBusConnector.postFormattedReader(b.rawArtefactsTopicPath(b.ArtefactID), reader, b.payloadFileName, extension, formatOfExtension(extension), generics.GetTimestamp())
 }
 
 // Posting JSON artefact state
 func (b *TModellingBusArtefactConnector) PostJSONArtefactState(stateJSON []byte, okJSONing bool) {
+	b.PostJSONArtefactStateE(stateJSON, okJSONing)
+}
+
+// Posting JSON artefact state, returning the error (if any) that made the posting fail
+func (b *TModellingBusArtefactConnector) PostJSONArtefactStateE(stateJSON []byte, okJSONing bool) error {
 	// If not ok, then do not proceed
 	if !okJSONing {
-		return
+		return ErrMarshal
 	}
 
-	// Post the JSON artefact state
+	// Do not re-post the very same state, e.g. after a restart, but keep the timestamp of the earlier posting
+	if timestamp, isPosted := b.postedStateTimestamp(stateJSON); isPosted {
+		b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "State of artefact %s has already been posted. Not posting it again.", b.ArtefactID)
+
+		b.updateMutex.Lock()
+		b.stopPendingUpdate()
+		b.CurrentTimestamp = timestamp
+		b.CurrentContent = stateJSON
+		b.UpdatedContent = stateJSON
+		b.ConsideredContent = stateJSON
+		b.lastStateHash = stateHashOf(stateJSON)
+		b.stateCommunicated = true
+		b.updateMutex.Unlock()
+
+		return nil
+	}
+
+	_, err := b.postJSONArtefactState(stateJSON, "")
+
+	return err
+}
+
+// Posting JSON artefact state, even when the very same state has been posted before
+func (b *TModellingBusArtefactConnector) ForcePostState(stateJSON []byte) error {
+	_, err := b.postJSONArtefactState(stateJSON, "")
+
+	return err
+}
+
+// Posting JSON artefact state, and waiting (up to the timeout) for listeners to acknowledge its receipt.
+// Returns the number of listeners that acknowledged the posting.
+func (b *TModellingBusArtefactConnector) PostJSONArtefactStateWithAck(stateJSON []byte, timeout time.Duration) (int, error) {
+	return b.PostJSONArtefactStateWithAcks(stateJSON, 0, timeout)
+}
+
+// Posting JSON artefact state, and waiting for the expected number of listeners to acknowledge its receipt.
+// Stops waiting at the timeout, or right away once the expected number of listeners acknowledged the posting.
+// With 0 expected listeners, it waits until the timeout. Returns the number of listeners that acknowledged the posting.
+func (b *TModellingBusArtefactConnector) PostJSONArtefactStateWithAcks(stateJSON []byte, expectedAcks int, timeout time.Duration) (int, error) {
+	return b.ModellingBusConnector.postWithAcknowledgements(b.jsonArtefactsStateTopicPath(b.ArtefactID), expectedAcks, timeout, func(ackID string) error {
+		_, err := b.postJSONArtefactState(stateJSON, ackID)
+
+		return err
+	})
+}
+
+// Posting JSON artefact state in our JSON version, as well as in (some of) the additional version channels.
+// All states are posted with the same timestamp, so listeners can tell they represent the same state.
+// The additional version channels only carry states, so changes should be posted in them as new states.
+func (b *TModellingBusArtefactConnector) PostJSONArtefactStateInVersions(statesByVersion map[string][]byte) error {
+	// The state in our JSON version is needed, as the other versions are posted alongside it
+	stateJSON, hasState := statesByVersion[b.JSONVersion]
+	if !hasState {
+		err := fmt.Errorf("no state given in JSON version %s", b.JSONVersion)
+		b.ModellingBusConnector.Reporter.ReportError("Cannot post the artefact state:", err)
+
+		return err
+	}
+
+	// Only registered version channels can be posted on
+	for jsonVersion := range statesByVersion {
+		if jsonVersion != b.JSONVersion && !slices.Contains(b.versionChannels, jsonVersion) {
+			err := fmt.Errorf("JSON version %s has not been added as version channel", jsonVersion)
+			b.ModellingBusConnector.Reporter.ReportError("Cannot post the artefact state:", err)
+
+			return err
+		}
+	}
+
+	// Post the state in our JSON version
+	currentTimestamp, err := b.postJSONArtefactState(stateJSON, "")
+	if err != nil {
+		return err
+	}
+
+	// Post the state in the other versions, using the same timestamp
+	for _, jsonVersion := range b.versionChannels {
+		if versionStateJSON, hasVersionState := statesByVersion[jsonVersion]; hasVersionState {
+			if err := b.ModellingBusConnector.postNamedVersionedJSONAsFile(b.jsonArtefactsStateTopicPathIn(jsonVersion, b.ArtefactID), b.payloadFileName, jsonVersion, "", versionStateJSON, currentTimestamp); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Posting JSON artefact state, asking listeners to acknowledge its receipt when an ack ID is given.
+// Returns the timestamp of the posted state.
+func (b *TModellingBusArtefactConnector) postJSONArtefactState(stateJSON []byte, ackID string) (string, error) {
+	// The content is updated while holding the update mutex, but the posting is done without it, so listeners are not blocked
+	b.updateMutex.Lock()
+
+	// A new state supersedes any pending update
+	b.stopPendingUpdate()
+
+	// Update the content
 	b.CurrentTimestamp = generics.GetTimestamp()
 	b.CurrentContent = stateJSON
 	b.UpdatedContent = stateJSON
 	b.ConsideredContent = stateJSON
-	b.ModellingBusConnector.postJSONAsFile(b.jsonArtefactsStateTopicPath(b.ArtefactID), b.CurrentContent, b.CurrentTimestamp)
+	currentTimestamp := b.CurrentTimestamp
 
 	// Mark that the state has been communicated
 	b.stateCommunicated = true
+	b.updateMutex.Unlock()
+
+	// Post the JSON artefact state
+	err := b.ModellingBusConnector.postNamedVersionedJSONAsFile(b.jsonArtefactsStateTopicPath(b.ArtefactID), b.payloadFileName, b.JSONVersion, ackID, stateJSON, currentTimestamp)
+	if err == nil {
+		b.updateMutex.Lock()
+		b.lastStateHash = stateHashOf(stateJSON)
+		b.updateMutex.Unlock()
+	}
+
+	return currentTimestamp, err
+}
+
+// Check whether the state has been communicated
+func (b *TModellingBusArtefactConnector) isStateCommunicated() bool {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	return b.stateCommunicated
 }
 
 // Posting JSON artefact update
+// When coalescing updates, the actual posting is deferred until the coalescing window has passed,
+// or until FlushPendingUpdates is called.
 func (b *TModellingBusArtefactConnector) PostJSONArtefactUpdate(updatedStateJSON []byte, okJSONing bool) {
+	b.PostJSONArtefactUpdateE(updatedStateJSON, okJSONing)
+}
+
+// Posting JSON artefact update, returning the error (if any) that made the posting fail
+// When the update is deferred due to coalescing, no error is returned.
+func (b *TModellingBusArtefactConnector) PostJSONArtefactUpdateE(updatedStateJSON []byte, okJSONing bool) error {
 	// If not ok, then do not proceed
 	if !okJSONing {
-		return
+		return ErrMarshal
 	}
 
 	// Ensure the state has been communicated
-	if !b.stateCommunicated {
-		b.PostJSONArtefactState(updatedStateJSON, okJSONing)
+	if !b.isStateCommunicated() {
+		if err := b.PostJSONArtefactStateE(updatedStateJSON, okJSONing); err != nil {
+			return err
+		}
+	}
+
+	b.updateMutex.Lock()
+
+	// Nothing changed since the latest update, so there is no need to post anything
+	if !b.hasJSONChanges(b.UpdatedContent, updatedStateJSON) {
+		b.updateMutex.Unlock()
+		b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "No changes to artefact %s, so no update is posted.", b.ArtefactID)
+
+		return nil
 	}
 
-	// Post the JSON artefact update
 	b.UpdatedContent = updatedStateJSON
 	b.ConsideredContent = updatedStateJSON
-	b.postJSONDelta(b.jsonArtefactsUpdateTopicPath(b.ArtefactID), b.CurrentContent, b.UpdatedContent)
+
+	// When coalescing, mark the update as pending, and make sure it will be flushed
+	if b.updateCoalescingWindow > 0 {
+		b.updatePending = true
+		if b.updateTimer == nil {
+			b.updateTimer = time.AfterFunc(b.updateCoalescingWindow, b.FlushPendingUpdates)
+		}
+		b.updateMutex.Unlock()
+
+		return nil
+	}
+
+	// Otherwise, post the JSON artefact update right away
+	currentContent, currentTimestamp := b.CurrentContent, b.CurrentTimestamp
+	b.updateMutex.Unlock()
+
+	return b.postJSONDelta(b.jsonArtefactsUpdateTopicPath(b.ArtefactID), currentTimestamp, currentContent, updatedStateJSON)
 }
 
 // Posting JSON considered artefact
 func (b *TModellingBusArtefactConnector) PostJSONArtefactConsidering(consideringStateJSON []byte, okJSONing bool) {
+	b.PostJSONArtefactConsideringE(consideringStateJSON, okJSONing)
+}
+
+// Posting JSON considered artefact, returning the error (if any) that made the posting fail
+func (b *TModellingBusArtefactConnector) PostJSONArtefactConsideringE(consideringStateJSON []byte, okJSONing bool) error {
 	// If not ok, then do not proceed
 	if !okJSONing {
-		return
+		return ErrMarshal
 	}
 
 	// Ensure the state has been communicated
-	if !b.stateCommunicated {
-		b.PostJSONArtefactState(b.CurrentContent, okJSONing)
+	if !b.isStateCommunicated() {
+		currentContent, _, _ := b.GetContents()
+		if err := b.PostJSONArtefactStateE(currentContent, okJSONing); err != nil {
+			return err
+		}
 	}
 
+	// The considering delta is relative to the updated content, so listeners need to have received the latest update
+	b.FlushPendingUpdates()
+
 	// Post the JSON considered artefact
+	b.updateMutex.Lock()
 	b.ConsideredContent = consideringStateJSON
+	updatedContent, currentTimestamp := b.UpdatedContent, b.CurrentTimestamp
+	b.updateMutex.Unlock()
 
 	// Post the JSON considered artefact
-	b.postJSONDelta(b.jsonArtefactsConsideringTopicPath(b.ArtefactID), b.UpdatedContent, b.ConsideredContent)
+	return b.postJSONDelta(b.jsonArtefactsConsideringTopicPath(b.ArtefactID), currentTimestamp, updatedContent, consideringStateJSON)
+}
+
+// Withdrawing the considered content, e.g. when retracting a proposed change.
+// The considered content is reset to the updated content, and this is posted as a considering.
+// When no state has been communicated yet, there cannot be a considering to withdraw, so nothing is posted.
+func (b *TModellingBusArtefactConnector) WithdrawConsidering() {
+	b.WithdrawConsideringE()
+}
+
+// Withdrawing the considered content, returning the error (if any) that made the posting fail
+func (b *TModellingBusArtefactConnector) WithdrawConsideringE() error {
+	// Without a communicated state, there is nothing to withdraw
+	b.updateMutex.Lock()
+	stateCommunicated := b.stateCommunicated
+	if !stateCommunicated {
+		b.ConsideredContent = b.UpdatedContent
+	}
+	updatedContent := b.UpdatedContent
+	b.updateMutex.Unlock()
+
+	if !stateCommunicated {
+		return nil
+	}
+
+	// Post the updated content as the considered content
+	return b.PostJSONArtefactConsideringE(updatedContent, true)
+}
+
+// Promoting the considered content to an update, e.g. when accepting a proposed change.
+// The considered content is posted as an update (relative to the current content), after which an empty
+// considering is posted, so the considered content is reset to the (new) updated content.
+func (b *TModellingBusArtefactConnector) PromoteConsideredToUpdate() {
+	// Post the considered content as an update
+	_, _, consideredContent := b.GetContents()
+	b.PostJSONArtefactUpdate(consideredContent, true)
+
+	// Reset the considered content
+	_, updatedContent, _ := b.GetContents()
+	b.PostJSONArtefactConsidering(updatedContent, true)
+}
+
+// Get the current, updated, and considered content of the artefact.
+// As listeners and coalesced updates may change the content concurrently, this should be preferred over reading the fields.
+func (b *TModellingBusArtefactConnector) GetContents() (json.RawMessage, json.RawMessage, json.RawMessage) {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	return b.CurrentContent, b.UpdatedContent, b.ConsideredContent
+}
+
+// Get the value at the given JSON pointer in the current content of the artefact, and whether it exists
+func (b *TModellingBusArtefactConnector) GetCurrentField(pointer string) (json.RawMessage, bool) {
+	currentContent, _, _ := b.GetContents()
+
+	return generics.JSONGet(currentContent, pointer)
+}
+
+// Check whether the given JSON delta (of an update or considering) would be posted inline, rather than as a file.
+// States are always posted as files.
+func (b *TModellingBusArtefactConnector) WouldInline(json []byte) bool {
+	return b.ModellingBusConnector.modellingBusEventsConnector.allowsInline(generics.PostingSize(json))
+}
+
+/*
+ * Coalescing artefact updates
+ */
+
+// Adding an additional JSON version in which the artefact's states can be posted, and listened to as fallback.
+// This allows for posting an artefact in multiple JSON versions side by side, e.g. while migrating to a new language version.
+func (b *TModellingBusArtefactConnector) AddVersionChannel(jsonVersion string) {
+	if jsonVersion != b.JSONVersion && !slices.Contains(b.versionChannels, jsonVersion) {
+		b.versionChannels = append(b.versionChannels, jsonVersion)
+	}
+}
+
+// Setting the JSON pointers of the parts of the artefact for which changes never appear in a delta.
+// Changes to these parts are only communicated when posting a state.
+func (b *TModellingBusArtefactConnector) SetDeltaIgnoredPaths(ignoredPaths ...string) {
+	b.deltaIgnoredPaths = ignoredPaths
+}
+
+// Setting the name of the payload files of our postings in the repository, such as "payload.json" for JSON artefacts, or
+// "payload.png" for raw PNG artefacts, so the files can be recognised when browsing the FTP server (empty means the default name).
+// Listeners are not affected, as the links to the files mention their exact names. When JSON payloads are compressed, ".gz" is added.
+func (b *TModellingBusArtefactConnector) SetPayloadFileName(payloadFileName string) {
+	b.payloadFileName = generics.SanitizeTopicElement(payloadFileName)
+}
+
+// Setting the window within which updates are coalesced into one update posting (0 disables coalescing)
+func (b *TModellingBusArtefactConnector) SetUpdateCoalescingWindow(window time.Duration) {
+	// Post whatever is still pending under the old window
+	b.FlushPendingUpdates()
+
+	// Set the new window
+	b.updateMutex.Lock()
+	b.updateCoalescingWindow = window
+	b.updateMutex.Unlock()
+}
+
+// Forcing the posting of a pending (coalesced) update
+func (b *TModellingBusArtefactConnector) FlushPendingUpdates() {
+	b.updateMutex.Lock()
+
+	// Take the pending update, if any
+	updatePending := b.updatePending
+	currentContent, updatedContent, currentTimestamp := b.CurrentContent, b.UpdatedContent, b.CurrentTimestamp
+	b.stopPendingUpdate()
+	b.updateMutex.Unlock()
+
+	// Post it as one delta from the current state to the latest updated content
+	if updatePending {
+		b.postJSONDelta(b.jsonArtefactsUpdateTopicPath(b.ArtefactID), currentTimestamp, currentContent, updatedContent)
+	}
+}
+
+// Cancelling a pending (coalesced) update, e.g. when it is superseded by a new state.
+// Should only be called while holding the update mutex.
+func (b *TModellingBusArtefactConnector) stopPendingUpdate() {
+	// Stop the timer, if any
+	if b.updateTimer != nil {
+		b.updateTimer.Stop()
+		b.updateTimer = nil
+	}
+
+	// Nothing is pending anymore
+	b.updatePending = false
+}
+
+/*
+ * Pausing handlers
+ */
+
+// Make a handler pausable, so calling it is deferred while the handlers are paused
+func (b *TModellingBusArtefactConnector) pausable(handler func()) func() {
+	p := b.handlerPause
+	if p == nil {
+		return handler
+	}
+
+	// Identify the handler
+	p.mutex.Lock()
+	handlerID := p.handlerCount
+	p.handlerCount++
+	p.mutex.Unlock()
+
+	return func() {
+		// Defer calling the handler while paused, calling it only once when resuming
+		p.mutex.Lock()
+		if p.paused {
+			if !p.deferredIDs[handlerID] {
+				p.deferredIDs[handlerID] = true
+				p.deferredHandlers = append(p.deferredHandlers, handler)
+			}
+			p.mutex.Unlock()
+
+			return
+		}
+		p.mutex.Unlock()
+
+		handler()
+	}
+}
+
+// Pause calling the handlers of JSON artefact state, update, and considering postings, e.g. during a burst of postings.
+// While paused, the postings are still applied to the content of the artefact. Calling the handlers is deferred until
+// the handlers are resumed.
+func (b *TModellingBusArtefactConnector) PauseHandlers() {
+	if b.handlerPause == nil {
+		return
+	}
+
+	b.handlerPause.mutex.Lock()
+	defer b.handlerPause.mutex.Unlock()
+
+	b.handlerPause.paused = true
+}
+
+// Resume calling the handlers, calling each handler that was deferred while paused once, with the latest content.
+// As the postings received while paused were applied as they arrived, pausing does not change how postings that
+// arrive out of order are dealt with. For instance, an update that cannot be applied to the state received before it
+// is still rejected, and does not lead to a deferred call. The handlers only see the outcome once.
+func (b *TModellingBusArtefactConnector) ResumeHandlers() {
+	if b.handlerPause == nil {
+		return
+	}
+
+	// Take the deferred handlers
+	b.handlerPause.mutex.Lock()
+	b.handlerPause.paused = false
+	deferredHandlers := b.handlerPause.deferredHandlers
+	b.handlerPause.deferredHandlers = nil
+	b.handlerPause.deferredIDs = map[int]bool{}
+	b.handlerPause.mutex.Unlock()
+
+	// Call them
+	for _, handler := range deferredHandlers {
+		handler()
+	}
 }
 
 /*
  * Listening to artefact related postings
  */
 
-// Listening for raw artefact state postings
+// Get the operations (as an RFC 6902 JSON patch) of the most recently received update, or nil if there is none
+func (b *TModellingBusArtefactConnector) LastUpdateOperations() json.RawMessage {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	return b.lastUpdateOperations
+}
+
+// Get the conflicts of the most recently received update, when it had to be merged into the current state as it was made against the previous state
+func (b *TModellingBusArtefactConnector) LastMergeConflicts() []generics.TJSONConflict {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	return b.lastMergeConflicts
+}
+
+// Get the operations (as an RFC 6902 JSON patch, relative to the updated content) of the most recently received considering, or nil if there is none
+func (b *TModellingBusArtefactConnector) LastConsideringOperations() json.RawMessage {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+
+	return b.lastConsideringOperations
+}
+
+// Listening for raw artefact state postings.
+// The posting handler gets the path of a local copy of the posted file, which is removed once the handler returns.
 func (b *TModellingBusArtefactConnector) ListenForRawArtefactStatePostings(agentID, artefactID string, postingHandler func(string)) {
 	// Listen for raw artefact state postings
-	b.ModellingBusConnector.listenForFilePostings(agentID, b.rawArtefactsTopicPath(artefactID), generics.JSONFileName, func(localFilePath, _ string) {
+	b.ModellingBusConnector.listenForFilePostings(agentID, b.rawArtefactsTopicPath(artefactID), func(localFilePath, _ string) {
 		postingHandler(localFilePath)
 	})
 }
 
+// Listening for raw artefact state postings, also passing on where the posting came from
+func (b *TModellingBusArtefactConnector) ListenForRawArtefactStatePostingsWithInfo(agentID, artefactID string, postingHandler func(string, TPostingInfo)) {
+	b.ModellingBusConnector.listenForFilePostingsWithInfo(agentID, b.rawArtefactsTopicPath(artefactID), postingHandler)
+}
+
 // Listening for JSON artefact state postings
 func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostings(agentID, artefactID string, handler func()) {
+	b.ListenForJSONArtefactStatePostingsInEnv(b.ModellingBusConnector.environmentID, agentID, artefactID, handler)
+}
+
+// Listening for JSON artefact state postings in a given modelling environment
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostingsInEnv(environmentID, agentID, artefactID string, handler func()) {
+	handler = b.pausable(handler)
+
 	// Listen for JSON artefact state postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsStateTopicPath(artefactID), func(json []byte, currentTimestamp string) {
-		b.updateCurrentJSONArtefact(json, currentTimestamp)
-		handler()
+	b.ModellingBusConnector.listenForVersionedJSONFilePostingsIn(environmentID, agentID, b.jsonArtefactsStateTopicPath(artefactID), func(json []byte, currentTimestamp, jsonVersion string) {
+		if b.acceptsJSONVersion(artefactID, jsonVersion) {
+			b.updateCurrentJSONArtefact(json, currentTimestamp)
+			handler()
+		}
 	})
 }
 
+// Listening for JSON artefact state postings in our JSON version, falling back to the additional version channels (in the
+// order in which they were added) for states that are not posted in our JSON version. The handler can use
+// ReceivedJSONVersion to see in which JSON version the current content is.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostingsWithFallback(agentID, artefactID string, handler func()) {
+	handler = b.pausable(handler)
+
+	for rank, jsonVersion := range append([]string{b.JSONVersion}, b.versionChannels...) {
+		b.ModellingBusConnector.listenForVersionedJSONFilePostingsIn(b.ModellingBusConnector.environmentID, agentID, b.jsonArtefactsStateTopicPathIn(jsonVersion, artefactID), func(json []byte, currentTimestamp, _ string) {
+			b.updateMutex.Lock()
+
+			// Prefer newer states, and for the same state, the more preferred JSON version.
+			// Without a state yet, the current timestamp is only the creation time of the connector, so any state is accepted.
+			hasState := len(b.CurrentContent) > 0
+			if order := generics.CompareTimestamps(currentTimestamp, b.CurrentTimestamp); hasState && (order < 0 || order == 0 && rank >= b.receivedVersionRank) {
+				b.updateMutex.Unlock()
+
+				return
+			}
+
+			// Update the current JSON artefact state
+			b.receivedVersionRank = rank
+			b.ReceivedJSONVersion = jsonVersion
+			b.setCurrentJSONArtefact(json, currentTimestamp)
+			b.updateMutex.Unlock()
+
+			handler()
+		})
+	}
+}
+
 // Listening for JSON artefact update postings
 func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostings(agentID, artefactID string, handler func()) {
+	b.ListenForJSONArtefactUpdatePostingsInEnv(b.ModellingBusConnector.environmentID, agentID, artefactID, handler)
+}
+
+// Catching up with the postings of a JSON artefact in the repository, e.g. when starting to listen after postings have been made.
+// The retained link on the modelling bus may be an update relative to a state we never saw, so the latest state is retrieved
+// from the repository, after which the latest update posted on top of it (if any) is applied.
+// Returns whether anything was caught up with. Updates posted inline are not kept in the repository, so they cannot be caught up with.
+func (b *TModellingBusArtefactConnector) catchUpFromRepository(environmentID, agentID, artefactID string) bool {
+	// Find the latest state in the repository
+	stateTimestamps, err := b.ModellingBusConnector.getPostingTimestampsIn(environmentID, agentID, b.jsonArtefactsStateTopicPath(artefactID))
+	if err != nil || len(stateTimestamps) == 0 {
+		return false
+	}
+	stateTimestamp := stateTimestamps[len(stateTimestamps)-1]
+
+	// Get that state, unless we already have it
+	b.updateMutex.Lock()
+	hasState := stateTimestamp == b.CurrentTimestamp && len(b.CurrentContent) > 0
+	b.updateMutex.Unlock()
+
+	caughtUp := false
+	if !hasState {
+		stateJSON, err := b.ModellingBusConnector.getPostingContentIn(environmentID, agentID, b.jsonArtefactsStateTopicPath(artefactID), stateTimestamp)
+		if b.ModellingBusConnector.Reporter.MaybeReportError("Something went wrong catching up with the artefact state:", err) {
+			return false
+		}
+		b.updateCurrentJSONArtefact(stateJSON, stateTimestamp)
+		caughtUp = true
+	}
+
+	// Get the updates posted since the state
+	updateTimestamps, err := b.ModellingBusConnector.getPostingTimestampsIn(environmentID, agentID, b.jsonArtefactsUpdateTopicPath(artefactID))
+	if err != nil {
+		return caughtUp
+	}
+
+	// Apply the latest of these updates that chains to the state, working backwards
+	for i := len(updateTimestamps) - 1; i >= 0 && generics.CompareTimestamps(updateTimestamps[i], stateTimestamp) > 0; i-- {
+		deltaJSON, err := b.ModellingBusConnector.getPostingContentIn(environmentID, agentID, b.jsonArtefactsUpdateTopicPath(artefactID), updateTimestamps[i])
+		if err == nil && b.updateUpdatedJSONArtefact(deltaJSON) {
+			return true
+		}
+	}
+
+	return caughtUp
+}
+
+// Listening for JSON artefact update postings in a given modelling environment.
+// Before listening, the latest state and update are caught up with from the repository (of that modelling environment),
+// so updates relative to a state posted before we started listening can still be applied.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostingsInEnv(environmentID, agentID, artefactID string, handler func()) {
+	handler = b.pausable(handler)
+
+	// Catch up with what has been posted before
+	if b.catchUpFromRepository(environmentID, agentID, artefactID) {
+		handler()
+	}
+
 	// Listen for JSON artefact update postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsUpdateTopicPath(artefactID), func(json []byte, _ string) {
-		if b.updateUpdatedJSONArtefact(json) {
+	b.ModellingBusConnector.listenForVersionedJSONFilePostingsIn(environmentID, agentID, b.jsonArtefactsUpdateTopicPath(artefactID), func(json []byte, _, jsonVersion string) {
+		if b.acceptsJSONVersion(artefactID, jsonVersion) && b.updateUpdatedJSONArtefact(json) {
 			handler()
 		}
 	})
@@ -303,18 +1072,121 @@ func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostings(age
 
 // Listening for JSON considered artefact postings
 func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPostings(agentID, artefactID string, handler func()) {
+	b.ListenForJSONArtefactConsideringPostingsInEnv(b.ModellingBusConnector.environmentID, agentID, artefactID, handler)
+}
+
+// Listening for JSON considered artefact postings in a given modelling environment
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPostingsInEnv(environmentID, agentID, artefactID string, handler func()) {
+	handler = b.pausable(handler)
+
 	// Listen for JSON considered artefact postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsConsideringTopicPath(artefactID), func(json []byte, _ string) {
-		if b.updateConsideringJSONArtefact(json) {
+	b.ModellingBusConnector.listenForVersionedJSONFilePostingsIn(environmentID, agentID, b.jsonArtefactsConsideringTopicPath(artefactID), func(json []byte, _, jsonVersion string) {
+		if b.acceptsJSONVersion(artefactID, jsonVersion) && b.updateConsideringJSONArtefact(json) {
 			handler()
 		}
 	})
 }
 
+// Stop listening for the JSON artefact state, update, and considering postings of an artefact
+func (b *TModellingBusArtefactConnector) StopListeningForJSONArtefactPostings(agentID, artefactID string) {
+	b.StopListeningForJSONArtefactPostingsInEnv(b.ModellingBusConnector.environmentID, agentID, artefactID)
+}
+
+// Stop listening for the JSON artefact state, update, and considering postings of an artefact in a given modelling environment
+func (b *TModellingBusArtefactConnector) StopListeningForJSONArtefactPostingsInEnv(environmentID, agentID, artefactID string) {
+	b.ModellingBusConnector.modellingBusEventsConnector.stopListeningForEventsIn(environmentID, agentID, b.jsonArtefactsStateTopicPath(artefactID))
+	b.ModellingBusConnector.modellingBusEventsConnector.stopListeningForEventsIn(environmentID, agentID, b.jsonArtefactsUpdateTopicPath(artefactID))
+	b.ModellingBusConnector.modellingBusEventsConnector.stopListeningForEventsIn(environmentID, agentID, b.jsonArtefactsConsideringTopicPath(artefactID))
+}
+
+// Listening for changes of the value at the given JSON pointer in the (updated) content of a JSON artefact.
+// The states and updates are applied as usual, but the handler is only called when the value actually changed.
+// When the value does not exist (anymore), the handler is called with nil.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactFieldPostings(agentID, artefactID, pointer string, handler func(json.RawMessage)) {
+	lastValue := json.RawMessage(nil)
+	valueKnown := false
+
+	// Call the handler when the value changed
+	fieldHandler := func() {
+		_, updatedContent, _ := b.GetContents()
+		value, exists := generics.JSONGet(updatedContent, pointer)
+		if !exists {
+			value = nil
+		} else if canonicalValue, err := generics.CanonicalizeJSON(value); err == nil {
+			value = canonicalValue
+		}
+
+		if valueKnown && bytes.Equal(value, lastValue) {
+			return
+		}
+		lastValue = value
+		valueKnown = true
+
+		handler(value)
+	}
+
+	// Listen for the states and updates
+	b.ListenForJSONArtefactStatePostings(agentID, artefactID, fieldHandler)
+	b.ListenForJSONArtefactUpdatePostings(agentID, artefactID, fieldHandler)
+}
+
+// Listening for the JSON artefact state postings of all artefacts, by all agents, in all JSON versions, using MQTT wildcards.
+// The artefact ID is taken from the topic the state was received on. As the links to the files mention their full path,
+// the states are retrieved from the repository as with other listeners.
+// The subscription uses the subscribe_qos from the config file, as other listeners do. As the links to the states are retained,
+// the latest state of every artefact already posted is passed on when starting to listen, which may be a burst in busy environments.
+func (b *TModellingBusConnector) ListenForAllJSONArtefactStates(handler func(artefactID string, json []byte, timestamp string)) {
+	stateTopicPath := jsonArtefactsPathElement + "/+/+/" + artefactStatePathElement
+
+	b.modellingBusEventsConnector.listenForTopicEventsIn(b.environmentID, "+", stateTopicPath, func(topic string, message []byte) {
+		// Get the artefact ID from the topic, which is artefacts/json/<artefact id>/<json version>/state for the agent
+		_, topicPath, ok := b.modellingBusEventsConnector.agentTopicPathOf(topic)
+		pathElements := strings.Split(strings.TrimPrefix(topicPath, jsonArtefactsPathElement+"/"), "/")
+		if !ok || len(pathElements) != 3 {
+			return
+		}
+		artefactID, err := generics.UnsanitizeTopicElement(pathElements[0])
+		if err != nil {
+			artefactID = pathElements[0]
+		}
+
+		// Get the state
+		stateJSON, timestamp, _ := b.getJSONFromMessage(message)
+		if len(stateJSON) > 0 {
+			handler(artefactID, stateJSON, timestamp)
+		}
+	})
+}
+
 /*
  * Retrieving artefact states
  */
 
+// Checking whether a state of a JSON artefact has ever been posted, without retrieving it.
+// When the repository cannot be reached, the error is reported and false is returned.
+func (b *TModellingBusArtefactConnector) ArtefactStateExists(agentID, artefactID string) bool {
+	exists, err := b.ArtefactStateExistsE(agentID, artefactID)
+	b.ModellingBusConnector.Reporter.MaybeReportError("Could not check for the artefact state:", err)
+
+	return exists
+}
+
+// Checking whether a state of a JSON artefact has ever been posted, without retrieving it, returning the error (if any) that made the check fail
+func (b *TModellingBusArtefactConnector) ArtefactStateExistsE(agentID, artefactID string) (bool, error) {
+	// A link to the state on the modelling bus suffices
+	if len(b.ModellingBusConnector.modellingBusEventsConnector.messageFromEvent(agentID, b.jsonArtefactsStateTopicPath(artefactID))) > 0 {
+		return true, nil
+	}
+
+	// Otherwise, look for postings of the state in the repository
+	timestamps, err := b.ModellingBusConnector.getPostingTimestamps(agentID, b.jsonArtefactsStateTopicPath(artefactID))
+	if err != nil {
+		return false, err
+	}
+
+	return len(timestamps) > 0, nil
+}
+
 // Getting raw artefact state
 func (b *TModellingBusArtefactConnector) GetRawArtefact(agentID, artefactID, localFileName string) string {
 	// Get the raw artefact state
@@ -324,10 +1196,80 @@ func (b *TModellingBusArtefactConnector) GetRawArtefact(agentID, artefactID, loc
 	return filePath
 }
 
+// Getting raw artefact state, streaming it directly to the given writer, rather than to a local file.
+// As the file is not held in memory as a whole, this also works for raw artefacts that are larger than the available memory.
+// Returns the timestamp of the posting, or ErrNoPosting when nothing has been posted (yet).
+func (b *TModellingBusArtefactConnector) GetRawArtefactToWriter(agentID, artefactID string, w io.Writer) (string, error) {
+	return b.ModellingBusConnector.getFileFromPostingToWriter(agentID, b.rawArtefactsTopicPath(artefactID), w)
+}
+
 // Getting JSON artefact state
+// When no state has been posted (yet), or it could not be retrieved, the current JSON artefact state is left as is.
 func (b *TModellingBusArtefactConnector) GetJSONArtefactState(agentID, artefactID string) {
+	// Get the JSON artefact state
+	stateJSON, currentTimestamp, err := b.ModellingBusConnector.getJSONE(agentID, b.jsonArtefactsStateTopicPath(artefactID))
+	if err != nil {
+		return
+	}
+
 	// Update the current JSON artefact state
-	b.updateCurrentJSONArtefact(b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsStateTopicPath(artefactID)))
+	b.updateCurrentJSONArtefact(stateJSON, currentTimestamp)
+}
+
+// Getting the JSON states of several artefacts at once, retrieving them concurrently.
+// The number of concurrent retrievals is set by "max_concurrent_retrievals" in the "ftp" section of the config file.
+// Artefacts that could not be retrieved are left out of the returned states, and their errors are joined in the returned error.
+func (b *TModellingBusArtefactConnector) GetJSONArtefactStates(agentID string, artefactIDs []string) (map[string][]byte, error) {
+	maxConcurrentRetrievals := b.ModellingBusConnector.configData.GetValue("ftp", "max_concurrent_retrievals").IntWithDefault(defaultConcurrentRetrievals)
+
+	// Collect the states, and the errors, per artefact
+	states := map[string][]byte{}
+	errs := []error{}
+	resultsMutex := sync.Mutex{}
+
+	// Retrieve the states, using a bounded number of workers
+	workers := make(chan bool, max(maxConcurrentRetrievals, 1))
+	retrievals := sync.WaitGroup{}
+	for _, artefactID := range artefactIDs {
+		workers <- true
+		retrievals.Add(1)
+
+		go func() {
+			defer func() {
+				<-workers
+				retrievals.Done()
+			}()
+
+			stateJSON, _, err := b.ModellingBusConnector.getJSONE(agentID, b.jsonArtefactsStateTopicPath(artefactID))
+
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("artefact %s: %w", artefactID, err))
+			} else {
+				states[artefactID] = stateJSON
+			}
+		}()
+	}
+	retrievals.Wait()
+
+	return states, errors.Join(errs...)
+}
+
+// Awaiting JSON artefact state, returning the current one if there is one already.
+// Otherwise, the first state posted within the timeout is returned. This requires a connector that is not posting only.
+func (b *TModellingBusArtefactConnector) AwaitJSONArtefactState(agentID, artefactID string, timeout time.Duration) ([]byte, error) {
+	// Await the JSON artefact state
+	stateJSON, currentTimestamp, err := b.ModellingBusConnector.awaitJSON(agentID, b.jsonArtefactsStateTopicPath(artefactID), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the current JSON artefact state
+	b.updateCurrentJSONArtefact(stateJSON, currentTimestamp)
+
+	return stateJSON, nil
 }
 
 // Getting JSON artefact update
@@ -348,6 +1290,136 @@ func (b *TModellingBusArtefactConnector) GetJSONArtefactConsidering(agentID, art
 	b.updateConsideringJSONArtefact(b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsConsideringTopicPath(artefactID)))
 }
 
+/*
+ * Retrieving artefact state versions
+ */
+
+// Listing the timestamps of the posted states of a JSON artefact that are still kept in the repository.
+// By default, all states are kept, unless the posting agent configured a retention that prunes older ones.
+func (b *TModellingBusArtefactConnector) ListStateVersions(agentID, artefactID string) ([]string, error) {
+	return b.ModellingBusConnector.getPostingTimestamps(agentID, b.jsonArtefactsStateTopicPath(artefactID))
+}
+
+// Getting the posted state of a JSON artefact with the given timestamp
+func (b *TModellingBusArtefactConnector) GetStateVersion(agentID, artefactID, timestamp string) ([]byte, error) {
+	return b.ModellingBusConnector.getPostingContent(agentID, b.jsonArtefactsStateTopicPath(artefactID), timestamp)
+}
+
+// Replaying the posted states and updates of a JSON artefact, to reconstruct its content at the given timestamp.
+// Each update delta is relative to the state that was current when it was posted, rather than to the previous update.
+// So, the content at the given timestamp follows from the latest state posted at or before it, combined with the latest
+// update posted (at or before it) on top of that state. Updates that do not chain to that state are skipped.
+// Note that updates posted inline (see [mqtt] max_inline_bytes) are not kept in the repository, so they cannot be replayed.
+func (b *TModellingBusArtefactConnector) ReplayTo(agentID, artefactID, targetTimestamp string) (json.RawMessage, error) {
+	// Find the latest state posted at or before the target timestamp
+	stateTimestamps, err := b.ListStateVersions(agentID, artefactID)
+	if err != nil {
+		return nil, err
+	}
+	stateTimestamp := ""
+	for _, timestamp := range stateTimestamps {
+		if generics.CompareTimestamps(timestamp, targetTimestamp) <= 0 {
+			stateTimestamp = timestamp
+		}
+	}
+	if stateTimestamp == "" {
+		return nil, fmt.Errorf("no state of artefact %s found at or before %s", artefactID, targetTimestamp)
+	}
+
+	// Get that state
+	stateJSON, err := b.GetStateVersion(agentID, artefactID, stateTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the updates that have been posted after the state, and at or before the target timestamp
+	updateTimestamps, err := b.ModellingBusConnector.getPostingTimestamps(agentID, b.jsonArtefactsUpdateTopicPath(artefactID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the latest of these updates that chains to the state, working backwards
+	for i := len(updateTimestamps) - 1; i >= 0; i-- {
+		updateTimestamp := updateTimestamps[i]
+		if generics.CompareTimestamps(updateTimestamp, targetTimestamp) > 0 || generics.CompareTimestamps(updateTimestamp, stateTimestamp) < 0 {
+			continue
+		}
+
+		// Get the update delta
+		deltaJSON, err := b.ModellingBusConnector.getPostingContent(agentID, b.jsonArtefactsUpdateTopicPath(artefactID), updateTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		delta := TJSONDelta{}
+		if err := json.Unmarshal(deltaJSON, &delta); err != nil {
+			return nil, err
+		}
+
+		// Skip deltas that do not chain to the state
+		if delta.CurrentTimestamp != stateTimestamp {
+			b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "Skipping update %s, as it does not chain to state %s.", updateTimestamp, stateTimestamp)
+			continue
+		}
+
+		// Apply the delta to the state
+		return generics.JSONApplyPatch(stateJSON, delta.Operations)
+	}
+
+	// No updates, so the state itself is the content at the target timestamp
+	return stateJSON, nil
+}
+
+/*
+ * Listing artefacts
+ */
+
+// Listing the IDs of the JSON artefacts that have been posted by the given agent.
+// On the repository, JSON artefacts are stored as artefacts/json/<artefact id>/<json version>/state,
+// so only folders that have a state for at least one JSON version count as artefacts.
+func (b *TModellingBusConnector) ListArtefacts(agentID string) ([]string, error) {
+	// Get the candidate artefact folders
+	candidateIDs, err := b.modellingBusRepositoryConnector.listTopicPath(agentID, jsonArtefactsPathElement)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check each candidate for a posted state
+	artefactIDs := []string{}
+	for _, candidateID := range candidateIDs {
+		// Get the JSON versions of the candidate
+		candidatePath := jsonArtefactsPathElement + "/" + candidateID
+		jsonVersions, err := b.modellingBusRepositoryConnector.listTopicPath(agentID, candidatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check whether any of the JSON versions has a state
+		hasState := false
+		for _, jsonVersion := range jsonVersions {
+			postingKinds, err := b.modellingBusRepositoryConnector.listTopicPath(agentID, candidatePath+"/"+jsonVersion)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, postingKind := range postingKinds {
+				hasState = hasState || postingKind == artefactStatePathElement
+			}
+		}
+
+		// If so, it is an artefact, named by its original ID
+		if hasState {
+			artefactID, err := generics.UnsanitizeTopicElement(candidateID)
+			if err != nil {
+				artefactID = candidateID
+			}
+			artefactIDs = append(artefactIDs, artefactID)
+		}
+	}
+
+	// Return the found artefacts
+	return artefactIDs, nil
+}
+
 /*
  * Deleting artefacts
  */
@@ -360,17 +1432,123 @@ func (b *TModellingBusArtefactConnector) DeleteRawArtefact(artefactID string) {
 
 // Deleting JSON artefact
 func (b *TModellingBusArtefactConnector) DeleteJSONArtefact(artefactID string) {
+	// Once deleted, the state of our artefact needs to be posted again
+	if artefactID == b.ArtefactID {
+		b.updateMutex.Lock()
+		b.lastStateHash = ""
+		b.updateMutex.Unlock()
+	}
+
 	// Delete the JSON artefact
 	b.ModellingBusConnector.deletePosting(b.jsonArtefactsStateTopicPath(artefactID))
 	b.ModellingBusConnector.deletePosting(b.jsonArtefactsUpdateTopicPath(artefactID))
 	b.ModellingBusConnector.deletePosting(b.jsonArtefactsConsideringTopicPath(artefactID))
 }
 
+// Compacting the postings of our JSON artefact, by posting its updated content as a fresh state, and
+// deleting the superseded states, updates, and considerings. This resets the delta chain, and bounds the storage
+// needed for long-lived, heavily edited artefacts, while preserving the latest content.
+func (b *TModellingBusArtefactConnector) CompactArtefact(artefactID string) {
+	b.CompactArtefactE(artefactID)
+}
+
+// Compacting the postings of our JSON artefact, returning the error (if any) that made the compaction fail
+func (b *TModellingBusArtefactConnector) CompactArtefactE(artefactID string) error {
+	// Only our own artefact can be compacted, as we can only post our own artefact
+	if artefactID != b.ArtefactID {
+		err := fmt.Errorf("can only compact artefact %s, not %s", b.ArtefactID, artefactID)
+		b.ModellingBusConnector.Reporter.ReportError("Cannot compact artefact:", err)
+
+		return err
+	}
+
+	// Without a state, there is nothing to compact
+	if !b.isStateCommunicated() {
+		return nil
+	}
+
+	// Post the updated content, which includes any pending update, as the fresh state
+	_, updatedContent, consideredContent := b.GetContents()
+	stateTimestamp, err := b.postJSONArtefactState(updatedContent, "")
+	if err != nil {
+		return err
+	}
+
+	// The updates and considerings relate to superseded states, so they can go
+	b.ModellingBusConnector.deletePosting(b.jsonArtefactsUpdateTopicPath(artefactID))
+	b.ModellingBusConnector.deletePosting(b.jsonArtefactsConsideringTopicPath(artefactID))
+	if err := b.ModellingBusConnector.deletePostingsBefore(b.jsonArtefactsStateTopicPath(artefactID), stateTimestamp); err != nil {
+		b.ModellingBusConnector.Reporter.ReportError("Something went wrong deleting the superseded states:", err)
+
+		return err
+	}
+
+	// Keep what was being considered
+	if b.hasJSONChanges(updatedContent, consideredContent) {
+		return b.PostJSONArtefactConsideringE(consideredContent, true)
+	}
+
+	return nil
+}
+
+// Publishing a checkpoint of our JSON artefact, i.e. posting the given state as a fresh state, and clearing the updates and
+// considerings posted so far, both from the repository and from the modelling bus. Listeners thus start cleanly from the
+// checkpoint, rather than applying old updates to it.
+func (b *TModellingBusArtefactConnector) PublishCheckpoint(json []byte) {
+	b.PublishCheckpointE(json)
+}
+
+// Publishing a checkpoint of our JSON artefact, returning the error (if any) that made the posting of the state fail
+func (b *TModellingBusArtefactConnector) PublishCheckpointE(json []byte) error {
+	// Post the state, even when the very same state has been posted before, as it needs a fresh timestamp
+	if _, err := b.postJSONArtefactState(json, ""); err != nil {
+		return err
+	}
+
+	// The updates and considerings relate to superseded states, so they can go
+	b.ModellingBusConnector.deletePosting(b.jsonArtefactsUpdateTopicPath(b.ArtefactID))
+	b.ModellingBusConnector.deletePosting(b.jsonArtefactsConsideringTopicPath(b.ArtefactID))
+
+	return nil
+}
+
+// Deleting an artefact entirely, i.e. its raw postings as well as its JSON postings across all JSON versions
+func (b *TModellingBusArtefactConnector) DeleteArtefact(artefactID string) {
+	// Without an artefact ID, the trees of all artefacts would be deleted
+	if artefactID == "" {
+		b.ModellingBusConnector.Reporter.Error("The artefact ID should not be empty.")
+		return
+	}
+
+	// A pending update of the artefact is no longer relevant
+	if artefactID == b.ArtefactID {
+		b.updateMutex.Lock()
+		b.stopPendingUpdate()
+		b.lastStateHash = ""
+		b.updateMutex.Unlock()
+	}
+
+	// Delete the raw and JSON artefact trees
+	b.ModellingBusConnector.deletePostingTree(b.rawArtefactsTopicPath(artefactID))
+	b.ModellingBusConnector.deletePostingTree(b.jsonArtefactsTreeTopicPath(artefactID))
+}
+
+/*
+ * Closing
+ */
+
+// Closing the artefact connector, which posts pending updates before closing the connection to the modelling bus
+func (b *TModellingBusArtefactConnector) Close() {
+	b.FlushPendingUpdates()
+	b.ModellingBusConnector.Close()
+}
+
 /*
  * Creating
  */
 
-// Creating a modelling bus artefact connector
+// Creating a modelling bus artefact connector.
+// As listeners and coalesced updates refer to the artefact connector, it should not be copied once it is in use.
 func CreateModellingBusArtefactConnector(ModellingBusConnector TModellingBusConnector, JSONVersion, ArtefactID string) TModellingBusArtefactConnector {
 	// Create the modelling bus artefact connector
 	ModellingBusArtefactConnector := TModellingBusArtefactConnector{}
@@ -382,6 +1560,10 @@ func CreateModellingBusArtefactConnector(ModellingBusConnector TModellingBusConn
 	ModellingBusArtefactConnector.ConsideredContent = []byte{}
 	ModellingBusArtefactConnector.CurrentTimestamp = generics.GetTimestamp()
 	ModellingBusArtefactConnector.stateCommunicated = false
+	ModellingBusArtefactConnector.updateMutex = &sync.Mutex{}
+	ModellingBusArtefactConnector.handlerPause = &tHandlerPause{deferredIDs: map[int]bool{}}
+	ModellingBusArtefactConnector.updateCoalescingWindow =
+		time.Duration(ModellingBusConnector.configData.GetValue("", "update_coalescing_window").IntWithDefault(0)) * time.Millisecond
 
 	// Return the created modelling bus artefact connector
 	return ModellingBusArtefactConnector
@@ -0,0 +1,491 @@
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestHasJSONChanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		ignoredPaths []string
+		oldState     string
+		newState     string
+		wantChanges  bool
+	}{
+		{"same state", nil, `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`, false},
+		{"different formatting", nil, `{"a":1,"b":[1,2]}`, "{\n  \"b\": [1, 2],\n  \"a\": 1\n}", false},
+		{"changed value", nil, `{"a":1,"b":[1,2]}`, `{"a":2,"b":[1,2]}`, true},
+		{"added element", nil, `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2,3]}`, true},
+		{"changed ignored path", []string{"/a"}, `{"a":1,"b":[1,2]}`, `{"a":2,"b":[1,2]}`, false},
+		{"changed underneath ignored path", []string{"/b"}, `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,3]}`, false},
+		{"changed next to ignored path", []string{"/a"}, `{"a":1,"b":[1,2]}`, `{"a":2,"b":[1,3]}`, true},
+		{"no old state", nil, ``, `{"a":1}`, true},
+		{"invalid new state", nil, `{"a":1}`, `{"a":`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := TModellingBusArtefactConnector{deltaIgnoredPaths: test.ignoredPaths}
+
+			if gotChanges := b.hasJSONChanges([]byte(test.oldState), []byte(test.newState)); gotChanges != test.wantChanges {
+				t.Errorf("hasJSONChanges(%s, %s) = %v, want %v", test.oldState, test.newState, gotChanges, test.wantChanges)
+			}
+		})
+	}
+}
+
+func TestGetJSONArtefactStateKeepsStateWithoutPosting(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string // The message on the state topic (empty when nothing has been posted)
+		wantState  string
+		wantErrors bool
+	}{
+		{"nothing posted", "", `{"kept":true}`, false},
+		{"posting cannot be retrieved", `{"server":"127.0.0.1","port":"1","file path":"some/path/payload.json","timestamp":"2026-10-15-13-04-05-00"}`, `{"kept":true}`, true},
+		{"posting made inline", `{"timestamp":"2026-10-15-13-04-05-00","payload":{"posted":true}}`, `{"posted":true}`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, reporter.TReporter), "", "model")
+			b.CurrentContent = json.RawMessage(`{"kept":true}`)
+			if test.message != "" {
+				storeTestMessage(b.ModellingBusConnector, "other", b.jsonArtefactsStateTopicPath("model"), test.message)
+			}
+
+			b.GetJSONArtefactState("other", "model")
+			if string(b.CurrentContent) != test.wantState {
+				t.Errorf("GetJSONArtefactState() gave state %s, want %s", b.CurrentContent, test.wantState)
+			}
+			if gotErrors := len(reporter.reportedErrors()) > 0; gotErrors != test.wantErrors {
+				t.Errorf("reported errors = %q, want errors: %v", reporter.reportedErrors(), test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestGetJSONE(t *testing.T) {
+	tests := []struct {
+		name          string
+		message       string
+		wantJSON      string
+		wantTimestamp string
+		wantErr       error
+	}{
+		{"nothing posted", "", "", "", ErrNoPosting},
+		{"posting cannot be retrieved", `{"server":"127.0.0.1","port":"1","file path":"some/path/payload.json","timestamp":"2026-10-15-13-04-05-00"}`, "", "", ErrRetrieve},
+		{"posting made inline", `{"timestamp":"2026-10-15-13-04-05-00","payload":{"posted":true}}`, `{"posted":true}`, "2026-10-15-13-04-05-00", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := createOfflineModellingBusConnector(t, createTestReporter().TReporter)
+			if test.message != "" {
+				storeTestMessage(b, "other", "topic", test.message)
+			}
+
+			gotJSON, gotTimestamp, err := b.getJSONE("other", "topic")
+			if string(gotJSON) != test.wantJSON || gotTimestamp != test.wantTimestamp || !errors.Is(err, test.wantErr) {
+				t.Errorf("getJSONE() = %s, %q, %v, want %s, %q, %v", gotJSON, gotTimestamp, err, test.wantJSON, test.wantTimestamp, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestListeningResumesAfterReconnect(t *testing.T) {
+	tests := []struct {
+		name              string
+		relistenOnConnect bool // Whether the listening is re-established after a reconnect
+		wantStates        []string
+	}{
+		{"without re-listening", false, []string{`{"posting":1}`}},
+		{"re-listening after a reconnect", true, []string{`{"posting":1}`, `{"posting":2}`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, createTestReporter().TReporter), "", "model")
+			client := connectToFakeMQTTBroker(b.ModellingBusConnector)
+			stateTopic := b.ModellingBusConnector.modellingBusEventsConnector.mqttAgentTopicPath("other", b.jsonArtefactsStateTopicPath("model"))
+			postState := func(posting int) {
+				client.Publish(stateTopic, 0, true, fmt.Sprintf(`{"timestamp":"2026-10-15-13-04-05-%02d","payload":{"posting":%d}}`, posting, posting))
+			}
+
+			// Listen for the states, as the CDM listener does
+			gotStates := []string{}
+			listen := func() {
+				b.ListenForJSONArtefactStatePostings("other", "model", func() {
+					gotStates = append(gotStates, string(b.CurrentContent))
+				})
+			}
+			listen()
+			if test.relistenOnConnect {
+				b.ModellingBusConnector.OnReconnect(listen)
+			}
+
+			// Post a state, lose the connection, reconnect, and post another state
+			postState(1)
+			client.loseConnection()
+			b.ModellingBusConnector.modellingBusEventsConnector.connectHandler(client)
+			postState(2)
+
+			if !slices.Equal(gotStates, test.wantStates) {
+				t.Errorf("received states %q, want %q", gotStates, test.wantStates)
+			}
+		})
+	}
+}
+
+func TestGetCurrentField(t *testing.T) {
+	b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, createTestReporter().TReporter), "", "model")
+	b.CurrentContent = json.RawMessage(`{"model name":"Births","types":["Person","Date"]}`)
+
+	tests := []struct {
+		pointer    string
+		wantValue  string
+		wantExists bool
+	}{
+		{"/model name", `"Births"`, true},
+		{"/types/1", `"Date"`, true},
+		{"/types/2", "", false},
+		{"/readings", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pointer, func(t *testing.T) {
+			gotValue, gotExists := b.GetCurrentField(test.pointer)
+			if string(gotValue) != test.wantValue || gotExists != test.wantExists {
+				t.Errorf("GetCurrentField(%q) = %s, %v, want %s, %v", test.pointer, gotValue, gotExists, test.wantValue, test.wantExists)
+			}
+		})
+	}
+}
+
+func TestPauseHandlers(t *testing.T) {
+	tests := []struct {
+		name            string
+		paused          bool
+		postings        int
+		wantWhilePaused int // The number of handler calls before resuming
+		wantAfterResume int // The number of handler calls after resuming
+	}{
+		{"not paused", false, 5, 5, 5},
+		{"paused burst", true, 5, 0, 1},
+		{"paused without postings", true, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, createTestReporter().TReporter), "", "model")
+			client := connectToFakeMQTTBroker(b.ModellingBusConnector)
+			stateTopic := b.ModellingBusConnector.modellingBusEventsConnector.mqttAgentTopicPath("other", b.jsonArtefactsStateTopicPath("model"))
+
+			handlerCalls := 0
+			handlerStates := []string{}
+			b.ListenForJSONArtefactStatePostings("other", "model", func() {
+				handlerCalls++
+				handlerStates = append(handlerStates, string(b.CurrentContent))
+			})
+
+			if test.paused {
+				b.PauseHandlers()
+			}
+			for posting := 1; posting <= test.postings; posting++ {
+				client.Publish(stateTopic, 0, true, fmt.Sprintf(`{"timestamp":"2026-10-15-13-04-05-%02d","payload":{"posting":%d}}`, posting, posting))
+			}
+
+			// While paused, the postings are still applied
+			if test.postings > 0 {
+				if wantState := fmt.Sprintf(`{"posting":%d}`, test.postings); string(b.CurrentContent) != wantState {
+					t.Errorf("state while paused = %s, want %s", b.CurrentContent, wantState)
+				}
+			}
+			if handlerCalls != test.wantWhilePaused {
+				t.Errorf("handler called %d time(s) before resuming, want %d", handlerCalls, test.wantWhilePaused)
+			}
+
+			// After resuming, the handler sees the latest state
+			b.ResumeHandlers()
+			if handlerCalls != test.wantAfterResume {
+				t.Errorf("handler called %d time(s) after resuming, want %d", handlerCalls, test.wantAfterResume)
+			}
+			if test.postings > 0 {
+				if wantState := fmt.Sprintf(`{"posting":%d}`, test.postings); handlerStates[len(handlerStates)-1] != wantState {
+					t.Errorf("handler last saw state %s, want %s", handlerStates[len(handlerStates)-1], wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestPausableHandlersAreDeferredInOrder(t *testing.T) {
+	b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, createTestReporter().TReporter), "", "model")
+
+	calls := []string{}
+	first := b.pausable(func() { calls = append(calls, "first") })
+	second := b.pausable(func() { calls = append(calls, "second") })
+
+	// Each deferred handler is called once, in the order of their first deferral
+	b.PauseHandlers()
+	second()
+	first()
+	second()
+	b.ResumeHandlers()
+
+	// Once resumed, handlers are called right away again
+	first()
+
+	if wantCalls := []string{"second", "first", "first"}; !slices.Equal(calls, wantCalls) {
+		t.Errorf("handler calls = %q, want %q", calls, wantCalls)
+	}
+}
+
+func TestArtefactTopicPathsOfUnsafeIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		artefactID  string
+		wantElement string
+		wantErrors  bool
+	}{
+		{"safe ID", "model", "model", false},
+		{"traversal", "../x", "..%2Fx", false},
+		{"parent folder", "..", "%2E%2E", false},
+		{"slash", "a/b", "a%2Fb", false},
+		{"wildcards", "+#", "%2B%23", false},
+		{"spaces", "my model", "my%20model", false},
+		{"empty ID", "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, reporter.TReporter), "", "model")
+
+			topicPathFunctions := []func(string) string{
+				b.rawArtefactsTopicPath,
+				b.jsonArtefactsTreeTopicPath,
+				b.jsonArtefactsStateTopicPath,
+			}
+			for _, topicPathFor := range topicPathFunctions {
+				// The artefact ID is exactly one element of the topic path, in the place of a safe ID
+				topicPath := topicPathFor(test.artefactID)
+				if wantTopicPath := strings.Replace(topicPathFor("model"), "/model", "/"+test.wantElement, 1); topicPath != wantTopicPath {
+					t.Errorf("topic path = %q, want %q", topicPath, wantTopicPath)
+				}
+
+				// It also stays within the folder of our agent on the FTP server
+				ftpTopicPath := b.ModellingBusConnector.modellingBusRepositoryConnector.ftpTopicPath(topicPath)
+				agentRoot := path.Clean(b.ModellingBusConnector.modellingBusRepositoryConnector.ftpAgentTopicPath("agent", ""))
+				if !strings.HasPrefix(path.Clean(ftpTopicPath), agentRoot+"/") {
+					t.Errorf("FTP path %q escapes %q", ftpTopicPath, agentRoot)
+				}
+			}
+
+			if gotErrors := len(reporter.reportedErrors()) > 0; gotErrors != test.wantErrors {
+				t.Errorf("reported errors = %q, want errors: %v", reporter.reportedErrors(), test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestDeleteArtefact(t *testing.T) {
+	tests := []struct {
+		name        string
+		artefactID  string
+		wantDeleted []string // The artefact IDs whose topics should be deleted
+	}{
+		{"traversal", "../x", []string{"../x"}},
+		{"sibling of a traversal", "x", []string{"x"}},
+		{"empty ID", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, reporter.TReporter), "", "model")
+			e := b.ModellingBusConnector.modellingBusEventsConnector
+			client := connectToFakeMQTTBroker(b.ModellingBusConnector)
+
+			// The states of artefacts of our own agent, known on the bus
+			stateTopics := map[string]string{}
+			for _, artefactID := range []string{"../x", "x", "x/.."} {
+				stateTopics[artefactID] = e.mqttAgentTopicPath("agent", b.jsonArtefactsStateTopicPath(artefactID))
+				e.storeMessage(stateTopics[artefactID], []byte(`{"timestamp":"2026-10-15-13-04-05-00","payload":{}}`))
+			}
+			publishedBefore := len(client.published())
+
+			b.DeleteArtefact(test.artefactID)
+
+			// Only the topics of the artefact itself are deleted
+			deleted := client.published()[publishedBefore:]
+			for artefactID, stateTopic := range stateTopics {
+				if gotDeleted, wantDeleted := slices.Contains(deleted, stateTopic), slices.Contains(test.wantDeleted, artefactID); gotDeleted != wantDeleted {
+					t.Errorf("DeleteArtefact(%q) deleted the state of %q: %v, want %v", test.artefactID, artefactID, gotDeleted, wantDeleted)
+				}
+			}
+			if test.artefactID == "" {
+				if len(deleted) > 0 {
+					t.Errorf("DeleteArtefact(\"\") deleted %q, want nothing deleted", deleted)
+				}
+				if !slices.Contains(reporter.reportedErrors(), "The artefact ID should not be empty.") {
+					t.Errorf("DeleteArtefact(\"\") reported %q, want the empty ID reported", reporter.reportedErrors())
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteWhileReceivingConcurrently(t *testing.T) {
+	b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, createTestReporter().TReporter), "", "model")
+	client := connectToFakeMQTTBroker(b.ModellingBusConnector)
+	updateTopic := b.ModellingBusConnector.modellingBusEventsConnector.mqttAgentTopicPath("other", b.jsonArtefactsUpdateTopicPath("model"))
+
+	b.updateMutex.Lock()
+	b.CurrentTimestamp = "2026-10-15-13-04-05-00"
+	b.CurrentContent = json.RawMessage(`{}`)
+	b.updateMutex.Unlock()
+	b.ListenForJSONArtefactUpdatePostings("other", "model", func() {
+		b.LastUpdateOperations()
+	})
+
+	// Receive updates, while deleting the artefact and reading the last operations, as agents may do
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+
+		for posting := range 20 {
+			client.Publish(updateTopic, 0, true, fmt.Sprintf(`{"timestamp":"2026-10-15-13-04-06-%02d","payload":`+
+				`{"operations":[{"op":"add","path":"/posting","value":%d}],"timestamp":"2026-10-15-13-04-06-%02d","current timestamp":"2026-10-15-13-04-05-00"}}`,
+				posting, posting, posting))
+		}
+	}()
+	for range 2 {
+		go func() {
+			defer wg.Done()
+
+			for range 20 {
+				b.DeleteJSONArtefact("model")
+				b.LastUpdateOperations()
+				b.LastConsideringOperations()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if operations := b.LastUpdateOperations(); !strings.Contains(string(operations), `"/posting"`) {
+		t.Errorf("LastUpdateOperations() = %s, want the operations of the last update", operations)
+	}
+}
+
+func TestUpdateAgainstPreviousStateIsMerged(t *testing.T) {
+	previousState := `{"a":1,"b":2,"c":["x","y"]}`
+	currentState := `{"a":3,"b":2,"c":["x","y","z"]}`
+
+	tests := []struct {
+		name             string
+		currentTimestamp string // The current timestamp at the sender side
+		updatedState     string // The updated state at the sender side
+		wantOK           bool
+		wantUpdated      string
+		wantConflicts    int
+	}{
+		{"against the current state", "T2", `{"a":3,"b":4,"c":["x","y","z"]}`, true, `{"a":3,"b":4,"c":["x","y","z"]}`, 0},
+		{"against the previous state", "T1", `{"a":1,"b":4,"c":["x","y"]}`, true, `{"a":3,"b":4,"c":["x","y","z"]}`, 0},
+		{"conflicting with the current state", "T1", `{"a":5,"b":4,"c":["x","y"]}`, true, `{"a":3,"b":4,"c":["x","y","z"]}`, 1},
+		{"conflicting array", "T1", `{"a":1,"b":2,"c":["w","x","y"]}`, true, `{"a":3,"b":2,"c":["x","y","z"]}`, 1},
+		{"against an older state", "T0", `{"a":1,"b":4,"c":["x","y"]}`, false, currentState, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := createTestReporter()
+			b := CreateModellingBusArtefactConnector(createOfflineModellingBusConnector(t, reporter.TReporter), "", "model")
+			b.updateCurrentJSONArtefact([]byte(previousState), "T1")
+			b.updateCurrentJSONArtefact([]byte(currentState), "T2")
+
+			// Create the update as the sender would
+			senderState := currentState
+			if test.currentTimestamp != "T2" {
+				senderState = previousState
+			}
+			operations, err := generics.JSONDiff([]byte(senderState), []byte(test.updatedState))
+			if err != nil {
+				t.Fatalf("JSONDiff() returned error: %v", err)
+			}
+			delta, _ := json.Marshal(TJSONDelta{Operations: operations, Timestamp: "T3", CurrentTimestamp: test.currentTimestamp})
+
+			if gotOK := b.updateUpdatedJSONArtefact(delta); gotOK != test.wantOK {
+				t.Errorf("updateUpdatedJSONArtefact() = %v, want %v", gotOK, test.wantOK)
+			}
+			gotUpdated, _ := generics.CanonicalizeJSON(b.UpdatedContent)
+			wantUpdated, _ := generics.CanonicalizeJSON([]byte(test.wantUpdated))
+			if string(gotUpdated) != string(wantUpdated) {
+				t.Errorf("UpdatedContent = %s, want %s", b.UpdatedContent, test.wantUpdated)
+			}
+			if gotConflicts := len(b.LastMergeConflicts()); gotConflicts != test.wantConflicts {
+				t.Errorf("LastMergeConflicts() has %d conflicts, want %d", gotConflicts, test.wantConflicts)
+			}
+			if gotErrors := len(reporter.reportedErrors()); gotErrors != test.wantConflicts {
+				t.Errorf("reported %d errors, want %d", gotErrors, test.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestArtefactsInOtherEnvironments(t *testing.T) {
+	tests := []struct {
+		name          string
+		environmentID string
+	}{
+		{"own environment", "environment"},
+		{"other environment", "other"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := createOfflineModellingBusConnector(t, createTestReporter().TReporter, "max_fallback_bytes = 1000")
+			client := connectToFakeMQTTBroker(b)
+			e := b.modellingBusEventsConnector
+
+			// Post in the environment, which falls back to posting inline as the FTP server is unreachable
+			poster := CreateModellingBusArtefactConnector(b.PostingInEnvironment(test.environmentID), "1.0", "model")
+			if err := poster.PostJSONArtefactStateE([]byte(`{"a":1}`), true); err != nil {
+				t.Fatalf("PostJSONArtefactStateE() returned error: %v", err)
+			}
+			stateTopic := e.mqttAgentTopicPathIn(test.environmentID, "agent", poster.jsonArtefactsStateTopicPath("model"))
+			if !slices.Contains(client.published(), stateTopic) {
+				t.Errorf("PostJSONArtefactStateE() did not post on %s, published: %v", stateTopic, client.published())
+			}
+
+			// Listen in the environment, and stop listening again
+			listener := CreateModellingBusArtefactConnector(b, "1.0", "model")
+			listener.ListenForJSONArtefactStatePostingsInEnv(test.environmentID, "agent", "model", func() {})
+			client.mutex.Lock()
+			_, subscribed := client.subscriptions[stateTopic]
+			client.mutex.Unlock()
+			if !subscribed {
+				t.Errorf("ListenForJSONArtefactStatePostingsInEnv() did not subscribe to %s", stateTopic)
+			}
+
+			listener.StopListeningForJSONArtefactPostingsInEnv(test.environmentID, "agent", "model")
+			client.mutex.Lock()
+			_, subscribed = client.subscriptions[stateTopic]
+			client.mutex.Unlock()
+			if subscribed {
+				t.Errorf("StopListeningForJSONArtefactPostingsInEnv() did not unsubscribe from %s", stateTopic)
+			}
+		})
+	}
+}
@@ -8,7 +8,7 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 15.10.2026
  *
  */
 
@@ -45,7 +45,12 @@ func (b *TModellingBusConnector) coordinationTopicPath(coordinationID string) st
 
 // Post a coordination message to the modelling bus
 func (b *TModellingBusConnector) PostCoordination(coordinationID string, json []byte) {
-	b.postJSONAsStreamed(b.coordinationTopicPath(coordinationID), json, generics.GetTimestamp())
+	b.PostCoordinationE(coordinationID, json)
+}
+
+// Post a coordination message to the modelling bus, returning the error (if any) that made the posting fail
+func (b *TModellingBusConnector) PostCoordinationE(coordinationID string, json []byte) error {
+	return b.postJSONAsStreamed(b.coordinationTopicPath(coordinationID), json, generics.GetTimestamp())
 }
 
 /*
@@ -0,0 +1,120 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 3 - Custom
+ *
+ * This module implements the posting of, and listening to, JSON messages on custom topics. This allows for
+ * message classes that do not fit artefacts, observations, or coordination, without forking the package.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	customPathElement = "custom"
+)
+
+/*
+ * Defining errors
+ */
+
+// The error when a custom topic path is not a proper relative topic path
+var ErrInvalidTopicPath = errors.New("invalid custom topic path")
+
+/*
+ * Defining topic paths
+ */
+
+// Checking that a custom topic path is a proper relative topic path
+func (b *TModellingBusConnector) checkCustomTopicPath(topicPath string) error {
+	switch {
+	case topicPath == "":
+		return fmt.Errorf("%w: the topic path is empty", ErrInvalidTopicPath)
+
+	case strings.HasPrefix(topicPath, "/"):
+		return fmt.Errorf("%w: %s has a leading slash", ErrInvalidTopicPath, topicPath)
+
+	case slices.Contains(strings.Split(topicPath, "/"), ".."):
+		return fmt.Errorf("%w: %s contains ..", ErrInvalidTopicPath, topicPath)
+
+	case strings.ContainsAny(topicPath, "+#"):
+		return fmt.Errorf("%w: %s contains MQTT wildcards", ErrInvalidTopicPath, topicPath)
+	}
+
+	return nil
+}
+
+// Defining the topic path for custom postings, which are kept apart from the other postings
+func (b *TModellingBusConnector) customTopicPath(topicPath string) string {
+	return customPathElement + "/" + topicPath
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+/*
+ * Posting custom JSON messages
+ */
+
+// Posting a JSON message on a custom topic path (relative to the agent's custom topics) to the modelling bus
+func (b *TModellingBusConnector) PostCustomJSON(topicPath string, json []byte) {
+	b.PostCustomJSONE(topicPath, json)
+}
+
+// Posting a JSON message on a custom topic path to the modelling bus, returning the error (if any) that made the posting fail
+func (b *TModellingBusConnector) PostCustomJSONE(topicPath string, json []byte) error {
+	// Only post on proper topic paths
+	if err := b.checkCustomTopicPath(topicPath); err != nil {
+		b.Reporter.ReportError("Cannot post custom JSON:", err)
+		return err
+	}
+
+	return b.postJSONAsFile(b.customTopicPath(topicPath), json, generics.GetTimestamp())
+}
+
+/*
+ * Listening to custom postings
+ */
+
+// Listen for JSON postings on a custom topic path on the modelling bus
+func (b *TModellingBusConnector) ListenForCustomJSONPostings(agentID, topicPath string, postingHandler func([]byte, string)) {
+	// Only listen on proper topic paths
+	if err := b.checkCustomTopicPath(topicPath); err != nil {
+		b.Reporter.ReportError("Cannot listen for custom JSON:", err)
+		return
+	}
+
+	b.listenForJSONFilePostings(agentID, b.customTopicPath(topicPath), postingHandler)
+}
+
+// Listen for JSON postings on a custom topic path on the modelling bus, also passing on where the posting came from
+func (b *TModellingBusConnector) ListenForCustomJSONPostingsWithInfo(agentID, topicPath string, postingHandler func([]byte, TPostingInfo)) {
+	// Only listen on proper topic paths
+	if err := b.checkCustomTopicPath(topicPath); err != nil {
+		b.Reporter.ReportError("Cannot listen for custom JSON:", err)
+		return
+	}
+
+	b.listenForJSONFilePostingsWithInfo(agentID, b.customTopicPath(topicPath), postingHandler)
+}
@@ -0,0 +1,188 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 3 - Mirror
+ *
+ * This component provides the mirroring of the postings of the agents on one modelling bus to a second
+ * modelling bus (e.g. using a different FTP server and MQTT broker), for backup and federation purposes.
+ * The postings are re-posted on the second bus on behalf of the agents that made them, using the same
+ * topic paths and timestamps, so that deltas still chain on the second bus.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining the mirrorable topic classes
+ */
+
+const (
+	MirrorJSONArtefactStates       = jsonArtefactsPathElement + "/" + artefactStatePathElement       // Mirror JSON artefact states
+	MirrorJSONArtefactUpdates      = jsonArtefactsPathElement + "/" + artefactUpdatePathElement      // Mirror JSON artefact updates
+	MirrorJSONArtefactConsiderings = jsonArtefactsPathElement + "/" + artefactConsideringPathElement // Mirror JSON artefact considerings
+	MirrorJSONObservations         = jsonObservationsPathElement                                     // Mirror JSON observations
+	MirrorStreamedObservations     = streamedObservationsPathElement                                 // Mirror streamed observations
+)
+
+/*
+ * Defining the mirror
+ */
+
+type (
+	TBusMirror struct {
+		source TModellingBusConnector // The connector listening on the modelling bus to be mirrored
+		target TModellingBusConnector // The connector posting on the modelling bus mirrored to
+
+		mirroredTopicClasses map[string]bool // The topic classes to be mirrored
+		mirrorMutex          *sync.Mutex     // Guards the mirrored topic classes, and keeps the re-postings in order
+	}
+)
+
+/*
+ * Mirroring postings
+ */
+
+// Get the topic class of a topic path, i.e. the topic path stripped from the IDs and JSON versions
+func mirrorTopicClass(topicPath string) string {
+	switch {
+	case strings.HasPrefix(topicPath, jsonArtefactsPathElement+"/"):
+		// JSON artefacts are posted as artefacts/json/<artefact id>/<json version>/<kind>
+		return jsonArtefactsPathElement + "/" + topicPath[strings.LastIndex(topicPath, "/")+1:]
+
+	case strings.HasPrefix(topicPath, jsonObservationsPathElement+"/"):
+		return jsonObservationsPathElement
+
+	case strings.HasPrefix(topicPath, streamedObservationsPathElement+"/"):
+		return streamedObservationsPathElement
+
+	default:
+		return ""
+	}
+}
+
+// Re-post a message, as received on the source bus, on the target bus
+func (m *TBusMirror) mirrorMessage(topic string, message []byte) {
+	agentID, topicPath, ok := m.source.modellingBusEventsConnector.agentTopicPathOf(topic)
+	if !ok || len(message) == 0 {
+		return
+	}
+
+	m.mirrorMutex.Lock()
+	defer m.mirrorMutex.Unlock()
+
+	// Only mirror the selected topic classes
+	topicClass := mirrorTopicClass(topicPath)
+	if !m.mirroredTopicClasses[topicClass] {
+		return
+	}
+
+	m.source.Reporter.Progress(generics.ProgressLevelDetailed, "Mirroring posting of agent %s on %s.", agentID, topicPath)
+
+	// Re-post on behalf of the agent that made the posting
+	target := m.target.postingAs(agentID)
+
+	// Streamed postings are self contained
+	if topicClass == streamedObservationsPathElement {
+		payload, timestamp := m.source.splitStreamedEventFromMessage(message)
+		target.postJSONAsStreamed(topicPath, payload, timestamp)
+
+		return
+	}
+
+	// Other postings are inline, or refer to a file in the source repository that needs to be copied to the target repository
+	jsonPayload, timestamp, jsonVersion := m.source.getJSONFromMessage(message)
+	if len(jsonPayload) == 0 {
+		return
+	}
+
+	// Keep the timestamp and JSON version, so deltas still chain on the target bus
+	if topicClass == MirrorJSONArtefactStates {
+		target.postVersionedJSONAsFile(topicPath, jsonVersion, jsonPayload, timestamp)
+	} else {
+		target.postVersionedJSON(topicPath, jsonVersion, jsonPayload, timestamp)
+	}
+}
+
+// Get the order in which to mirror the postings already on the source bus: states before updates before considerings
+func (m *TBusMirror) mirrorOrder(topic string) int {
+	_, topicPath, _ := m.source.modellingBusEventsConnector.agentTopicPathOf(topic)
+
+	switch mirrorTopicClass(topicPath) {
+	case MirrorJSONArtefactStates:
+		return 0
+	case MirrorJSONArtefactUpdates:
+		return 1
+	case MirrorJSONArtefactConsiderings:
+		return 2
+	default:
+		return 3
+	}
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Setting the topic classes to be mirrored (using the Mirror... constants); by default all of them are mirrored
+func (m *TBusMirror) SetMirroredTopicClasses(topicClasses ...string) {
+	m.mirrorMutex.Lock()
+	defer m.mirrorMutex.Unlock()
+
+	m.mirroredTopicClasses = map[string]bool{}
+	for _, topicClass := range topicClasses {
+		m.mirroredTopicClasses[topicClass] = true
+	}
+}
+
+/*
+ * Creating mirrors
+ */
+
+// Creating a mirror of the postings on the source bus to the target bus.
+// The source connector must be listening (i.e. not posting only), while the target connector may be posting only.
+// The postings of all agents are mirrored, each on behalf of the agent that made it, so the topic structure remains the same.
+func CreateBusMirror(source, target TModellingBusConnector) *TBusMirror {
+	// Setting up the mirror
+	m := TBusMirror{}
+	m.source = source
+	m.target = target
+	m.mirrorMutex = &sync.Mutex{}
+	m.SetMirroredTopicClasses(
+		MirrorJSONArtefactStates,
+		MirrorJSONArtefactUpdates,
+		MirrorJSONArtefactConsiderings,
+		MirrorJSONObservations,
+		MirrorStreamedObservations)
+
+	// Start observing the source bus
+	knownMessages := source.modellingBusEventsConnector.observeMessages(m.mirrorMessage)
+
+	// Mirror what is already on the source bus, in an order that lets deltas chain
+	topics := slices.SortedFunc(maps.Keys(knownMessages), func(topic1, topic2 string) int {
+		return cmp.Or(
+			cmp.Compare(m.mirrorOrder(topic1), m.mirrorOrder(topic2)),
+			cmp.Compare(topic1, topic2))
+	})
+	for _, topic := range topics {
+		m.mirrorMessage(topic, knownMessages[topic])
+	}
+
+	return &m
+}
@@ -0,0 +1,35 @@
+package connect
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMirrorPostingsOfAllAgents(t *testing.T) {
+	tests := []struct {
+		name    string
+		agentID string
+	}{
+		{"own agent", "agent"},
+		{"other agent", "other"},
+	}
+
+	source := createOfflineModellingBusConnector(t, createTestReporter().TReporter)
+	for _, test := range tests {
+		storeTestMessage(source, test.agentID, streamedObservationsPathElement+"/sensor", `{"timestamp":"2026-10-15-13-04-05-00","payload":{"a":1}}`)
+	}
+
+	target := createOfflineModellingBusConnector(t, createTestReporter().TReporter)
+	client := connectToFakeMQTTBroker(target)
+	CreateBusMirror(source, target)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The posting should be mirrored on behalf of the agent that made it
+			wantTopic := target.modellingBusEventsConnector.mqttAgentTopicPath(test.agentID, streamedObservationsPathElement+"/sensor")
+			if !slices.Contains(client.published(), wantTopic) {
+				t.Errorf("CreateBusMirror() did not mirror on %s, published: %v", wantTopic, client.published())
+			}
+		})
+	}
+}
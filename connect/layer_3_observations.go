@@ -8,13 +8,18 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 15.10.2026
  *
  */
 
 package connect
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
 
@@ -28,6 +33,44 @@ const (
 	streamedObservationsPathElement = "observations/streamed"
 )
 
+/*
+ * Defining observation records
+ */
+
+type (
+	// A JSON observation, as posted at a given time
+	TObservationRecord struct {
+		Timestamp string          `json:"timestamp"` // The timestamp of the posting of the observation
+		JSON      json.RawMessage `json:"json"`      // The observation
+	}
+)
+
+/*
+ * Exporting observations
+ */
+
+// Get the CSV cell for a value in a JSON observation, where missing values and null give empty cells,
+// and non-scalar values are given as JSON
+func csvCellOf(value json.RawMessage, found bool) string {
+	if !found {
+		return ""
+	}
+
+	// Strings are given without their quotes
+	text := ""
+	if json.Unmarshal(value, &text) == nil {
+		return text
+	}
+
+	// Other values are given as (compact) JSON
+	compactValue := bytes.Buffer{}
+	if json.Compact(&compactValue, value) != nil || compactValue.String() == "null" {
+		return ""
+	}
+
+	return compactValue.String()
+}
+
 /*
  * Defining topic paths
  */
@@ -35,19 +78,19 @@ const (
 // Defining the topic path for raw oservations
 func (b *TModellingBusConnector) rawObservationsTopicPath(observationID string) string {
 	return rawObservationsPathElement +
-		"/" + observationID
+		"/" + b.topicElement("observation", observationID)
 }
 
 // Defining the topic path for JSON oservations
 func (b *TModellingBusConnector) jsonObservationsTopicPath(observationID string) string {
 	return jsonObservationsPathElement +
-		"/" + observationID
+		"/" + b.topicElement("observation", observationID)
 }
 
 // Defining the topic path for streamed oservations
 func (b *TModellingBusConnector) streamedObservationsTopicPath(observationID string) string {
 	return streamedObservationsPathElement +
-		"/" + observationID
+		"/" + b.topicElement("observation", observationID)
 }
 
 /*
@@ -62,35 +105,59 @@ func (b *TModellingBusConnector) streamedObservationsTopicPath(observationID str
 
 // Posting a raw observation to the modelling bus
 func (b *TModellingBusConnector) PostRawObservation(observationID, localFilePath string) {
-	b.postFile(b.rawObservationsTopicPath(observationID), localFilePath, generics.GetTimestamp())
+	b.PostRawObservationE(observationID, localFilePath)
+}
+
+// Posting a raw observation to the modelling bus, returning the error (if any) that made the posting fail
+func (b *TModellingBusConnector) PostRawObservationE(observationID, localFilePath string) error {
+	return b.postFile(b.rawObservationsTopicPath(observationID), localFilePath, generics.GetTimestamp())
 }
 
 // Posting a JSON observation to the modelling bus
 func (b *TModellingBusConnector) PostJSONObservation(observationID string, json []byte) {
-	b.postJSONAsFile(b.jsonObservationsTopicPath(observationID), json, generics.GetTimestamp())
+	b.PostJSONObservationE(observationID, json)
+}
+
+// Posting a JSON observation to the modelling bus, returning the error (if any) that made the posting fail
+func (b *TModellingBusConnector) PostJSONObservationE(observationID string, json []byte) error {
+	return b.postJSONAsFile(b.jsonObservationsTopicPath(observationID), json, generics.GetTimestamp())
 }
 
 // Posting a streamed observation to the modelling bus
 func (b *TModellingBusConnector) PostStreamedObservation(observationID string, json []byte) {
-	b.postJSONAsStreamed(b.streamedObservationsTopicPath(observationID), json, generics.GetTimestamp())
+	b.PostStreamedObservationE(observationID, json)
+}
+
+// Posting a streamed observation to the modelling bus, returning the error (if any) that made the posting fail
+func (b *TModellingBusConnector) PostStreamedObservationE(observationID string, json []byte) error {
+	return b.postJSONAsStreamed(b.streamedObservationsTopicPath(observationID), json, generics.GetTimestamp())
 }
 
 /*
  * Listening to observations related postings
  */
 
-// Listen for raw observation postings on the modelling bus
+// Listen for raw observation postings on the modelling bus.
+// The posting handler gets the path of a local copy of the posted file, which is removed once the handler returns.
 func (b *TModellingBusConnector) ListenForRawObservationPostings(agentID, observationID string, postingHandler func(string)) {
-	b.listenForFilePostings(agentID, b.rawObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, _ string) {
+	b.listenForFilePostings(agentID, b.rawObservationsTopicPath(observationID), func(localFilePath, _ string) {
 		postingHandler(localFilePath)
 	})
 }
 
+// Listen for raw observation postings on the modelling bus, also passing on where the posting came from
+func (b *TModellingBusConnector) ListenForRawObservationPostingsWithInfo(agentID, observationID string, postingHandler func(string, TPostingInfo)) {
+	b.listenForFilePostingsWithInfo(agentID, b.rawObservationsTopicPath(observationID), postingHandler)
+}
+
 // Listen for JSON observation postings on the modelling bus
 func (b *TModellingBusConnector) ListenForJSONObservationPostings(agentID, observationID string, postingHandler func([]byte, string)) {
-	b.listenForFilePostings(agentID, b.jsonObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, timestamp string) {
-		postingHandler(b.getJSONFromTemporaryFile(localFilePath, timestamp))
-	})
+	b.listenForJSONFilePostings(agentID, b.jsonObservationsTopicPath(observationID), postingHandler)
+}
+
+// Listen for JSON observation postings on the modelling bus, also passing on where the posting came from
+func (b *TModellingBusConnector) ListenForJSONObservationPostingsWithInfo(agentID, observationID string, postingHandler func([]byte, TPostingInfo)) {
+	b.listenForJSONFilePostingsWithInfo(agentID, b.jsonObservationsTopicPath(observationID), postingHandler)
 }
 
 // Listen for streamed observation postings on the modelling bus
@@ -112,6 +179,70 @@ func (b *TModellingBusConnector) GetJSONObservation(agentID, observationID strin
 	return b.getJSON(agentID, b.jsonObservationsTopicPath(observationID))
 }
 
+// Retrieve the series of JSON observations posted after the given timestamp (use "" for all of them), from old to new.
+// Only the observations still kept in the repository are included. By default, all of them are kept, so the series
+// is only pruned when the posting agent configured a retention (see "retention" in the "ftp" section of the config file).
+func (b *TModellingBusConnector) GetJSONObservationSeries(agentID, observationID, since string) ([]TObservationRecord, error) {
+	// Get the timestamps of the observations kept in the repository
+	timestamps, err := b.getPostingTimestamps(agentID, b.jsonObservationsTopicPath(observationID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the observations posted after the given timestamp
+	observationRecords := []TObservationRecord{}
+	for _, timestamp := range timestamps {
+		if generics.CompareTimestamps(timestamp, since) <= 0 {
+			continue
+		}
+
+		observationJSON, err := b.getPostingContent(agentID, b.jsonObservationsTopicPath(observationID), timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		observationRecord := TObservationRecord{}
+		observationRecord.Timestamp = timestamp
+		observationRecord.JSON = observationJSON
+		observationRecords = append(observationRecords, observationRecord)
+	}
+
+	return observationRecords, nil
+}
+
+// Export the series of JSON observations as CSV, with a header row, a "timestamp" column, and a column for each
+// of the given JSON pointers (see RFC 6901).
+// Values missing from an observation give empty cells, while non-scalar values are given as JSON.
+func (b *TModellingBusConnector) ExportJSONObservationsCSV(agentID, observationID string, w io.Writer, columns []string) error {
+	// Get the series of observations
+	observationRecords, err := b.GetJSONObservationSeries(agentID, observationID, "")
+	if err != nil {
+		return err
+	}
+
+	// Write the header row
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(append([]string{"timestamp"}, columns...)); err != nil {
+		return err
+	}
+
+	// Write a row per observation
+	for _, observationRecord := range observationRecords {
+		row := []string{observationRecord.Timestamp}
+		for _, column := range columns {
+			row = append(row, csvCellOf(generics.JSONGet(observationRecord.JSON, column)))
+		}
+
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
 // Retrieve streamed observations from the modelling bus
 func (b *TModellingBusConnector) GetStreamedObservation(agentID, observationID string) ([]byte, string) {
 	return b.getStreamedEvent(agentID, b.streamedObservationsTopicPath(observationID))
@@ -135,3 +266,10 @@ func (b *TModellingBusConnector) DeleteJSONObservation(observationID string) {
 func (b *TModellingBusConnector) DeleteStreamedObservation(observationID string) {
 	b.deletePosting(b.streamedObservationsTopicPath(observationID))
 }
+
+// Delete all observations of this agent from the modelling bus, including those with forgotten observation IDs
+func (b *TModellingBusConnector) DeleteAllObservations() {
+	b.deletePostingTree(rawObservationsPathElement)
+	b.deletePostingTree(jsonObservationsPathElement)
+	b.deletePostingTree(streamedObservationsPathElement)
+}
@@ -0,0 +1,170 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Connect
+ * Component: Layer 3 - Requests
+ *
+ * This module implements simple request/response interactions on the modelling bus, turning it into an RPC substrate
+ * for coordination messages. A requester posts a request, which carries a correlation ID and the topic path on which
+ * it expects the response. A responder posts its response for the requester on that topic path, carrying the same
+ * correlation ID. Requests and responses are not retained, so they only reach those listening when they are posted.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	requestsPathElement  = "requests"  // Requests path element
+	responsesPathElement = "responses" // Responses path element
+)
+
+/*
+ * Defining requests and responses
+ */
+
+type (
+	tRequest struct {
+		CorrelationID string `json:"correlation id"` // The ID correlating the request with its response
+		ResponseTopic string `json:"response topic"` // The topic path on which the requester expects the response
+		Payload       []byte `json:"payload"`        // The actual payload of the request
+	}
+
+	tResponse struct {
+		CorrelationID string `json:"correlation id"` // The ID of the request this is a response to
+		Payload       []byte `json:"payload"`        // The actual payload of the response
+	}
+)
+
+/*
+ * Defining topic paths
+ */
+
+// Defining the topic path for requests
+func requestsTopicPath(requestTopic string) string {
+	return requestsPathElement +
+		"/" + requestTopic
+}
+
+// Defining the topic path for the response to a given request by a given agent.
+// As each request gets its own topic path, concurrent requests do not interfere with each other's subscriptions.
+func responsesTopicPath(requesterAgentID, responseTopic, correlationID string) string {
+	return responsesPathElement +
+		"/" + requesterAgentID +
+		"/" + responseTopic +
+		"/" + correlationID
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Post a request on the request topic path, and wait (up to the timeout) for the first response on the response topic path.
+// The response is matched to the request by a correlation ID, so concurrent requests do not get each other's responses.
+// Returns ErrTimeout when no response arrived within the timeout.
+func (b *TModellingBusConnector) RequestResponse(requestTopic string, payload []byte, responseTopic string, timeout time.Duration) ([]byte, error) {
+	// Only use proper topic paths
+	for _, topicPath := range []string{requestTopic, responseTopic} {
+		if err := b.checkCustomTopicPath(topicPath); err != nil {
+			b.Reporter.ReportError("Cannot make the request:", err)
+			return nil, err
+		}
+	}
+
+	// Create the request
+	request := tRequest{}
+	request.CorrelationID = b.GetNewID()
+	request.ResponseTopic = responseTopic
+	request.Payload = payload
+
+	// Convert the request to JSON
+	requestJSON, err := json.Marshal(request)
+	if b.Reporter.MaybeReportError("Something went wrong JSONing the request:", err) {
+		return nil, fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	// Listen for the response from any agent before posting the request, so it is not missed
+	responses := make(chan []byte, 1)
+	responseTopicPath := responsesTopicPath(b.agentID, responseTopic, request.CorrelationID)
+	b.modellingBusEventsConnector.listenForEvents("+", responseTopicPath, func(message []byte) {
+		response := tResponse{}
+		if json.Unmarshal(message, &response) == nil && response.CorrelationID == request.CorrelationID {
+			// Only the first response is needed
+			select {
+			case responses <- response.Payload:
+			default:
+			}
+		}
+	})
+	defer b.modellingBusEventsConnector.stopListeningForEvents("+", responseTopicPath)
+
+	// Post the request, without retaining it
+	if err := b.modellingBusEventsConnector.postTransientEventIn(b.environmentID, requestsTopicPath(requestTopic), requestJSON); err != nil {
+		return nil, err
+	}
+
+	// Wait for the response, or the timeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case responsePayload := <-responses:
+		return responsePayload, nil
+
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+}
+
+// Respond to the requests posted by a given agent (or "+" for any agent) on the request topic path.
+// The responder is called with the payload of each request, and the payload it returns is posted as the response.
+func (b *TModellingBusConnector) RespondToRequests(agentID, requestTopic string, responder func([]byte) []byte) {
+	// Only listen on proper topic paths
+	if err := b.checkCustomTopicPath(requestTopic); err != nil {
+		b.Reporter.ReportError("Cannot respond to requests:", err)
+		return
+	}
+
+	// Listen for the requests, and the agents posting them
+	b.modellingBusEventsConnector.listenForEventsWithAgent(agentID, requestsTopicPath(requestTopic), func(requesterAgentID string, message []byte) {
+		request := tRequest{}
+		if b.Reporter.MaybeReportError("Something went wrong unJSONing the received request:", json.Unmarshal(message, &request)) {
+			return
+		}
+
+		// Only respond on proper topic paths
+		if err := b.checkCustomTopicPath(request.ResponseTopic); err != nil {
+			b.Reporter.ReportError("Cannot respond to the request:", err)
+			return
+		}
+
+		// Create the response
+		response := tResponse{}
+		response.CorrelationID = request.CorrelationID
+		response.Payload = responder(request.Payload)
+
+		// Convert the response to JSON
+		responseJSON, err := json.Marshal(response)
+		if b.Reporter.MaybeReportError("Something went wrong JSONing the response:", err) {
+			return
+		}
+
+		// Post the response for the requester, without retaining it
+		b.modellingBusEventsConnector.postTransientEventIn(b.environmentID, responsesTopicPath(requesterAgentID, request.ResponseTopic, request.CorrelationID), responseJSON)
+	})
+}
@@ -0,0 +1,79 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Generic
+ * Component: Backoff
+ *
+ * This component provides capped exponential backoff, with jitter, for retrying connections.
+ * The jitter avoids that agents that lost their connection at the same time all retry at the same time.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package generics
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	MinimumBackoffDelay = 100 * time.Millisecond // The minimum delay between retries, so a zero (or negative) delay does not make retries loop
+)
+
+/*
+ * Defining backoff
+ */
+
+type (
+	TBackoff struct {
+		initialDelay time.Duration // The delay before the first retry
+		maximumDelay time.Duration // The cap on the delay between retries
+		currentDelay time.Duration // The delay (before jitter) of the next retry
+	}
+)
+
+/*
+ * Defining backoff functionality
+ */
+
+// Get the delay to wait before the next retry.
+// The delays double with each retry, until they reach the maximum delay. Each delay is jittered
+// to lie between half of, and the full, delay.
+func (b *TBackoff) Next() time.Duration {
+	delay := b.currentDelay
+
+	// Double the delay for the next retry, without exceeding the maximum delay
+	b.currentDelay = min(2*b.currentDelay, b.maximumDelay)
+
+	// Add the jitter
+	halfDelay := delay / 2
+	if halfDelay <= 0 {
+		return delay
+	}
+
+	return halfDelay + rand.N(delay-halfDelay+1)
+}
+
+// Reset the backoff, e.g. after a successful retry
+func (b *TBackoff) Reset() {
+	b.currentDelay = b.initialDelay
+}
+
+// Create a backoff, starting at the initial delay, and capped at the maximum delay.
+// Both delays are at least the minimum backoff delay.
+func CreateBackoff(initialDelay, maximumDelay time.Duration) TBackoff {
+	b := TBackoff{}
+	b.maximumDelay = max(maximumDelay, MinimumBackoffDelay)
+	b.initialDelay = min(max(initialDelay, MinimumBackoffDelay), b.maximumDelay)
+	b.Reset()
+
+	return b
+}
@@ -0,0 +1,47 @@
+package generics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name         string
+		initialDelay time.Duration
+		maximumDelay time.Duration
+		wantDelays   []time.Duration // The delays before jitter
+	}{
+		{"doubling up to the maximum", time.Second, 5 * time.Second,
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"initial delay beyond the maximum", 10 * time.Second, 3 * time.Second,
+			[]time.Duration{3 * time.Second, 3 * time.Second}},
+		{"zero delays", 0, 0,
+			[]time.Duration{MinimumBackoffDelay, MinimumBackoffDelay, MinimumBackoffDelay}},
+		{"negative initial delay", -time.Second, time.Second,
+			[]time.Duration{MinimumBackoffDelay, 2 * MinimumBackoffDelay, 4 * MinimumBackoffDelay, 8 * MinimumBackoffDelay, time.Second}},
+		{"zero maximum delay", time.Second, 0,
+			[]time.Duration{MinimumBackoffDelay, MinimumBackoffDelay}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backoff := CreateBackoff(test.initialDelay, test.maximumDelay)
+
+			// Each delay is jittered to lie between half of, and the full, delay, and should never be zero
+			checkDelays := func() {
+				for i, wantDelay := range test.wantDelays {
+					if gotDelay := backoff.Next(); gotDelay < wantDelay/2 || gotDelay > wantDelay || gotDelay <= 0 {
+						t.Errorf("Next() #%d = %v, want between %v and %v", i+1, gotDelay, wantDelay/2, wantDelay)
+					}
+				}
+			}
+
+			checkDelays()
+
+			// After a reset, the delays start over
+			backoff.Reset()
+			checkDelays()
+		})
+	}
+}
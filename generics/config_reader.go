@@ -4,10 +4,12 @@
  * Package:   Generic
  * Component: Config Reader
  *
- * This component reads ini files.
- * It gladly uses the functionality provided by "gopkg.in/ini.v1".
+ * This component reads ini files, as well as YAML files.
+ * It gladly uses the functionality provided by "gopkg.in/ini.v1" and "gopkg.in/yaml.v3".
  * Nevertheless, having our own configuration loader makes the rest of the code less dependent on potential changes to
- * the latter package.
+ * the latter packages.
+ * In YAML files, the top-level maps play the role of sections, while the other top-level keys are the keys
+ * outside of any section.
  * Furthermore, it also allows us to:
  * - introduce the option of default values (see StringWithDefault, etc) of default values, when no value is
  *   provided in the ini file.
@@ -15,14 +17,21 @@
  *
  * Author: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 27.11.2025
+ * Version of: 15.10.2026
  *
  */
 
 package generics
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
 )
 
 /*
@@ -30,31 +39,113 @@ import (
  */
 
 type (
-	// Since we want to define some extra functions for config data, we need to "wrap" the two existing types as
-	// defined by the "gopkg.in/ini.v1" package.
+	// The config data can be read from different kinds of config files, each providing the (string) values of keys in sections
+	tConfigSource interface {
+		value(section, key string) string
+	}
 
 	TConfigData struct {
-		configFile *ini.File // The ini file as read by the ini package
+		configSource tConfigSource // The source of the config data
 	}
 
 	TConfigValue struct {
-		configKey *ini.Key // The ini key as read by the ini package
+		configValue string // The value as read from the config source
 	}
 )
 
+/*
+ * Defining config sources
+ */
+
+type (
+	// Since we want to define some extra functions for config data, we need to "wrap" the existing type as
+	// defined by the "gopkg.in/ini.v1" package.
+	tINIConfigSource struct {
+		configFile *ini.File // The ini file as read by the ini package
+	}
+
+	// The sections of a YAML file, where "" is used for the top-level keys
+	tYAMLConfigSource struct {
+		sections map[string]map[string]string // The values per key, per section
+	}
+)
+
+// Get the value from a given section and key of an ini file
+func (s *tINIConfigSource) value(section, key string) string {
+	return s.configFile.Section(section).Key(key).String()
+}
+
+// Get the value from a given section and key of a YAML file
+func (s *tYAMLConfigSource) value(section, key string) string {
+	return s.sections[section][key]
+}
+
+// Add the values in a YAML map to the given section.
+// Nested maps become sections themselves, using the "parent.child" naming of ini files.
+func (s *tYAMLConfigSource) addValues(section string, values map[string]any) {
+	if s.sections[section] == nil {
+		s.sections[section] = map[string]string{}
+	}
+
+	for key, value := range values {
+		switch value := value.(type) {
+		case map[string]any:
+			if section == "" {
+				s.addValues(key, value)
+			} else {
+				s.addValues(section+"."+key, value)
+			}
+
+		case nil:
+			s.sections[section][key] = ""
+
+		default:
+			s.sections[section][key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// Load a YAML file as config source
+func loadYAMLConfigSource(filePath string) (*tYAMLConfigSource, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+
+	configSource := tYAMLConfigSource{}
+	configSource.sections = map[string]map[string]string{}
+	configSource.addValues("", values)
+
+	return &configSource, nil
+}
+
 /*
  * Loading configguration files
  */
 
 // Load the configuration file.
+// Files with a ".yaml" or ".yml" extension are read as YAML, all other files as ini.
 func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
 	var (
 		err        error       //	Error return value
 		configData TConfigData // The read config data
 	)
 
-	reporter.Progress(1, "Reading config file: %s", filePath)
-	configData.configFile, err = ini.Load(filePath)
+	reporter.Progress(ProgressLevelBasic, "Reading config file: %s", filePath)
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		configData.configSource, err = loadYAMLConfigSource(filePath)
+
+	default:
+		configSource := tINIConfigSource{}
+		configSource.configFile, err = ini.Load(filePath)
+		configData.configSource = &configSource
+	}
 
 	if err != nil {
 		reporter.Panic("Failed to read config file. %s", err)
@@ -63,6 +154,30 @@ func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
 	return &configData
 }
 
+/*
+ * Validating config data
+ */
+
+// Check that the given section/key pairs have a (non-empty) value, returning the missing ones (e.g. "[ftp] server").
+// Keys at the top level of the config file use "" as section.
+func (c *TConfigData) Require(pairs ...[2]string) []string {
+	missing := []string{}
+
+	for _, pair := range pairs {
+		section, key := pair[0], pair[1]
+
+		if c.GetValue(section, key).String() == "" {
+			if section == "" {
+				missing = append(missing, key)
+			} else {
+				missing = append(missing, "["+section+"] "+key)
+			}
+		}
+	}
+
+	return missing
+}
+
 /*
  * Retrieving config values
  */
@@ -71,14 +186,14 @@ func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
 func (c *TConfigData) GetValue(section, key string) *TConfigValue {
 	var configValue TConfigValue
 
-	configValue.configKey = c.configFile.Section(section).Key(key)
+	configValue.configValue = c.configSource.value(section, key)
 
 	return &configValue
 }
 
 // Map the config value to a string, using the given default when the config value is empty
 func (v *TConfigValue) StringWithDefault(defaultString string) string {
-	s := v.configKey.String()
+	s := v.configValue
 	if s == "" {
 		return defaultString
 	} else {
@@ -91,9 +206,21 @@ func (v *TConfigValue) String() string {
 	return v.StringWithDefault("")
 }
 
+// Parse a bool in the same way as the ini package does, so all config sources behave identically
+func parseConfigBool(s string) (bool, error) {
+	switch s {
+	case "1", "t", "T", "true", "TRUE", "True", "YES", "yes", "Yes", "y", "ON", "on", "On":
+		return true, nil
+	case "0", "f", "F", "false", "FALSE", "False", "NO", "no", "No", "n", "OFF", "off", "Off":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("parsing \"%s\": invalid syntax", s)
+}
+
 // Map the config value to a bool, using the given default when the config value is not provided
 func (v *TConfigValue) BoolWithDefault(defaultBool bool) bool {
-	keyBool, err := v.configKey.Bool()
+	keyBool, err := parseConfigBool(v.configValue)
 	if err == nil {
 		return keyBool
 	} else {
@@ -108,9 +235,9 @@ func (v *TConfigValue) Bool() bool {
 
 // Map the config value to an int, using the given default when the config value is not provided
 func (v *TConfigValue) IntWithDefault(defaultInt int) int {
-	keyInt, err := v.configKey.Int()
+	keyInt, err := strconv.ParseInt(v.configValue, 0, 64)
 	if err == nil {
-		return keyInt
+		return int(keyInt)
 	} else {
 		return defaultInt
 	}
@@ -0,0 +1,143 @@
+package generics
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Load a config file with the given name and content
+func loadTestConfig(t *testing.T, fileName, content string) *TConfigData {
+	t.Helper()
+
+	configFilePath := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(configFilePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing the config file: %v", err)
+	}
+
+	return LoadConfig(configFilePath, CreateReporter(ProgressLevelBasic, func(string) {}, func(string) {}))
+}
+
+func TestRequire(t *testing.T) {
+	configData := loadTestConfig(t, "config.ini", "environment = test\nagent =\n\n[ftp]\nserver = localhost\nport =\n")
+
+	tests := []struct {
+		name        string
+		pairs       [][2]string
+		wantMissing []string
+	}{
+		{"nothing required", nil, []string{}},
+		{"all present", [][2]string{{"", "environment"}, {"ftp", "server"}}, []string{}},
+		{"empty top level key", [][2]string{{"", "environment"}, {"", "agent"}}, []string{"agent"}},
+		{"absent top level key", [][2]string{{"", "work_folder"}}, []string{"work_folder"}},
+		{"empty key in section", [][2]string{{"ftp", "server"}, {"ftp", "port"}}, []string{"[ftp] port"}},
+		{"absent section", [][2]string{{"mqtt", "broker"}, {"mqtt", "port"}}, []string{"[mqtt] broker", "[mqtt] port"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if gotMissing := configData.Require(test.pairs...); !slices.Equal(gotMissing, test.wantMissing) {
+				t.Errorf("Require(%v) = %q, want %q", test.pairs, gotMissing, test.wantMissing)
+			}
+		})
+	}
+}
+
+func TestParseConfigBool(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantBool  bool
+		wantError bool
+	}{
+		{"1", true, false},
+		{"true", true, false},
+		{"True", true, false},
+		{"YES", true, false},
+		{"y", true, false},
+		{"on", true, false},
+		{"0", false, false},
+		{"false", false, false},
+		{"FALSE", false, false},
+		{"No", false, false},
+		{"n", false, false},
+		{"Off", false, false},
+		{"", false, true},
+		{"tRuE", false, true},
+		{"2", false, true},
+		{"enabled", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			gotBool, err := parseConfigBool(test.value)
+			if gotBool != test.wantBool || (err != nil) != test.wantError {
+				t.Errorf("parseConfigBool(%q) = %v, %v, want %v, error: %v", test.value, gotBool, err, test.wantBool, test.wantError)
+			}
+		})
+	}
+}
+
+// The same configuration, whether read from an ini or a YAML file, should give the same values
+func TestConfigSourcesAgree(t *testing.T) {
+	configs := map[string]*TConfigData{
+		"ini": loadTestConfig(t, "config.ini",
+			"environment = test\ndry_run = yes\n\n[ftp]\nport = 21\ncompress = off\n\n[mqtt.tls]\nenabled = true\n"),
+		"yaml": loadTestConfig(t, "config.yaml",
+			"environment: test\ndry_run: yes\nftp:\n  port: 21\n  compress: off\nmqtt:\n  tls:\n    enabled: true\n"),
+	}
+
+	for kind, configData := range configs {
+		t.Run(kind, func(t *testing.T) {
+			if got := configData.GetValue("", "environment").String(); got != "test" {
+				t.Errorf("environment = %q, want %q", got, "test")
+			}
+			if got := configData.GetValue("", "dry_run").BoolWithDefault(false); !got {
+				t.Errorf("dry_run = %v, want true", got)
+			}
+			if got := configData.GetValue("ftp", "port").IntWithDefault(0); got != 21 {
+				t.Errorf("[ftp] port = %d, want 21", got)
+			}
+			if got := configData.GetValue("ftp", "compress").BoolWithDefault(true); got {
+				t.Errorf("[ftp] compress = %v, want false", got)
+			}
+			if got := configData.GetValue("mqtt.tls", "enabled").Bool(); !got {
+				t.Errorf("[mqtt.tls] enabled = %v, want true", got)
+			}
+			if got := configData.GetValue("ftp", "absent").StringWithDefault("default"); got != "default" {
+				t.Errorf("[ftp] absent = %q, want %q", got, "default")
+			}
+		})
+	}
+}
+
+func TestLoadConfigProgressLevel(t *testing.T) {
+	configFilePath := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(configFilePath, []byte("environment = test\n"), 0o644); err != nil {
+		t.Fatalf("writing the config file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		level        int
+		wantProgress []string
+	}{
+		{"silent", 0, nil},
+		{"basic", ProgressLevelBasic, []string{"Reading config file: " + configFilePath}},
+		{"noisy", ProgressLevelNoisy, []string{"Reading config file: " + configFilePath}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotProgress := []string(nil)
+			reporter := CreateReporter(test.level, func(string) {}, func(message string) {
+				gotProgress = append(gotProgress, message)
+			})
+
+			LoadConfig(configFilePath, reporter)
+			if !slices.Equal(gotProgress, test.wantProgress) {
+				t.Errorf("LoadConfig() reported progress %q, want %q", gotProgress, test.wantProgress)
+			}
+		})
+	}
+}
@@ -22,5 +22,8 @@ const (
 	ModellingBusVersion = "bus-version-1.0"         // The current version of the BIG modelling bus.
 	PayloadFileName     = "payload"                 // Name of the file used to store the "payload" of artefacts on the FTP server.
 	JSONExtension       = ".json"                   // Name of the local file used to (temporarily) represent upload/downloaded JSONs.
+	GZipExtension       = ".gz"                     // Extension of files that have been compressed using gzip.
 	JSONFileName        = "message" + JSONExtension // Name of the local file used to (temporarily) represent upload/downloaded JSONs.
+
+	TemporaryJSONFilePattern = "message-*" + JSONExtension // Pattern for uniquely named local files used to (temporarily) represent upload/downloaded JSONs.
 )
@@ -12,14 +12,17 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 29.11.2025
+ * Version of: 15.10.2026
  *
  */
 
 package generics
 
 import (
+	"bytes"
 	"encoding/json"
+	"strconv"
+	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/wI2L/jsondiff"
@@ -35,6 +38,44 @@ func JSONDiff(sourceJSON, targetJSON []byte) (json.RawMessage, error) {
 	return json.Marshal(deltaOperations)
 }
 
+// TDiffOptions defines the options for computing the difference between two JSONs.
+type TDiffOptions struct {
+	IgnoredPaths []string // JSON pointers of the parts of the JSONs for which changes are excluded
+	EmitTests    bool     // Whether to emit "test" operations, guarding the values that are removed or replaced
+}
+
+// JSONDiffWithOptions computes the difference between two JSONs, taking the given options into account, and returns it as a JSON Patch.
+func JSONDiffWithOptions(sourceJSON, targetJSON []byte, options TDiffOptions) (json.RawMessage, error) {
+	// Translate the options
+	diffOptions := []jsondiff.Option{jsondiff.Ignores(options.IgnoredPaths...)}
+	if options.EmitTests {
+		diffOptions = append(diffOptions, jsondiff.Invertible())
+	}
+
+	deltaOperations, err := jsondiff.CompareJSON(sourceJSON, targetJSON, diffOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure no changes under the ignored paths remain
+	filteredOperations := jsondiff.Patch{}
+	for _, deltaOperation := range deltaOperations {
+		ignored := false
+		for _, ignoredPath := range options.IgnoredPaths {
+			if deltaOperation.Path == ignoredPath || strings.HasPrefix(deltaOperation.Path, ignoredPath+"/") {
+				ignored = true
+				break
+			}
+		}
+
+		if !ignored {
+			filteredOperations = append(filteredOperations, deltaOperation)
+		}
+	}
+
+	return json.Marshal(filteredOperations)
+}
+
 // JSONApplyPatch applies a JSON Patch to a source JSON and returns the resulting JSON.
 func JSONApplyPatch(sourceJSON, patchJSON []byte) (json.RawMessage, error) {
 	patch, err := jsonpatch.DecodePatch(patchJSON)
@@ -45,7 +86,232 @@ func JSONApplyPatch(sourceJSON, patchJSON []byte) (json.RawMessage, error) {
 	return patch.Apply(sourceJSON)
 }
 
+// CanonicalizeJSON re-marshals a JSON into a canonical form, without insignificant whitespace, with the keys
+// of objects in sorted order, and with numbers in a uniform format.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// CompactJSON removes the insignificant whitespace from a JSON, e.g. as added by pretty-printing, leaving it otherwise as is.
+// Content that is not a valid JSON is returned unchanged.
+func CompactJSON(data []byte) []byte {
+	compacted := bytes.Buffer{}
+	if err := json.Compact(&compacted, data); err != nil {
+		return data
+	}
+
+	return compacted.Bytes()
+}
+
+// JSONGet returns the value at the given JSON pointer (see https://datatracker.ietf.org/doc/html/rfc6901), and whether it exists.
+func JSONGet(data []byte, pointer string) (json.RawMessage, bool) {
+	value := json.RawMessage(data)
+
+	// The empty pointer refers to the whole JSON
+	if pointer == "" {
+		return value, IsJSON(value)
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	// Follow the reference tokens of the pointer
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		// Look the token up as member of an object
+		object := map[string]json.RawMessage{}
+		if json.Unmarshal(value, &object) == nil {
+			member, exists := object[token]
+			if !exists {
+				return nil, false
+			}
+			value = member
+
+			continue
+		}
+
+		// Otherwise, look the token up as index of an array
+		array := []json.RawMessage{}
+		if json.Unmarshal(value, &array) != nil {
+			return nil, false
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(array) || strconv.Itoa(index) != token {
+			return nil, false
+		}
+		value = array[index]
+	}
+
+	return value, true
+}
+
+// PostingSize returns the size (in bytes) of a JSON message when it is posted.
+func PostingSize(json []byte) int {
+	return len(json)
+}
+
 // IsJSON checks whether the message is a valid JSON.
 func IsJSON(message []byte) bool {
 	return json.Unmarshal(message, &json.RawMessage{}) == nil
 }
+
+/*
+ * Three-way merging
+ */
+
+// TJSONConflict describes a path that has been changed by both sides of a three-way merge, in different ways.
+type TJSONConflict struct {
+	Path   string          `json:"path"`   // JSON pointer to the conflicting part
+	Theirs json.RawMessage `json:"theirs"` // The value as changed by them (null when removed)
+	Mine   json.RawMessage `json:"mine"`   // The value as changed by me (null when removed)
+}
+
+// Check whether two JSON pointers overlap, i.e. whether one of them is (a prefix of) the other.
+func jsonPointersOverlap(pointer1, pointer2 string) bool {
+	return pointer1 == pointer2 ||
+		strings.HasPrefix(pointer1, pointer2+"/") ||
+		strings.HasPrefix(pointer2, pointer1+"/")
+}
+
+// Get the value of an operation as JSON
+func jsonOperationValue(operation jsondiff.Operation) json.RawMessage {
+	if operation.Type == jsondiff.OperationRemove {
+		return json.RawMessage("null")
+	}
+
+	value, err := json.Marshal(operation.Value)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+
+	return value
+}
+
+// Get the JSON pointer to the outermost array in the base JSON that contains the part the given JSON pointer refers to, if any
+func jsonArrayPointer(baseJSON []byte, pointer string) (string, bool) {
+	arrayPointer := ""
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		value, exists := JSONGet(baseJSON, arrayPointer)
+		if !exists {
+			return "", false
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(value), []byte("[")) {
+			return arrayPointer, true
+		}
+
+		arrayPointer += "/" + token
+	}
+
+	return "", false
+}
+
+// Determine the changes from the base JSON to the changed JSON.
+// Positions in arrays shift when elements are added or removed, so the positions in the changes of both sides
+// of a merge cannot be compared. Changes within an array are therefore turned into a replacement of the whole array.
+func jsonMergeOperations(baseJSON, changedJSON []byte) (jsondiff.Patch, error) {
+	operations, err := jsondiff.CompareJSON(baseJSON, changedJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeOperations := jsondiff.Patch{}
+	replacedArrays := map[string]bool{}
+	for _, operation := range operations {
+		arrayPointer, withinArray := jsonArrayPointer(baseJSON, operation.Path)
+		if !withinArray {
+			mergeOperations = append(mergeOperations, operation)
+
+			continue
+		}
+
+		// Only replace each array once
+		if replacedArrays[arrayPointer] {
+			continue
+		}
+		replacedArrays[arrayPointer] = true
+
+		// Replace the whole array by its changed version
+		var changedArray any
+		changedArrayJSON, _ := JSONGet(changedJSON, arrayPointer)
+		if err := json.Unmarshal(changedArrayJSON, &changedArray); err != nil {
+			return nil, err
+		}
+		mergeOperations = append(mergeOperations, jsondiff.Operation{Type: jsondiff.OperationReplace, Path: arrayPointer, Value: changedArray})
+	}
+
+	return mergeOperations, nil
+}
+
+// JSONThreeWayMerge merges the changes made by them and by me, relative to a common base JSON.
+// Changes by only one of the sides are all taken along, while changes by both sides to the same path
+// are only taken along when they are the same. Otherwise, their change is kept and the conflict is returned.
+// Arrays are merged as a whole, so when both sides changed the same array in different ways, the whole array is in conflict.
+func JSONThreeWayMerge(baseJSON, theirsJSON, mineJSON []byte) (json.RawMessage, []TJSONConflict, error) {
+	// Determine the changes on both sides
+	theirOperations, err := jsonMergeOperations(baseJSON, theirsJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	myOperations, err := jsonMergeOperations(baseJSON, mineJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Select my changes that do not conflict with theirs
+	conflicts := []TJSONConflict{}
+	mergedOperations := jsondiff.Patch{}
+	for _, myOperation := range myOperations {
+		conflicting := false
+		alreadyDone := false
+
+		for _, theirOperation := range theirOperations {
+			if !jsonPointersOverlap(myOperation.Path, theirOperation.Path) {
+				continue
+			}
+
+			// Both sides made the very same change
+			if myOperation.Path == theirOperation.Path && myOperation.Type == theirOperation.Type &&
+				bytes.Equal(jsonOperationValue(myOperation), jsonOperationValue(theirOperation)) {
+				alreadyDone = true
+				continue
+			}
+
+			// Both sides changed the same path in different ways
+			conflict := TJSONConflict{}
+			conflict.Path = myOperation.Path
+			conflict.Theirs = jsonOperationValue(theirOperation)
+			conflict.Mine = jsonOperationValue(myOperation)
+			conflicts = append(conflicts, conflict)
+			conflicting = true
+
+			break
+		}
+
+		if !conflicting && !alreadyDone {
+			mergedOperations = append(mergedOperations, myOperation)
+		}
+	}
+
+	// Nothing of mine to add
+	if len(mergedOperations) == 0 {
+		return theirsJSON, conflicts, nil
+	}
+
+	// Apply my remaining changes to theirs
+	mergedPatch, err := json.Marshal(mergedOperations)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergedJSON, err := JSONApplyPatch(theirsJSON, mergedPatch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mergedJSON, conflicts, nil
+}
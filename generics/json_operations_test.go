@@ -0,0 +1,259 @@
+package generics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Check whether two JSONs have the same value, regardless of their formatting
+func sameJSON(t *testing.T, json1, json2 []byte) bool {
+	t.Helper()
+
+	var value1, value2 any
+	if err := json.Unmarshal(json1, &value1); err != nil {
+		t.Fatalf("invalid JSON %q: %v", json1, err)
+	}
+	if err := json.Unmarshal(json2, &value2); err != nil {
+		t.Fatalf("invalid JSON %q: %v", json2, err)
+	}
+
+	return reflect.DeepEqual(value1, value2)
+}
+
+func TestJSONThreeWayMerge(t *testing.T) {
+	base := `{"a":1,"b":2,"c":{"d":3}}`
+
+	tests := []struct {
+		name          string
+		theirs        string
+		mine          string
+		wantMerged    string
+		wantConflicts []TJSONConflict
+	}{
+		{"nothing changed", base, base, base, nil},
+		{"only they changed", `{"a":1,"b":3,"c":{"d":3}}`, base, `{"a":1,"b":3,"c":{"d":3}}`, nil},
+		{"only I changed", base, `{"a":2,"b":2,"c":{"d":3}}`, `{"a":2,"b":2,"c":{"d":3}}`, nil},
+		{"different paths", `{"a":1,"b":3,"c":{"d":3}}`, `{"a":2,"b":2,"c":{"d":3}}`, `{"a":2,"b":3,"c":{"d":3}}`, nil},
+		{"same change", `{"a":1,"b":3,"c":{"d":3}}`, `{"a":1,"b":3,"c":{"d":3}}`, `{"a":1,"b":3,"c":{"d":3}}`, nil},
+		{"added by me", base, `{"a":1,"b":2,"c":{"d":3,"e":4}}`, `{"a":1,"b":2,"c":{"d":3,"e":4}}`, nil},
+		{
+			"different changes", `{"a":1,"b":3,"c":{"d":3}}`, `{"a":1,"b":4,"c":{"d":3}}`, `{"a":1,"b":3,"c":{"d":3}}`,
+			[]TJSONConflict{{Path: "/b", Theirs: json.RawMessage("3"), Mine: json.RawMessage("4")}},
+		},
+		{
+			"removed by them, changed by me", `{"a":1,"c":{"d":3}}`, `{"a":1,"b":4,"c":{"d":3}}`, `{"a":1,"c":{"d":3}}`,
+			[]TJSONConflict{{Path: "/b", Theirs: json.RawMessage("null"), Mine: json.RawMessage("4")}},
+		},
+		{
+			"removed by them, changed underneath by me", `{"a":1,"b":2}`, `{"a":1,"b":2,"c":{"d":4}}`, `{"a":1,"b":2}`,
+			[]TJSONConflict{{Path: "/c/d", Theirs: json.RawMessage("null"), Mine: json.RawMessage("4")}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotMerged, gotConflicts, err := JSONThreeWayMerge([]byte(base), []byte(test.theirs), []byte(test.mine))
+			if err != nil {
+				t.Fatalf("JSONThreeWayMerge() returned error: %v", err)
+			}
+			if !sameJSON(t, gotMerged, []byte(test.wantMerged)) {
+				t.Errorf("JSONThreeWayMerge() merged = %s, want %s", gotMerged, test.wantMerged)
+			}
+			if len(gotConflicts) != len(test.wantConflicts) {
+				t.Fatalf("JSONThreeWayMerge() conflicts = %+v, want %+v", gotConflicts, test.wantConflicts)
+			}
+			for i, gotConflict := range gotConflicts {
+				wantConflict := test.wantConflicts[i]
+				if gotConflict.Path != wantConflict.Path ||
+					!sameJSON(t, gotConflict.Theirs, wantConflict.Theirs) || !sameJSON(t, gotConflict.Mine, wantConflict.Mine) {
+					t.Errorf("JSONThreeWayMerge() conflict = %+v, want %+v", gotConflict, wantConflict)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONThreeWayMergeArrays(t *testing.T) {
+	base := `{"a":["x","y","z"],"b":{"c":[1,2]}}`
+
+	tests := []struct {
+		name          string
+		theirs        string
+		mine          string
+		wantMerged    string
+		wantConflicts []TJSONConflict
+	}{
+		{
+			"only they changed", `{"a":["w","x","y","z"],"b":{"c":[1,2]}}`, base, `{"a":["w","x","y","z"],"b":{"c":[1,2]}}`, nil,
+		},
+		{
+			"only I changed", base, `{"a":["x","y","Z"],"b":{"c":[1,2]}}`, `{"a":["x","y","Z"],"b":{"c":[1,2]}}`, nil,
+		},
+		{
+			"different arrays", `{"a":["w","x","y","z"],"b":{"c":[1,2]}}`, `{"a":["x","y","z"],"b":{"c":[1,2,3]}}`,
+			`{"a":["w","x","y","z"],"b":{"c":[1,2,3]}}`, nil,
+		},
+		{
+			"same change", `{"a":["x","z"],"b":{"c":[1,2]}}`, `{"a":["x","z"],"b":{"c":[1,2]}}`, `{"a":["x","z"],"b":{"c":[1,2]}}`, nil,
+		},
+		{
+			"prepended by them, last changed by me", `{"a":["w","x","y","z"],"b":{"c":[1,2]}}`, `{"a":["x","y","Z"],"b":{"c":[1,2]}}`,
+			`{"a":["w","x","y","z"],"b":{"c":[1,2]}}`,
+			[]TJSONConflict{{Path: "/a", Theirs: json.RawMessage(`["w","x","y","z"]`), Mine: json.RawMessage(`["x","y","Z"]`)}},
+		},
+		{
+			"removed by them, changed by me", `{"a":["y","z"],"b":{"c":[1,2]}}`, `{"a":["x","Y","z"],"b":{"c":[1,2]}}`,
+			`{"a":["y","z"],"b":{"c":[1,2]}}`,
+			[]TJSONConflict{{Path: "/a", Theirs: json.RawMessage(`["y","z"]`), Mine: json.RawMessage(`["x","Y","z"]`)}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotMerged, gotConflicts, err := JSONThreeWayMerge([]byte(base), []byte(test.theirs), []byte(test.mine))
+			if err != nil {
+				t.Fatalf("JSONThreeWayMerge() returned error: %v", err)
+			}
+			if !sameJSON(t, gotMerged, []byte(test.wantMerged)) {
+				t.Errorf("JSONThreeWayMerge() merged = %s, want %s", gotMerged, test.wantMerged)
+			}
+			if len(gotConflicts) != len(test.wantConflicts) {
+				t.Fatalf("JSONThreeWayMerge() conflicts = %+v, want %+v", gotConflicts, test.wantConflicts)
+			}
+			for i, gotConflict := range gotConflicts {
+				wantConflict := test.wantConflicts[i]
+				if gotConflict.Path != wantConflict.Path ||
+					!sameJSON(t, gotConflict.Theirs, wantConflict.Theirs) || !sameJSON(t, gotConflict.Mine, wantConflict.Mine) {
+					t.Errorf("JSONThreeWayMerge() conflict = %+v, want %+v", gotConflict, wantConflict)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONThreeWayMergeInvalidJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		theirs string
+		mine   string
+	}{
+		{"invalid theirs", `{"a":1}`, `{"a":`, `{"a":1}`},
+		{"invalid mine", `{"a":1}`, `{"a":1}`, `{"a":`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := JSONThreeWayMerge([]byte(test.base), []byte(test.theirs), []byte(test.mine)); err == nil {
+				t.Errorf("JSONThreeWayMerge() returned no error")
+			}
+		})
+	}
+}
+
+func TestCanonicalizeJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		wantCanonical string
+		wantErr       bool
+	}{
+		{"already canonical", `{"a":1,"b":[true,null,"c"]}`, `{"a":1,"b":[true,null,"c"]}`, false},
+		{"whitespace", "{\n  \"a\": 1,\n  \"b\": [ true, null, \"c\" ]\n}\n", `{"a":1,"b":[true,null,"c"]}`, false},
+		{"key order", `{"b":{"d":2,"c":1},"a":1}`, `{"a":1,"b":{"c":1,"d":2}}`, false},
+		{"number formats", `[1.0,1e2,-0.50,2E-1]`, `[1,100,-0.5,0.2]`, false},
+		{"escapes", `"A\/b"`, `"A/b"`, false},
+		{"invalid JSON", `{"a":`, "", true},
+		{"empty", ``, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotCanonical, err := CanonicalizeJSON([]byte(test.json))
+			if string(gotCanonical) != test.wantCanonical || (err != nil) != test.wantErr {
+				t.Errorf("CanonicalizeJSON(%q) = %s, %v, want %s, error: %v", test.json, gotCanonical, err, test.wantCanonical, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONDiffOfCanonicalizedJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		sourceJSON    string
+		targetJSON    string
+		wantUnchanged bool
+	}{
+		{"differently formatted", `{"a":1.0,"b":[1,2]}`, "{ \"b\": [ 1, 2 ],\n \"a\": 1 }", true},
+		{"differently written numbers", `{"a":100}`, `{"a":1e2}`, true},
+		{"different values", `{"a":1,"b":[1,2]}`, `{"a":1,"b":[2,1]}`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canonicalSourceJSON, err := CanonicalizeJSON([]byte(test.sourceJSON))
+			if err != nil {
+				t.Fatalf("CanonicalizeJSON(%q): %v", test.sourceJSON, err)
+			}
+			canonicalTargetJSON, err := CanonicalizeJSON([]byte(test.targetJSON))
+			if err != nil {
+				t.Fatalf("CanonicalizeJSON(%q): %v", test.targetJSON, err)
+			}
+
+			operations, err := JSONDiff(canonicalSourceJSON, canonicalTargetJSON)
+			if err != nil {
+				t.Fatalf("JSONDiff(): %v", err)
+			}
+			gotOperations := []any{}
+			if err := json.Unmarshal(operations, &gotOperations); err != nil {
+				t.Fatalf("JSONDiff() = %s, which is not a list of operations: %v", operations, err)
+			}
+			if gotUnchanged := len(gotOperations) == 0; gotUnchanged != test.wantUnchanged {
+				t.Errorf("JSONDiff() = %s, want no operations: %v", operations, test.wantUnchanged)
+			}
+		})
+	}
+}
+
+func TestJSONGet(t *testing.T) {
+	data := []byte(`{"model name":"Births","types":{"id-1":"Person","a/b":"slash","m~n":"tilde"},"readings":[["born","on"],[]],"empty":null}`)
+
+	tests := []struct {
+		name       string
+		pointer    string
+		wantValue  string
+		wantExists bool
+	}{
+		{"whole document", "", string(data), true},
+		{"member", "/model name", `"Births"`, true},
+		{"nested member", "/types/id-1", `"Person"`, true},
+		{"escaped slash", "/types/a~1b", `"slash"`, true},
+		{"escaped tilde", "/types/m~0n", `"tilde"`, true},
+		{"array element", "/readings/0/1", `"on"`, true},
+		{"empty array", "/readings/1", `[]`, true},
+		{"null value", "/empty", `null`, true},
+		{"missing member", "/types/id-2", "", false},
+		{"index out of range", "/readings/2", "", false},
+		{"negative index", "/readings/-1", "", false},
+		{"index with leading zero", "/readings/00", "", false},
+		{"index that is not a number", "/readings/first", "", false},
+		{"below a string", "/model name/x", "", false},
+		{"without leading slash", "model name", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotValue, gotExists := JSONGet(data, test.pointer)
+			if string(gotValue) != test.wantValue || gotExists != test.wantExists {
+				t.Errorf("JSONGet(%q) = %s, %v, want %s, %v", test.pointer, gotValue, gotExists, test.wantValue, test.wantExists)
+			}
+		})
+	}
+}
+
+func TestJSONGetInvalidJSON(t *testing.T) {
+	if gotValue, gotExists := JSONGet([]byte(`{"a":`), ""); gotExists {
+		t.Errorf("JSONGet() of an invalid JSON = %s, %v, want it not to exist", gotValue, gotExists)
+	}
+}
@@ -9,7 +9,7 @@
  *
  * Author: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 27.11.2025
+ * Version of: 15.10.2026
  *
  */
 
@@ -17,6 +17,9 @@ package generics
 
 import (
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 )
 
 /*
@@ -37,10 +40,32 @@ type (
 	TErrorReporter    func(string)
 	TProgressReporter func(string)
 
+	// An observer of errors or panics, identified so it can be deregistered again
+	tObserver struct {
+		id       int    // The ID of the observer
+		observer func() // The function to be called
+	}
+
 	TReporter struct {
 		reportingLevel   int
+		levelMutex       sync.RWMutex // Guards the reporting level, as it may be changed while reporting
 		errorReporter    TErrorReporter
 		progressReporter TProgressReporter
+
+		errorObservers []tObserver // Called for each reported error (e.g. to count errors)
+		panicObservers []tObserver // Called just before panicking (e.g. to clean up)
+		observerCount  int         // The number of observers registered so far, used to identify them
+		observersMutex sync.Mutex  // Guards the observers, as e.g. connectors may (de)register them while the reporter is in use
+
+		sinks []*TReporter // The reporters to fan out to, for reporters combining multiple reporters
+
+		// When deduplicating, repeats of the same error within the deduplication window are only counted
+		deduplicationWindow time.Duration // The window within which repeated errors are suppressed (0 means no deduplication)
+		lastError           string        // The last reported error
+		lastErrorTime       time.Time     // The time the last error was reported
+		errorRepeats        int           // The number of suppressed repeats of the last error
+		summaryTimer        *time.Timer   // Reports the number of suppressed repeats when the deduplication window expires
+		deduplicationMutex  sync.Mutex    // Guards the deduplication administration, and the reporting of deduplicated errors
 	}
 )
 
@@ -48,15 +73,169 @@ type (
  * Defining reporter functionality
  */
 
+// Registering an observer in the given list of observers, returning a function that deregisters it again
+func (r *TReporter) addObserver(observers *[]tObserver, observer func()) func() {
+	r.observersMutex.Lock()
+	defer r.observersMutex.Unlock()
+
+	id := r.observerCount
+	r.observerCount++
+	*observers = append(*observers, tObserver{id: id, observer: observer})
+
+	return func() {
+		r.observersMutex.Lock()
+		defer r.observersMutex.Unlock()
+
+		*observers = slices.DeleteFunc(*observers, func(o tObserver) bool {
+			return o.id == id
+		})
+	}
+}
+
+// Calling the observers in the given list of observers.
+// They are called without holding the lock, so they can (de)register observers themselves.
+func (r *TReporter) notifyObservers(observers *[]tObserver) {
+	r.observersMutex.Lock()
+	currentObservers := slices.Clone(*observers)
+	r.observersMutex.Unlock()
+
+	for _, o := range currentObservers {
+		o.observer()
+	}
+}
+
+// Notifying the error observers of a reported error
+func (r *TReporter) notifyErrorObservers() {
+	r.notifyObservers(&r.errorObservers)
+}
+
+// Registering an observer that is called for each reported error.
+// Returns a function that deregisters the observer, e.g. once what it counts the errors for has been closed.
+func (r *TReporter) OnError(errorObserver func()) func() {
+	return r.addObserver(&r.errorObservers, errorObserver)
+}
+
+// Reporting an error, unless it is a repeat of the last error within the deduplication window.
+// Otherwise, the number of suppressed repeats of the last error (if any) is reported first.
+// The errors are reported while holding the lock, so they do not interleave with the summaries reported by the timer.
+func (r *TReporter) reportUnlessRepeated(errorMessage string, report func()) {
+	if r.deduplicationWindow <= 0 {
+		report()
+
+		return
+	}
+
+	r.deduplicationMutex.Lock()
+	defer r.deduplicationMutex.Unlock()
+
+	// Suppress repeats within the window, and summarise them when the window expires
+	now := time.Now()
+	if errorMessage == r.lastError && now.Sub(r.lastErrorTime) < r.deduplicationWindow {
+		r.errorRepeats++
+		if r.errorRepeats == 1 {
+			lastErrorTime := r.lastErrorTime
+			r.summaryTimer = time.AfterFunc(lastErrorTime.Add(r.deduplicationWindow).Sub(now), func() {
+				r.deduplicationMutex.Lock()
+				defer r.deduplicationMutex.Unlock()
+
+				// Only summarise when no other error has been reported in the meantime
+				if r.lastErrorTime.Equal(lastErrorTime) {
+					r.summariseRepeatedErrors()
+				}
+			})
+		}
+
+		return
+	}
+
+	r.summariseRepeatedErrors()
+
+	r.lastError = errorMessage
+	r.lastErrorTime = now
+	report()
+}
+
+// Reporting the number of suppressed repeats of the last error (if any).
+// Should be called while holding the deduplication lock.
+func (r *TReporter) summariseRepeatedErrors() {
+	if r.summaryTimer != nil {
+		r.summaryTimer.Stop()
+		r.summaryTimer = nil
+	}
+
+	if r.errorRepeats > 0 {
+		r.errorReporter(fmt.Sprintf("The previous error was repeated %d more time(s).", r.errorRepeats))
+	}
+
+	r.lastError = ""
+	r.errorRepeats = 0
+}
+
+// Closing the reporter, reporting the number of suppressed repeats of the last error (if any),
+// including those of the combined reporters
+func (r *TReporter) Close() {
+	for _, sink := range r.sinks {
+		sink.Close()
+	}
+
+	r.deduplicationMutex.Lock()
+	defer r.deduplicationMutex.Unlock()
+
+	r.summariseRepeatedErrors()
+}
+
+// Notifying the panic observers of an upcoming panic, including those of the combined reporters
+func (r *TReporter) notifyPanicObservers() {
+	for _, sink := range r.sinks {
+		sink.notifyPanicObservers()
+	}
+
+	r.notifyObservers(&r.panicObservers)
+}
+
+// Registering an observer that is called just before panicking, e.g. to disconnect or remove temporary files.
+// Returns a function that deregisters the observer, e.g. once what it cleans up has been closed.
+func (r *TReporter) OnPanic(panicObserver func()) func() {
+	return r.addObserver(&r.panicObservers, panicObserver)
+}
+
 // Reporting an error
 func (r *TReporter) Error(message string, context ...any) {
-	r.errorReporter(fmt.Sprintf(message, context...))
+	errorMessage := fmt.Sprintf(message, context...)
+
+	// Fan out to the combined reporters, each with their own deduplication and observers
+	if len(r.sinks) > 0 {
+		for _, sink := range r.sinks {
+			sink.Error("%s", errorMessage)
+		}
+		r.notifyErrorObservers()
+
+		return
+	}
+
+	r.reportUnlessRepeated(errorMessage, func() {
+		r.errorReporter(errorMessage)
+	})
+	r.notifyErrorObservers()
 }
 
 // Reporting an error with an error value
 func (r *TReporter) ReportError(message string, err error) {
-	r.Error(message)
-	r.Error("=> %s", err)
+	// Fan out to the combined reporters, each with their own deduplication and observers
+	if len(r.sinks) > 0 {
+		for _, sink := range r.sinks {
+			sink.ReportError(message, err)
+		}
+		r.notifyErrorObservers()
+
+		return
+	}
+
+	r.reportUnlessRepeated(message+"\n"+err.Error(), func() {
+		r.errorReporter(message)
+		r.errorReporter(fmt.Sprintf("=> %s", err))
+	})
+	r.notifyErrorObservers()
 }
 
 // Reporting an error if the error value is not nil
@@ -74,7 +253,7 @@ func (r *TReporter) MaybeReportEmptyFlagError(flagValue *string, message string)
 	// Checking the flag value
 	if len(*flagValue) == 0 {
 		// Reporting the error if needed
-		r.Error(message)
+		r.Error("%s", message)
 
 		// Indicating that an error was reported
 		return true
@@ -84,27 +263,69 @@ func (r *TReporter) MaybeReportEmptyFlagError(flagValue *string, message string)
 	return false
 }
 
-// Panicking with an error message
+// Panicking with an error message, using the message as error for the panic value
 func (r *TReporter) Panic(message string, context ...any) {
 	r.Error(message+" Panicking.", context...)
+	r.notifyPanicObservers()
 
-	panic("")
+	panic(fmt.Errorf(message, context...))
 }
 
-// Panicking with an error message and an error value
+// Panicking with an error message and an error value, using an error wrapping the error value for the panic value
 func (r *TReporter) PanicError(message string, err error) {
 	r.ReportError(message+" Panicking:", err)
+	r.notifyPanicObservers()
+
+	panic(fmt.Errorf("%s %w", message, err))
+}
+
+// Setting the reporting level, e.g. to turn up verbosity while debugging a running agent
+func (r *TReporter) SetLevel(level int) {
+	r.levelMutex.Lock()
+	defer r.levelMutex.Unlock()
+
+	r.reportingLevel = level
+}
+
+// Getting the reporting level
+func (r *TReporter) Level() int {
+	r.levelMutex.RLock()
+	defer r.levelMutex.RUnlock()
 
-	panic("")
+	return r.reportingLevel
 }
 
 // Reporting progress
 func (r *TReporter) Progress(level int, message string, context ...any) {
-	if level <= r.reportingLevel {
+	// Fan out to the combined reporters, each with their own reporting level
+	if len(r.sinks) > 0 {
+		for _, sink := range r.sinks {
+			sink.Progress(level, message, context...)
+		}
+
+		return
+	}
+
+	if level <= r.Level() {
 		r.progressReporter(fmt.Sprintf(message, context...))
 	}
 }
 
+// Reporting basic progress
+func (r *TReporter) Info(message string, context ...any) {
+	r.Progress(ProgressLevelBasic, message, context...)
+}
+
+// Reporting detailed progress
+func (r *TReporter) Detail(message string, context ...any) {
+	r.Progress(ProgressLevelDetailed, message, context...)
+}
+
+// Reporting noisy progress, e.g. for debugging
+func (r *TReporter) Debug(message string, context ...any) {
+	r.Progress(ProgressLevelNoisy, message, context...)
+}
+
 // Creating a new reporter
 func CreateReporter(level int, errorReporter TErrorReporter, progressReporter TProgressReporter) *TReporter {
 	reporter := TReporter{}
@@ -116,6 +337,36 @@ func CreateReporter(level int, errorReporter TErrorReporter, progressReporter TP
 	return &reporter
 }
 
+// Creating a reporter that suppresses repeats of the same error within the given window, reporting the number of repeats instead
+func CreateDeduplicatingReporter(level int, errorReporter TErrorReporter, progressReporter TProgressReporter, window time.Duration) *TReporter {
+	reporter := CreateReporter(level, errorReporter, progressReporter)
+	reporter.deduplicationWindow = window
+
+	return reporter
+}
+
+// Creating a reporter that fans out to multiple reporters, e.g. to report progress on the console while logging errors to a file.
+// Progress is reported by each of the reporters according to its own reporting level, and errors according to its own
+// deduplication, notifying its own error observers. Panics happen once, after all reporters reported the error and
+// notified their panic observers.
+func MultiReporter(reporters ...*TReporter) *TReporter {
+	reporter := TReporter{}
+
+	reporter.sinks = reporters
+	reporter.errorReporter = func(message string) {
+		for _, sink := range reporters {
+			sink.Error("%s", message)
+		}
+	}
+	reporter.progressReporter = func(message string) {
+		for _, sink := range reporters {
+			sink.progressReporter(message)
+		}
+	}
+
+	return &reporter
+}
+
 /*
  * Default progress and error reporters
  */
@@ -0,0 +1,341 @@
+package generics
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A sink collecting the reported errors and progress
+type tTestSink struct {
+	errors   []string
+	progress []string
+	mutex    sync.Mutex // Guards the errors, as summaries of repeated errors are reported by a timer
+}
+
+func (s *tTestSink) reportError(message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errors = append(s.errors, message)
+}
+
+// Get the errors reported so far
+func (s *tTestSink) reportedErrors() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return slices.Clone(s.errors)
+}
+
+func (s *tTestSink) reportProgress(message string) {
+	s.progress = append(s.progress, message)
+}
+
+func TestMultiReporter(t *testing.T) {
+	consoleSink, fileSink := &tTestSink{}, &tTestSink{}
+	console := CreateReporter(ProgressLevelBasic, consoleSink.reportError, consoleSink.reportProgress)
+	file := CreateDeduplicatingReporter(ProgressLevelNoisy, fileSink.reportError, fileSink.reportProgress, time.Minute)
+	reporter := MultiReporter(console, file)
+
+	// Count the errors observed by each of the reporters
+	consoleErrors, fileErrors, reporterErrors := 0, 0, 0
+	console.OnError(func() { consoleErrors++ })
+	file.OnError(func() { fileErrors++ })
+	reporter.OnError(func() { reporterErrors++ })
+
+	reporter.Info("Connected.")
+	reporter.Detail("Subscribed to %d topics.", 3)
+	reporter.Error("Could not post %s.", "model")
+	reporter.Error("Could not post %s.", "model")
+	reporter.ReportError("Error uploading file:", errors.New("connection refused"))
+
+	tests := []struct {
+		name         string
+		sink         *tTestSink
+		gotErrors    int
+		wantErrors   []string
+		wantProgress []string
+	}{
+		{
+			"console", consoleSink, consoleErrors,
+			[]string{"Could not post model.", "Could not post model.", "Error uploading file:", "=> connection refused"},
+			[]string{"Connected."},
+		},
+		{
+			"file", fileSink, fileErrors,
+			[]string{"Could not post model.", "The previous error was repeated 1 more time(s).", "Error uploading file:", "=> connection refused"},
+			[]string{"Connected.", "Subscribed to 3 topics."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !slices.Equal(test.sink.errors, test.wantErrors) {
+				t.Errorf("errors = %q, want %q", test.sink.errors, test.wantErrors)
+			}
+			if !slices.Equal(test.sink.progress, test.wantProgress) {
+				t.Errorf("progress = %q, want %q", test.sink.progress, test.wantProgress)
+			}
+			if test.gotErrors != 3 {
+				t.Errorf("observed %d error(s), want 3", test.gotErrors)
+			}
+		})
+	}
+	if reporterErrors != 3 {
+		t.Errorf("MultiReporter() observed %d error(s), want 3", reporterErrors)
+	}
+}
+
+func TestMultiReporterPanicsOnce(t *testing.T) {
+	sinks := []*tTestSink{{}, {}}
+	observed := []string{}
+	reporters := []*TReporter{}
+	for i, sink := range sinks {
+		reporter := CreateReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress)
+		reporter.OnPanic(func() { observed = append(observed, fmt.Sprintf("sink %d", i)) })
+		reporters = append(reporters, reporter)
+	}
+	reporter := MultiReporter(reporters...)
+	reporter.OnPanic(func() { observed = append(observed, "reporter") })
+
+	defer func() {
+		if gotPanic := fmt.Sprint(recover()); gotPanic != "Lost the connection." {
+			t.Errorf("Panic() panicked with %q, want %q", gotPanic, "Lost the connection.")
+		}
+		for i, sink := range sinks {
+			if !slices.Equal(sink.errors, []string{"Lost the connection. Panicking."}) {
+				t.Errorf("sink %d errors = %q, want the panic reported once", i, sink.errors)
+			}
+		}
+		if wantObserved := []string{"sink 0", "sink 1", "reporter"}; !slices.Equal(observed, wantObserved) {
+			t.Errorf("panic observers called = %q, want %q", observed, wantObserved)
+		}
+	}()
+	reporter.Panic("Lost the connection.")
+}
+
+func TestDeduplicatingReporter(t *testing.T) {
+	tests := []struct {
+		name       string
+		window     time.Duration
+		wantErrors int // The number of error lines for 100 identical errors, followed by a different one
+	}{
+		{"without deduplication", 0, 101},
+		{"within the window", time.Minute, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &tTestSink{}
+			reporter := CreateDeduplicatingReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress, test.window)
+			observed := 0
+			reporter.OnError(func() { observed++ })
+
+			for range 100 {
+				reporter.Error("Could not connect to the FTP server.")
+			}
+			reporter.Error("Could not post the model.")
+
+			if len(sink.errors) != test.wantErrors {
+				t.Errorf("the sink got %d error line(s), want %d: %q", len(sink.errors), test.wantErrors, sink.errors)
+			}
+			if observed != 101 {
+				t.Errorf("observed %d error(s), want 101, as suppressed errors are still observed", observed)
+			}
+		})
+	}
+}
+
+func TestDeduplicatingReporterSummary(t *testing.T) {
+	sink := &tTestSink{}
+	reporter := CreateDeduplicatingReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress, time.Minute)
+
+	for range 100 {
+		reporter.ReportError("Error uploading file:", errors.New("connection refused"))
+	}
+	reporter.ReportError("Error uploading file:", errors.New("timeout"))
+
+	wantErrors := []string{
+		"Error uploading file:", "=> connection refused",
+		"The previous error was repeated 99 more time(s).",
+		"Error uploading file:", "=> timeout",
+	}
+	if !slices.Equal(sink.errors, wantErrors) {
+		t.Errorf("errors = %q, want %q", sink.errors, wantErrors)
+	}
+}
+
+func TestDeduplicatingReporterWindowExpires(t *testing.T) {
+	sink := &tTestSink{}
+	reporter := CreateDeduplicatingReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress, time.Millisecond)
+
+	reporter.Error("Could not connect to the FTP server.")
+	time.Sleep(5 * time.Millisecond)
+	reporter.Error("Could not connect to the FTP server.")
+
+	wantErrors := []string{"Could not connect to the FTP server.", "Could not connect to the FTP server."}
+	if !slices.Equal(sink.errors, wantErrors) {
+		t.Errorf("errors = %q, want %q", sink.errors, wantErrors)
+	}
+}
+
+func TestDeduplicatingReporterSummaryWhenWindowExpires(t *testing.T) {
+	sink := &tTestSink{}
+	reporter := CreateDeduplicatingReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress, 20*time.Millisecond)
+
+	for range 100 {
+		reporter.Error("Could not connect to the FTP server.")
+	}
+
+	// The summary should be reported without a further error being reported
+	wantErrors := []string{"Could not connect to the FTP server.", "The previous error was repeated 99 more time(s)."}
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(sink.reportedErrors(), wantErrors) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if gotErrors := sink.reportedErrors(); !slices.Equal(gotErrors, wantErrors) {
+		t.Errorf("errors = %q, want %q", gotErrors, wantErrors)
+	}
+
+	// After the summary, the same error should be reported again
+	reporter.Error("Could not connect to the FTP server.")
+	wantErrors = append(wantErrors, "Could not connect to the FTP server.")
+	if gotErrors := sink.reportedErrors(); !slices.Equal(gotErrors, wantErrors) {
+		t.Errorf("errors = %q, want %q", gotErrors, wantErrors)
+	}
+}
+
+func TestDeduplicatingReporterClose(t *testing.T) {
+	sink := &tTestSink{}
+	reporter := CreateDeduplicatingReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress, time.Minute)
+	combined := MultiReporter(reporter)
+
+	for range 3 {
+		combined.Error("Could not connect to the FTP server.")
+	}
+	combined.Close()
+	combined.Close()
+
+	wantErrors := []string{"Could not connect to the FTP server.", "The previous error was repeated 2 more time(s)."}
+	if gotErrors := sink.reportedErrors(); !slices.Equal(gotErrors, wantErrors) {
+		t.Errorf("errors = %q, want %q", gotErrors, wantErrors)
+	}
+}
+
+func TestOnPanic(t *testing.T) {
+	tests := []struct {
+		name         string
+		deregistered []bool // For each observer, whether it is deregistered before panicking
+		wantObserved []int
+	}{
+		{"no observers", nil, []int{}},
+		{"all observers", []bool{false, false, false}, []int{0, 1, 2}},
+		{"deregistered observer", []bool{false, true, false}, []int{0, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &tTestSink{}
+			reporter := CreateReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress)
+
+			gotObserved := []int{}
+			for i, deregistered := range test.deregistered {
+				stopObserving := reporter.OnPanic(func() {
+					// Cleaning up happens before panicking, so after the panic has been reported
+					if len(sink.errors) == 0 {
+						t.Errorf("panic observer %d was called before the panic was reported", i)
+					}
+					gotObserved = append(gotObserved, i)
+				})
+				if deregistered {
+					stopObserving()
+				}
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Panic() did not panic")
+				}
+				if !slices.Equal(gotObserved, test.wantObserved) {
+					t.Errorf("panic observers called = %v, want %v", gotObserved, test.wantObserved)
+				}
+			}()
+			reporter.Panic("Lost the connection.")
+		})
+	}
+}
+
+func TestOnPanicDeregisteringItself(t *testing.T) {
+	reporter := CreateReporter(ProgressLevelBasic, func(string) {}, func(string) {})
+
+	// An observer may deregister itself, as the connectors do when closing while cleaning up
+	observed := 0
+	var stopObserving func()
+	stopObserving = reporter.OnPanic(func() {
+		observed++
+		stopObserving()
+	})
+
+	for range 2 {
+		func() {
+			defer func() { recover() }()
+			reporter.Panic("Lost the connection.")
+		}()
+	}
+
+	if observed != 1 {
+		t.Errorf("the panic observer was called %d time(s), want 1", observed)
+	}
+}
+
+func TestPanicValue(t *testing.T) {
+	underlying := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		panic       func(*TReporter)
+		wantMessage string
+		wantWrapped error
+	}{
+		{
+			"Panic",
+			func(r *TReporter) { r.Panic("Missing required configuration: %s", "agent") },
+			"Missing required configuration: agent", nil,
+		},
+		{
+			"PanicError",
+			func(r *TReporter) { r.PanicError("MQTT connection lost.", underlying) },
+			"MQTT connection lost. connection refused", underlying,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &tTestSink{}
+			reporter := CreateReporter(ProgressLevelBasic, sink.reportError, sink.reportProgress)
+
+			defer func() {
+				err, ok := recover().(error)
+				if !ok {
+					t.Fatalf("%s() did not panic with an error", test.name)
+				}
+				if err.Error() != test.wantMessage {
+					t.Errorf("%s() panicked with %q, want %q", test.name, err, test.wantMessage)
+				}
+				if test.wantWrapped != nil && errors.Unwrap(err) != test.wantWrapped {
+					t.Errorf("%s() panicked with %v, which does not wrap %v", test.name, err, test.wantWrapped)
+				}
+
+				// The panic is still reported
+				if len(sink.errors) == 0 {
+					t.Errorf("%s() did not report the panic", test.name)
+				}
+			}()
+			test.panic(reporter)
+		})
+	}
+}
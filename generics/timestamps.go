@@ -6,36 +6,88 @@
  *
  * This component computes unique (within the present run-time environment) timestamps.
  * The uniqueness is based on the current time up to seconds, and is combined with a counter
+ * The current time is taken from a clock, which can be replaced (e.g. by a frozen clock) for deterministic replays.
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 27.11.2025
+ * Version of: 15.10.2026
  *
  */
 
 package generics
 
 import (
+	"cmp"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 )
 
+/*
+ * Defining key constants
+ */
+
+const (
+	timestampTimeLayout = "2006-01-02-15-04-05" // The layout of the time-based part of timestamps
+)
+
+/*
+ * Defining clocks
+ */
+
+type (
+	// A clock, providing the current time
+	TClock interface {
+		Now() time.Time
+	}
+
+	// The real clock
+	tRealClock struct{}
+)
+
+// Get the current time from the real clock
+func (c tRealClock) Now() time.Time {
+	return time.Now()
+}
+
 /*
  * Defining key variables
  */
 
 var (
+	timestampClock    TClock // The clock used for the timestamps
 	timestampCounter  int    // Counter to ensure uniqueness within the same second
 	lastTimeTimestamp string // The last time-based part of the timestamp
+
+	timestampMutex sync.Mutex // Guards the clock, counter, and last time-based part, as timestamps may be taken concurrently
 )
 
 /*
  * Defining timestamp functionality
  */
 
+// Set the clock used for the timestamps; setting a nil clock restores the real clock.
+// Also resets the counter, so a fresh clock yields a fresh sequence of timestamps.
+func SetClock(clock TClock) {
+	if clock == nil {
+		clock = tRealClock{}
+	}
+
+	timestampMutex.Lock()
+	defer timestampMutex.Unlock()
+
+	timestampClock = clock
+	timestampCounter = 0
+	lastTimeTimestamp = ""
+}
+
 func GetTimestamp() string {
+	timestampMutex.Lock()
+	defer timestampMutex.Unlock()
+
 	// Getting the current time
-	CurrenTime := time.Now()
+	CurrenTime := timestampClock.Now()
 
 	// Creating the time-based part of the timestamp
 	timeTimestamp := fmt.Sprintf(
@@ -61,8 +113,51 @@ func GetTimestamp() string {
 	return fmt.Sprintf("%s-%02d", lastTimeTimestamp, timestampCounter)
 }
 
+// Get the time represented by a timestamp, and whether the string is a timestamp at all
+func TimestampTime(timestamp string) (time.Time, bool) {
+	// A timestamp consists of the time-based part, followed by "-" and the counter
+	if len(timestamp) < len(timestampTimeLayout)+2 || timestamp[len(timestampTimeLayout)] != '-' {
+		return time.Time{}, false
+	}
+
+	// Parsing the time-based part of the timestamp
+	timestampTime, err := time.ParseInLocation(timestampTimeLayout, timestamp[:len(timestampTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Returning the time
+	return timestampTime, true
+}
+
+// Compare two timestamps, returning -1, 0, or +1 depending on whether the first one is older than, the same as, or
+// newer than the second one. As the counter may grow beyond its two digits, it is compared as a number.
+// Strings that are not timestamps are compared as strings.
+func CompareTimestamps(timestamp1, timestamp2 string) int {
+	counter1, isTimestamp1 := timestampCounterOf(timestamp1)
+	counter2, isTimestamp2 := timestampCounterOf(timestamp2)
+	if !isTimestamp1 || !isTimestamp2 {
+		return cmp.Compare(timestamp1, timestamp2)
+	}
+
+	// The time-based parts have a fixed width, so they can be compared as strings
+	return cmp.Or(
+		cmp.Compare(timestamp1[:len(timestampTimeLayout)], timestamp2[:len(timestampTimeLayout)]),
+		cmp.Compare(counter1, counter2))
+}
+
+// Get the counter of a timestamp, and whether the string is a timestamp at all
+func timestampCounterOf(timestamp string) (int, bool) {
+	if _, isTimestamp := TimestampTime(timestamp); !isTimestamp {
+		return 0, false
+	}
+
+	counter, err := strconv.Atoi(timestamp[len(timestampTimeLayout)+1:])
+
+	return counter, err == nil && counter >= 0
+}
+
 // Initializing timestamp functionality
 func init() {
-	timestampCounter = 0
-	lastTimeTimestamp = ""
+	SetClock(tRealClock{})
 }
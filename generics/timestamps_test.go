@@ -0,0 +1,164 @@
+package generics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimestampTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		wantTime  time.Time
+		wantOK    bool
+	}{
+		{"timestamp", "2026-10-15-13-04-05-00", time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local), true},
+		{"larger counter", "2026-10-15-13-04-05-123", time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local), true},
+		{"empty", "", time.Time{}, false},
+		{"without counter", "2026-10-15-13-04-05", time.Time{}, false},
+		{"without counter separator", "2026-10-15-13-04-05x00", time.Time{}, false},
+		{"invalid month", "2026-13-15-13-04-05-00", time.Time{}, false},
+		{"not a timestamp", "payload.json-and-then-some", time.Time{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotTime, gotOK := TimestampTime(test.timestamp)
+			if gotOK != test.wantOK || !gotTime.Equal(test.wantTime) {
+				t.Errorf("TimestampTime(%q) = %v, %v, want %v, %v", test.timestamp, gotTime, gotOK, test.wantTime, test.wantOK)
+			}
+		})
+	}
+}
+
+// A clock that always gives the same time
+type tFixedClock struct {
+	now time.Time
+}
+
+func (c tFixedClock) Now() time.Time {
+	return c.now
+}
+
+// A clock giving the given times, one per call
+type tSteppingClock struct {
+	times []time.Time
+}
+
+func (c *tSteppingClock) Now() time.Time {
+	now := c.times[0]
+	c.times = c.times[1:]
+
+	return now
+}
+
+func TestGetTimestamp(t *testing.T) {
+	t.Cleanup(func() { SetClock(nil) })
+
+	first := time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local)
+	tests := []struct {
+		name           string
+		times          []time.Time
+		wantTimestamps []string
+	}{
+		{
+			"same second",
+			[]time.Time{first, first.Add(100 * time.Millisecond), first.Add(900 * time.Millisecond)},
+			[]string{"2026-10-15-13-04-05-00", "2026-10-15-13-04-05-01", "2026-10-15-13-04-05-02"},
+		},
+		{
+			"next second",
+			[]time.Time{first, first, first.Add(time.Second), first.Add(time.Second)},
+			[]string{"2026-10-15-13-04-05-00", "2026-10-15-13-04-05-01", "2026-10-15-13-04-06-00", "2026-10-15-13-04-06-01"},
+		},
+		{
+			"clock set back",
+			[]time.Time{first.Add(time.Second), first},
+			[]string{"2026-10-15-13-04-06-00", "2026-10-15-13-04-05-00"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setting the clock also restarts the counter
+			SetClock(&tSteppingClock{times: test.times})
+
+			for _, wantTimestamp := range test.wantTimestamps {
+				if gotTimestamp := GetTimestamp(); gotTimestamp != wantTimestamp {
+					t.Errorf("GetTimestamp() = %q, want %q", gotTimestamp, wantTimestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestSetClockRestoresRealClock(t *testing.T) {
+	SetClock(&tSteppingClock{times: []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)}})
+	GetTimestamp()
+	SetClock(nil)
+
+	before := time.Now().Truncate(time.Second)
+	gotTime, gotOK := TimestampTime(GetTimestamp())
+	if !gotOK || gotTime.Before(before) || gotTime.After(time.Now()) {
+		t.Errorf("GetTimestamp() after SetClock(nil) gave time %v, want the current time", gotTime)
+	}
+}
+
+func TestCompareTimestamps(t *testing.T) {
+	tests := []struct {
+		name        string
+		timestamp1  string
+		timestamp2  string
+		wantCompare int
+	}{
+		{"same", "2026-10-15-13-04-05-00", "2026-10-15-13-04-05-00", 0},
+		{"earlier second", "2026-10-15-13-04-05-99", "2026-10-15-13-04-06-00", -1},
+		{"later counter", "2026-10-15-13-04-05-02", "2026-10-15-13-04-05-01", 1},
+		{"counter beyond two digits", "2026-10-15-13-04-05-100", "2026-10-15-13-04-05-11", 1},
+		{"counter below three digits", "2026-10-15-13-04-05-99", "2026-10-15-13-04-05-100", -1},
+		{"empty", "", "2026-10-15-13-04-05-00", -1},
+		{"not a timestamp", "payload.json", "2026-10-15-13-04-05-00", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if gotCompare := CompareTimestamps(test.timestamp1, test.timestamp2); gotCompare != test.wantCompare {
+				t.Errorf("CompareTimestamps(%q, %q) = %d, want %d", test.timestamp1, test.timestamp2, gotCompare, test.wantCompare)
+			}
+			if gotCompare := CompareTimestamps(test.timestamp2, test.timestamp1); gotCompare != -test.wantCompare {
+				t.Errorf("CompareTimestamps(%q, %q) = %d, want %d", test.timestamp2, test.timestamp1, gotCompare, -test.wantCompare)
+			}
+		})
+	}
+}
+
+func TestGetTimestampConcurrently(t *testing.T) {
+	SetClock(tFixedClock{now: time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local)})
+	t.Cleanup(func() { SetClock(nil) })
+
+	// Take timestamps concurrently, as concurrent postings do
+	timestamps := make(chan string, 8*50)
+	wg := sync.WaitGroup{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 50 {
+				timestamps <- GetTimestamp()
+			}
+		}()
+	}
+	wg.Wait()
+	close(timestamps)
+
+	// All of them should be unique
+	seen := map[string]bool{}
+	for timestamp := range timestamps {
+		if seen[timestamp] {
+			t.Errorf("GetTimestamp() gave %q more than once", timestamp)
+		}
+		seen[timestamp] = true
+	}
+}
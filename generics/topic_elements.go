@@ -0,0 +1,60 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Generic
+ * Component: Topic Elements
+ *
+ * This component provides the sanitising of the elements of topic paths, such as artefact IDs, which are used
+ * both in MQTT topics and in paths on the FTP server. Characters that could break these (such as "/", spaces, or
+ * the MQTT wildcards) are percent-encoded, as are the "." and ".." elements that could escape the intended folder.
+ * Elements that only use safe characters remain as they are.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package generics
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+ * Sanitising topic elements
+ */
+
+// Check whether a character can be used as is in a topic element
+func isSafeTopicCharacter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// Sanitise an element of a topic path, by percent-encoding the characters that are not safe to use.
+// The "." and ".." elements are encoded completely, so they cannot refer to the current or parent folder.
+func SanitizeTopicElement(s string) string {
+	// Prevent references to the current or parent folder
+	if s == "." || s == ".." {
+		return strings.Repeat("%2E", len(s))
+	}
+
+	// Percent-encode the unsafe characters
+	sanitized := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if isSafeTopicCharacter(s[i]) {
+			sanitized.WriteByte(s[i])
+		} else {
+			fmt.Fprintf(&sanitized, "%%%02X", s[i])
+		}
+	}
+
+	return sanitized.String()
+}
+
+// Get the original of a sanitised element of a topic path, e.g. when listing the elements on the FTP server
+func UnsanitizeTopicElement(s string) (string, error) {
+	return url.PathUnescape(s)
+}
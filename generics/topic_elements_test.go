@@ -0,0 +1,55 @@
+package generics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeTopicElement(t *testing.T) {
+	tests := []struct {
+		name          string
+		element       string
+		wantSanitized string
+	}{
+		{"safe", "model-1_v2.0~draft", "model-1_v2.0~draft"},
+		{"empty", "", ""},
+		{"current folder", ".", "%2E"},
+		{"parent folder", "..", "%2E%2E"},
+		{"traversal", "../x", "..%2Fx"},
+		{"nested traversal", "a/../../b", "a%2F..%2F..%2Fb"},
+		{"slash", "a/b", "a%2Fb"},
+		{"backslash", `a\b`, "a%5Cb"},
+		{"single level wildcard", "+", "%2B"},
+		{"multi level wildcard", "#", "%23"},
+		{"spaces", "my model", "my%20model"},
+		{"percent", "100%", "100%25"},
+		{"non ASCII", "modèle", "mod%C3%A8le"},
+		{"dots inside", "a..b", "a..b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotSanitized := SanitizeTopicElement(test.element)
+			if gotSanitized != test.wantSanitized {
+				t.Errorf("SanitizeTopicElement(%q) = %q, want %q", test.element, gotSanitized, test.wantSanitized)
+			}
+
+			// The sanitised element can neither span or escape folders and topic levels, nor act as a wildcard
+			if strings.ContainsAny(gotSanitized, `/\+# `) || gotSanitized == "." || gotSanitized == ".." {
+				t.Errorf("SanitizeTopicElement(%q) = %q, which is not safe", test.element, gotSanitized)
+			}
+
+			// The original can be recovered
+			gotOriginal, err := UnsanitizeTopicElement(gotSanitized)
+			if err != nil || gotOriginal != test.element {
+				t.Errorf("UnsanitizeTopicElement(%q) = %q, %v, want %q", gotSanitized, gotOriginal, err, test.element)
+			}
+		})
+	}
+}
+
+func TestUnsanitizeTopicElementInvalid(t *testing.T) {
+	if gotOriginal, err := UnsanitizeTopicElement("100%"); err == nil {
+		t.Errorf("UnsanitizeTopicElement(%q) = %q, want an error", "100%", gotOriginal)
+	}
+}
@@ -5,13 +5,14 @@ go 1.24.0
 require (
 	github.com/eclipse/paho.mqtt.golang v1.5.1
 	github.com/evanphx/json-patch v0.5.2
+	github.com/gorilla/websocket v1.5.3
 	github.com/secsy/goftp v0.0.0-20200609142545-aa2de14babf4
 	github.com/wI2L/jsondiff v0.7.0
 	gopkg.in/ini.v1 v1.67.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/tidwall/gjson v1.18.0 // indirect
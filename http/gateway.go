@@ -0,0 +1,406 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   HTTP
+ * Component: Artefact gateway
+ *
+ * This component provides a REST gateway to the BIG Modelling Bus, allowing (web) front-ends to read the latest
+ * state, update, and considering of JSON artefacts, without having to speak MQTT/FTP. It serves:
+ *
+ *   GET /artefacts/{agentID}/{artefactID}/state
+ *   GET /artefacts/{agentID}/{artefactID}/update
+ *   GET /artefacts/{agentID}/{artefactID}/considering
+ *   GET /artefacts/{agentID}/{artefactID}/stream
+ *   GET /healthz
+ *
+ * The artefacts are served in the JSON version from the [http] json_version setting, which is required.
+ * The first request for an artefact starts listening for its postings, so the served content stays live. An artefact
+ * is served for as long as requests or WebSocket subscribers use it. When the last of these leaves, the gateway stops
+ * listening for its postings and forgets it, so artefacts (including ones that do not exist) are not kept forever.
+ * The stream endpoint upgrades to a WebSocket, on which each new state, update, and considering of the artefact
+ * is pushed as a JSON frame. The health endpoint reports the health of the connection to the bus (see HealthHandler).
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/connect"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+	"github.com/gorilla/websocket"
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	stateKind       = "state"       // Kind of content for the artefact state
+	updateKind      = "update"      // Kind of content for the artefact update
+	consideringKind = "considering" // Kind of content for the artefact considering
+
+	defaultAddress               = ":8080" // Default address to listen on
+	defaultMaxStreamSubscribers  = 100     // Default maximum number of concurrent WebSocket subscriptions
+	streamSubscriberBufferLength = 16      // Number of frames that may be queued for a WebSocket subscriber
+)
+
+// The gateway cannot serve artefacts without knowing their JSON version
+var ErrMissingJSONVersion = errors.New("missing JSON version for the served artefacts")
+
+/*
+ * Defining the gateway
+ */
+
+type (
+	// The served content of an artefact, as kept up to date by the listeners
+	tArtefactContent struct {
+		artefactConnector *connect.TModellingBusArtefactConnector // The artefact connector used to listen for postings
+		content           map[string]json.RawMessage              // The content, per kind
+		loaded            chan struct{}                           // Closed once the content has been loaded
+		stopped           chan struct{}                           // Closed once listening for its postings has stopped
+		references        int                                     // The number of requests and WebSocket subscribers using the artefact
+		subscribers       map[chan tStreamFrame]bool              // The WebSocket subscribers to new content
+	}
+
+	// A frame pushed to WebSocket subscribers
+	tStreamFrame struct {
+		Kind    string          `json:"kind"`    // The kind of content (state, update, or considering)
+		Content json.RawMessage `json:"content"` // The content itself
+	}
+
+	TArtefactGateway struct {
+		ModellingBusConnector connect.TModellingBusConnector // The modelling bus connector to be used
+		Address               string                         // The address to listen on
+		JSONVersion           string                         // The JSON version of the served artefacts
+
+		artefacts map[string]*tArtefactContent // The artefacts being served, per agent and artefact ID
+		mutex     sync.Mutex                   // Guards the artefacts being served, and their subscribers
+
+		maxStreamSubscribers int                // Maximum number of concurrent WebSocket subscriptions
+		streamSubscribers    int                // Current number of WebSocket subscriptions
+		upgrader             websocket.Upgrader // Upgrader of requests to WebSockets
+
+		server   *nethttp.Server     // The HTTP server
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+)
+
+/*
+ * Keeping track of artefacts
+ */
+
+// Get the key of an artefact
+func artefactKey(agentID, artefactID string) string {
+	return agentID + "/" + artefactID
+}
+
+// Take a snapshot of the content of an artefact connector.
+// Should only be called while holding the gateway mutex.
+func (a *tArtefactContent) snapshot() {
+	a.content[stateKind], a.content[updateKind], a.content[consideringKind] = a.artefactConnector.GetContents()
+}
+
+// Push the content of the given kind to the subscribers of an artefact.
+// Should only be called while holding the gateway mutex.
+func (a *tArtefactContent) push(kind string, reporter *generics.TReporter) {
+	frame := tStreamFrame{}
+	frame.Kind = kind
+	frame.Content = a.content[kind]
+
+	for subscriber := range a.subscribers {
+		select {
+		case subscriber <- frame:
+		default:
+			reporter.Progress(generics.ProgressLevelDetailed, "Dropped a %s frame for a slow WebSocket subscriber.", kind)
+		}
+	}
+}
+
+// Get the artefact, starting to serve it when needed.
+// Each call should be matched by a call of releaseArtefact, once the artefact is no longer used.
+func (g *TArtefactGateway) acquireArtefact(agentID, artefactID string) *tArtefactContent {
+	key := artefactKey(agentID, artefactID)
+
+	// Find the artefact, or start serving it
+	g.mutex.Lock()
+	artefact, served := g.artefacts[key]
+	for served && artefact.references == 0 {
+		// The artefact is no longer used, and listening for its postings is being stopped, so wait for that first
+		g.mutex.Unlock()
+		<-artefact.stopped
+		g.mutex.Lock()
+		artefact, served = g.artefacts[key]
+	}
+	if !served {
+		artefact = &tArtefactContent{}
+		artefact.content = map[string]json.RawMessage{}
+		artefact.loaded = make(chan struct{})
+		artefact.stopped = make(chan struct{})
+		artefact.subscribers = map[chan tStreamFrame]bool{}
+		g.artefacts[key] = artefact
+	}
+	artefact.references++
+	g.mutex.Unlock()
+
+	// Load the artefact, when we just started serving it.
+	// This is done without holding the mutex, as the listeners need the mutex to update the content.
+	if !served {
+		// Do not keep serving an artefact that failed to load
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				g.releaseArtefact(agentID, artefactID, artefact)
+				panic(recovered)
+			}
+		}()
+
+		g.loadArtefact(artefact, agentID, artefactID)
+	}
+
+	// Wait for the artefact to be loaded
+	<-artefact.loaded
+
+	return artefact
+}
+
+// Release an artefact obtained from acquireArtefact. When it is no longer used, stop serving it.
+func (g *TArtefactGateway) releaseArtefact(agentID, artefactID string, artefact *tArtefactContent) {
+	g.mutex.Lock()
+	artefact.references--
+	stillUsed := artefact.references > 0
+	g.mutex.Unlock()
+
+	if stillUsed {
+		return
+	}
+
+	// Stop listening for the postings of the artefact.
+	// This is done without holding the mutex, as the listeners may need the mutex while the subscriptions are being ended.
+	g.reporter.Progress(generics.ProgressLevelDetailed, "Stopping to serve artefact %s of agent %s.", artefactID, agentID)
+	if artefact.artefactConnector != nil {
+		artefact.artefactConnector.StopListeningForJSONArtefactPostings(agentID, artefactID)
+	}
+
+	// Forget the artefact
+	g.mutex.Lock()
+	delete(g.artefacts, artefactKey(agentID, artefactID))
+	close(artefact.stopped)
+	g.mutex.Unlock()
+}
+
+// Get the content of an artefact
+func (g *TArtefactGateway) artefactContent(agentID, artefactID, kind string) json.RawMessage {
+	artefact := g.acquireArtefact(agentID, artefactID)
+	defer g.releaseArtefact(agentID, artefactID, artefact)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return artefact.content[kind]
+}
+
+// Load the latest content of an artefact, and keep it up to date
+func (g *TArtefactGateway) loadArtefact(artefact *tArtefactContent, agentID, artefactID string) {
+	// Even when loading fails, the requests waiting for the artefact should not hang
+	defer close(artefact.loaded)
+
+	g.reporter.Progress(generics.ProgressLevelDetailed, "Starting to serve artefact %s of agent %s.", artefactID, agentID)
+
+	// Get the latest content
+	artefactConnector := connect.CreateModellingBusArtefactConnector(g.ModellingBusConnector, g.JSONVersion, artefactID)
+	artefact.artefactConnector = &artefactConnector
+	artefactConnector.GetJSONArtefactState(agentID, artefactID)
+	artefactConnector.GetJSONArtefactUpdate(agentID, artefactID)
+	artefactConnector.GetJSONArtefactConsidering(agentID, artefactID)
+
+	g.mutex.Lock()
+	artefact.snapshot()
+	g.mutex.Unlock()
+
+	// Keep the content up to date, and push it to the subscribers
+	updateSnapshot := func(kind string) func() {
+		return func() {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+
+			artefact.snapshot()
+			artefact.push(kind, g.reporter)
+		}
+	}
+	artefactConnector.ListenForJSONArtefactStatePostings(agentID, artefactID, updateSnapshot(stateKind))
+	artefactConnector.ListenForJSONArtefactUpdatePostings(agentID, artefactID, updateSnapshot(updateKind))
+	artefactConnector.ListenForJSONArtefactConsideringPostings(agentID, artefactID, updateSnapshot(consideringKind))
+}
+
+/*
+ * Serving requests
+ */
+
+// Serve the content of an artefact
+func (g *TArtefactGateway) serveArtefact(writer nethttp.ResponseWriter, request *nethttp.Request) {
+	agentID := request.PathValue("agentID")
+	artefactID := request.PathValue("artefactID")
+	kind := request.PathValue("kind")
+
+	// Check the kind of content requested
+	if kind != stateKind && kind != updateKind && kind != consideringKind {
+		g.respond(writer, request, nethttp.StatusNotFound, nil)
+		return
+	}
+
+	// The artefact has not been posted (yet)
+	content := g.artefactContent(agentID, artefactID, kind)
+	if len(content) == 0 {
+		g.respond(writer, request, nethttp.StatusNotFound, nil)
+		return
+	}
+
+	// Serve the content
+	writer.Header().Set("Content-Type", "application/json")
+	g.respond(writer, request, nethttp.StatusOK, content)
+}
+
+// Stream the content of an artefact over a WebSocket
+func (g *TArtefactGateway) streamArtefact(writer nethttp.ResponseWriter, request *nethttp.Request) {
+	agentID := request.PathValue("agentID")
+	artefactID := request.PathValue("artefactID")
+
+	// Make sure the artefact is being served, while the subscriber uses it
+	artefact := g.acquireArtefact(agentID, artefactID)
+	defer g.releaseArtefact(agentID, artefactID, artefact)
+
+	// Register the subscriber, unless there are too many already
+	subscriber := make(chan tStreamFrame, streamSubscriberBufferLength)
+	g.mutex.Lock()
+	if g.streamSubscribers >= g.maxStreamSubscribers {
+		g.mutex.Unlock()
+		g.respond(writer, request, nethttp.StatusServiceUnavailable, nil)
+		return
+	}
+	g.streamSubscribers++
+	artefact.subscribers[subscriber] = true
+	g.mutex.Unlock()
+
+	// Unregister the subscriber when done
+	defer func() {
+		g.mutex.Lock()
+		delete(artefact.subscribers, subscriber)
+		g.streamSubscribers--
+		g.mutex.Unlock()
+	}()
+
+	// Upgrade to a WebSocket
+	connection, err := g.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		g.reporter.ReportError("Error upgrading to a WebSocket:", err)
+		return
+	}
+	defer connection.Close()
+	g.reporter.Progress(generics.ProgressLevelDetailed, "HTTP %s %s: streaming", request.Method, request.URL.Path)
+
+	// Detect the client disconnecting, by reading until an error occurs
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := connection.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Push the frames, until the client disconnects
+	for {
+		select {
+		case frame := <-subscriber:
+			if err := connection.WriteJSON(frame); err != nil {
+				return
+			}
+
+		case <-disconnected:
+			return
+		}
+	}
+}
+
+// Respond to a request, and report on it
+func (g *TArtefactGateway) respond(writer nethttp.ResponseWriter, request *nethttp.Request, status int, content []byte) {
+	g.reporter.Progress(generics.ProgressLevelDetailed, "HTTP %s %s: %d", request.Method, request.URL.Path, status)
+
+	if content == nil {
+		nethttp.Error(writer, nethttp.StatusText(status), status)
+		return
+	}
+
+	writer.WriteHeader(status)
+	writer.Write(content)
+}
+
+// Get the handler for the requests served by the gateway
+func (g *TArtefactGateway) Handler() nethttp.Handler {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("GET /artefacts/{agentID}/{artefactID}/stream", g.streamArtefact)
+	mux.HandleFunc("GET /artefacts/{agentID}/{artefactID}/{kind}", g.serveArtefact)
+	mux.Handle("GET /healthz", HealthHandler(g.ModellingBusConnector))
+
+	return mux
+}
+
+/*
+ * Running the gateway
+ */
+
+// Start serving requests, until the gateway is closed
+func (g *TArtefactGateway) ListenAndServe() error {
+	if g.JSONVersion == "" {
+		g.reporter.Error("Missing JSON version. Please set [http] json_version.")
+		return ErrMissingJSONVersion
+	}
+
+	g.server = &nethttp.Server{Addr: g.Address, Handler: g.Handler()}
+
+	g.reporter.Progress(generics.ProgressLevelBasic, "Serving artefacts on: %s", g.Address)
+	err := g.server.ListenAndServe()
+	if err == nethttp.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// Stop serving requests
+func (g *TArtefactGateway) Close() error {
+	if g.server == nil {
+		return nil
+	}
+
+	return g.server.Close()
+}
+
+/*
+ * Creating gateways
+ */
+
+// Create an artefact gateway, listening on the address from the [http] address setting of the config file.
+// The [http] json_version setting gives the JSON version of the served artefacts; without it, the gateway will not serve.
+// The [http] max_stream_subscribers setting caps the number of concurrent WebSocket subscriptions.
+func CreateArtefactGateway(ModellingBusConnector connect.TModellingBusConnector, configData *generics.TConfigData, reporter *generics.TReporter) *TArtefactGateway {
+	g := TArtefactGateway{}
+
+	g.ModellingBusConnector = ModellingBusConnector
+	g.Address = configData.GetValue("http", "address").StringWithDefault(defaultAddress)
+	g.JSONVersion = configData.GetValue("http", "json_version").String()
+	g.maxStreamSubscribers = configData.GetValue("http", "max_stream_subscribers").IntWithDefault(defaultMaxStreamSubscribers)
+	g.artefacts = map[string]*tArtefactContent{}
+	g.reporter = reporter
+
+	return &g
+}
@@ -0,0 +1,420 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/erikproper/big-modelling-bus.go.v1/connect"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+	"github.com/gorilla/websocket"
+)
+
+// Create a gateway from a config file with the given content
+func createTestGateway(t *testing.T, content string) *TArtefactGateway {
+	t.Helper()
+
+	configFilePath := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(configFilePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing the config file: %v", err)
+	}
+	reporter := generics.CreateReporter(0, func(string) {}, func(string) {})
+
+	return CreateArtefactGateway(connect.TModellingBusConnector{}, generics.LoadConfig(configFilePath, reporter), reporter)
+}
+
+// Serve an artefact with the given content, as if it had been loaded from the bus.
+// The returned artefact holds a reference, so the gateway keeps serving it while the test runs.
+func serveTestArtefact(g *TArtefactGateway, agentID, artefactID string, content map[string]json.RawMessage) *tArtefactContent {
+	artefact := &tArtefactContent{}
+	artefact.content = content
+	artefact.loaded = make(chan struct{})
+	artefact.stopped = make(chan struct{})
+	artefact.subscribers = map[chan tStreamFrame]bool{}
+	artefact.references = 1
+	close(artefact.loaded)
+
+	g.mutex.Lock()
+	g.artefacts[artefactKey(agentID, artefactID)] = artefact
+	g.mutex.Unlock()
+
+	return artefact
+}
+
+// Wait until the given condition, checked while holding the gateway mutex, holds
+func waitForGateway(t *testing.T, g *TArtefactGateway, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		g.mutex.Lock()
+		holds := condition()
+		g.mutex.Unlock()
+
+		if holds {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for the gateway")
+}
+
+// A fake MQTT client, which delivers published messages to the matching subscriptions right away
+type tFakeMQTTClient struct {
+	mqtt.Client
+
+	options       *mqtt.ClientOptions
+	subscriptions map[string]mqtt.MessageHandler
+	retained      map[string][]byte // The retained messages, per topic
+	mutex         sync.Mutex
+}
+
+// A token of a completed MQTT operation
+type tFakeMQTTToken struct {
+	mqtt.Token
+}
+
+func (t tFakeMQTTToken) Wait() bool                     { return true }
+func (t tFakeMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t tFakeMQTTToken) Error() error                   { return nil }
+
+// A message delivered by the fake MQTT client
+type tFakeMQTTMessage struct {
+	mqtt.Message
+
+	topic   string
+	payload []byte
+}
+
+func (m tFakeMQTTMessage) Topic() string   { return m.topic }
+func (m tFakeMQTTMessage) Payload() []byte { return m.payload }
+
+func (c *tFakeMQTTClient) IsConnected() bool { return true }
+func (c *tFakeMQTTClient) Disconnect(uint)   {}
+
+func (c *tFakeMQTTClient) Connect() mqtt.Token {
+	if c.options.OnConnect != nil {
+		c.options.OnConnect(c)
+	}
+
+	return tFakeMQTTToken{}
+}
+
+func (c *tFakeMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	message := tFakeMQTTMessage{topic: topic, payload: []byte(fmt.Sprint(payload))}
+
+	// Get the handlers of the matching subscriptions, and call them without holding the mutex
+	c.mutex.Lock()
+	if retained {
+		c.retained[topic] = message.payload
+	}
+	handlers := []mqtt.MessageHandler{}
+	for filter, handler := range c.subscriptions {
+		if fakeMQTTTopicMatches(filter, topic) {
+			handlers = append(handlers, handler)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(c, message)
+	}
+
+	return tFakeMQTTToken{}
+}
+
+func (c *tFakeMQTTClient) Subscribe(filter string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	// Get the matching retained messages, and deliver them without holding the mutex
+	c.mutex.Lock()
+	c.subscriptions[filter] = callback
+	messages := []tFakeMQTTMessage{}
+	for topic, payload := range c.retained {
+		if fakeMQTTTopicMatches(filter, topic) {
+			messages = append(messages, tFakeMQTTMessage{topic: topic, payload: payload})
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, message := range messages {
+		callback(c, message)
+	}
+
+	return tFakeMQTTToken{}
+}
+
+func (c *tFakeMQTTClient) Unsubscribe(filters ...string) mqtt.Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, filter := range filters {
+		delete(c.subscriptions, filter)
+	}
+
+	return tFakeMQTTToken{}
+}
+
+// Check whether a topic matches a topic filter, with the "+" and "#" wildcards
+func fakeMQTTTopicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, filterLevel := range filterLevels {
+		if filterLevel == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || filterLevel != "+" && filterLevel != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+// Create a modelling bus connector that is connected to a fake MQTT client, with a gateway on top of it
+func createTestGatewayOnFakeBus(t *testing.T) (*TArtefactGateway, connect.TModellingBusConnector) {
+	t.Helper()
+
+	// Have the modelling bus connector use a fake MQTT client
+	newMQTTClient := connect.NewMQTTClient
+	connect.NewMQTTClient = func(options *mqtt.ClientOptions) mqtt.Client {
+		return &tFakeMQTTClient{options: options, subscriptions: map[string]mqtt.MessageHandler{}, retained: map[string][]byte{}}
+	}
+	t.Cleanup(func() { connect.NewMQTTClient = newMQTTClient })
+
+	// No FTP server can be reached, so small states are posted inline instead
+	content := strings.Join([]string{
+		"environment = environment",
+		"agent = agent",
+		"work_folder = " + t.TempDir(),
+		"[ftp]",
+		"server = 127.0.0.1",
+		"port = 1",
+		"password = secret",
+		"max_fallback_bytes = 1000",
+		"[mqtt]",
+		"broker = 127.0.0.1",
+		"port = 1883",
+		"load_delay = 0",
+		"[http]",
+		"json_version = 1.0",
+	}, "\n")
+	configFilePath := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(configFilePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing the config file: %v", err)
+	}
+	reporter := generics.CreateReporter(0, func(string) {}, func(string) {})
+	configData := generics.LoadConfig(configFilePath, reporter)
+
+	modellingBusConnector := connect.CreateModellingBusConnector(configData, reporter, false)
+	t.Cleanup(modellingBusConnector.Close)
+
+	return CreateArtefactGateway(modellingBusConnector, configData, reporter), modellingBusConnector
+}
+
+func TestServePostedArtefact(t *testing.T) {
+	g, modellingBusConnector := createTestGatewayOnFakeBus(t)
+	server := httptest.NewServer(g.Handler())
+	defer server.Close()
+
+	// Post the state, and an update, of an artefact on the bus
+	artefactConnector := connect.CreateModellingBusArtefactConnector(modellingBusConnector, "1.0", "model")
+	defer artefactConnector.Close()
+	if err := artefactConnector.PostJSONArtefactStateE([]byte(`{"model name":"Births"}`), true); err != nil {
+		t.Fatalf("PostJSONArtefactStateE() returned error: %v", err)
+	}
+	if err := artefactConnector.PostJSONArtefactUpdateE([]byte(`{"model name":"Births","types":["Person"]}`), true); err != nil {
+		t.Fatalf("PostJSONArtefactUpdateE() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"posted state", "/artefacts/agent/model/state", nethttp.StatusOK, `{"model name":"Births"}`},
+		{"posted update", "/artefacts/agent/model/update", nethttp.StatusOK, `{"model name":"Births","types":["Person"]}`},
+		{"other artefact", "/artefacts/agent/other/state", nethttp.StatusNotFound, ""},
+		{"other agent", "/artefacts/other/model/state", nethttp.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := nethttp.Get(server.URL + test.path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", test.path, err)
+			}
+			defer response.Body.Close()
+
+			body, _ := io.ReadAll(response.Body)
+			if response.StatusCode != test.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", test.path, response.StatusCode, test.wantStatus)
+			}
+			if test.wantBody != "" && string(body) != test.wantBody {
+				t.Errorf("GET %s body = %s, want %s", test.path, body, test.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeArtefact(t *testing.T) {
+	g := createTestGateway(t, "")
+	serveTestArtefact(g, "agent", "artefact", map[string]json.RawMessage{stateKind: json.RawMessage(`{"a":1}`)})
+	server := httptest.NewServer(g.Handler())
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"state", "/artefacts/agent/artefact/state", nethttp.StatusOK, `{"a":1}`},
+		{"not posted", "/artefacts/agent/artefact/update", nethttp.StatusNotFound, ""},
+		{"unknown kind", "/artefacts/agent/artefact/other", nethttp.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := nethttp.Get(server.URL + test.path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", test.path, err)
+			}
+			defer response.Body.Close()
+
+			body, _ := io.ReadAll(response.Body)
+			if response.StatusCode != test.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", test.path, response.StatusCode, test.wantStatus)
+			}
+			if test.wantBody != "" && string(body) != test.wantBody {
+				t.Errorf("GET %s body = %s, want %s", test.path, body, test.wantBody)
+			}
+		})
+	}
+}
+
+func TestStreamArtefact(t *testing.T) {
+	g := createTestGateway(t, "[http]\nmax_stream_subscribers = 1\n")
+	artefact := serveTestArtefact(g, "agent", "artefact", map[string]json.RawMessage{})
+	server := httptest.NewServer(g.Handler())
+	defer server.Close()
+	streamURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/artefacts/agent/artefact/stream"
+
+	// Subscribe to the artefact
+	connection, _, err := websocket.DefaultDialer.Dial(streamURL, nil)
+	if err != nil {
+		t.Fatalf("dialling the stream: %v", err)
+	}
+	defer connection.Close()
+
+	g.mutex.Lock()
+	if artefact.references != 2 || len(artefact.subscribers) != 1 {
+		t.Errorf("subscribing gave %d reference(s) and %d subscriber(s), want 2 and 1", artefact.references, len(artefact.subscribers))
+	}
+	g.mutex.Unlock()
+
+	// New content is pushed to the subscriber
+	tests := []struct {
+		kind    string
+		content string
+	}{
+		{stateKind, `{"a":2}`},
+		{updateKind, `{"a":3}`},
+		{consideringKind, `{"a":4}`},
+	}
+	for _, test := range tests {
+		g.mutex.Lock()
+		artefact.content[test.kind] = json.RawMessage(test.content)
+		artefact.push(test.kind, g.reporter)
+		g.mutex.Unlock()
+
+		frame := tStreamFrame{}
+		connection.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := connection.ReadJSON(&frame); err != nil {
+			t.Fatalf("reading a frame: %v", err)
+		}
+		if frame.Kind != test.kind || string(frame.Content) != test.content {
+			t.Errorf("frame = %s %s, want %s %s", frame.Kind, frame.Content, test.kind, test.content)
+		}
+	}
+
+	// Further subscribers are refused, as the maximum is reached
+	_, response, err := websocket.DefaultDialer.Dial(streamURL, nil)
+	if err == nil || response == nil || response.StatusCode != nethttp.StatusServiceUnavailable {
+		t.Errorf("dialling beyond the maximum gave %v, want status %d", err, nethttp.StatusServiceUnavailable)
+	}
+
+	// Disconnecting releases the subscription, as well as the artefact
+	connection.Close()
+	waitForGateway(t, g, func() bool {
+		return g.streamSubscribers == 0 && len(artefact.subscribers) == 0 && artefact.references == 1
+	})
+}
+
+func TestReleaseArtefact(t *testing.T) {
+	tests := []struct {
+		name        string
+		references  int
+		wantServed  bool
+		wantStopped bool
+	}{
+		{"still used", 2, true, false},
+		{"last reference", 1, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := createTestGateway(t, "")
+			artefact := serveTestArtefact(g, "agent", "artefact", map[string]json.RawMessage{})
+			artefact.references = test.references
+
+			g.releaseArtefact("agent", "artefact", artefact)
+
+			_, gotServed := g.artefacts[artefactKey("agent", "artefact")]
+			gotStopped := false
+			select {
+			case <-artefact.stopped:
+				gotStopped = true
+			default:
+			}
+			if gotServed != test.wantServed || gotStopped != test.wantStopped {
+				t.Errorf("releaseArtefact() served = %v, stopped = %v, want %v, %v", gotServed, gotStopped, test.wantServed, test.wantStopped)
+			}
+		})
+	}
+}
+
+func TestGatewayJSONVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantJSONVersion string
+	}{
+		{"configured", "[http]\njson_version = 1.0\naddress = 127.0.0.1:0\n", "1.0"},
+		{"missing", "[http]\naddress = 127.0.0.1:0\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := createTestGateway(t, test.content)
+			if g.JSONVersion != test.wantJSONVersion {
+				t.Errorf("JSONVersion = %q, want %q", g.JSONVersion, test.wantJSONVersion)
+			}
+
+			// Without a JSON version, the gateway should refuse to serve
+			if test.wantJSONVersion != "" {
+				return
+			}
+			if err := g.ListenAndServe(); !errors.Is(err, ErrMissingJSONVersion) {
+				t.Errorf("ListenAndServe() = %v, want %v", err, ErrMissingJSONVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   HTTP
+ * Component: Health
+ *
+ * This component provides an HTTP health check endpoint for an agent, e.g. to be served on /healthz. It responds
+ * with the health status of the agent's connection to the BIG Modelling Bus, as JSON, with status 200 when the
+ * agent is healthy, and 503 otherwise.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/connect"
+)
+
+/*
+ * Serving health checks
+ */
+
+// Get the handler for health checks of the given modelling bus connector
+func HealthHandler(ModellingBusConnector connect.TModellingBusConnector) nethttp.Handler {
+	return nethttp.HandlerFunc(func(writer nethttp.ResponseWriter, request *nethttp.Request) {
+		healthStatus := ModellingBusConnector.HealthCheck()
+
+		// Respond with the health status
+		status := nethttp.StatusOK
+		if !healthStatus.Healthy {
+			status = nethttp.StatusServiceUnavailable
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(status)
+		json.NewEncoder(writer).Encode(healthStatus)
+	})
+}
@@ -9,14 +9,17 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 15.12.2025
+ * Version of: 15.10.2026
  *
  */
 
 package cdm_v1_0_v1_0
 
 import (
+	"bytes"
 	"encoding/json"
+	"maps"
+	"slices"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/connect"
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
@@ -52,6 +55,9 @@ type (
 		// For posting of, and listening to, model updates on the modelling bus
 		ModelListener connect.TModellingBusArtefactConnector `json:"-"` // The Modelling Bus Artefact Poster used to listen for updates of the model
 
+		// For generating element IDs
+		newID func() string // Generates new element IDs (nil means timestamps are used)
+
 		// General properties for the model
 		ModelName       string `json:"model name"` // The name of the model
 		InstanceIDCount int    `json:"-"`          // The counter for instance IDs
@@ -77,6 +83,16 @@ type (
 		AlternativeReadingsOfRelationType map[string]map[string]bool  `json:"alternative readings of relation types"` // The alternative readings of each relation type
 		PrimaryReadingOfRelationType      map[string]string           `json:"primary readings of relation types"`     // The primary reading of each relation type
 		ReadingDefinition                 map[string]TRelationReading `json:"reading definition"`                     // The definition of each relation type reading
+
+		// For subtyping
+		// Note: this field was added later on. As it is additive, models posted without it still load (without subtypings).
+		SupertypesOfType map[string]map[string]bool `json:"supertypes of types"` // The (direct) supertypes of each type
+
+		// For uniqueness constraints
+		// Note: these fields were added later on. As they are additive, models posted without them still load (without constraints).
+		UniquenessConstraints                  map[string]bool            `json:"uniqueness constraints"`                      // The uniqueness constraints
+		RelationTypeOfUniquenessConstraint     map[string]string          `json:"relation types of uniqueness constraints"`    // The relation type of each uniqueness constraint
+		InvolvementTypesOfUniquenessConstraint map[string]map[string]bool `json:"involvement types of uniqueness constraints"` // The involvement types of each uniqueness constraint
 	}
 )
 
@@ -84,6 +100,143 @@ type (
  * Converting JSON to models and back
  */
 
+type (
+	// A field of a JSON object, with its (JSON) value
+	tJSONField struct {
+		name  string          // The name of the field
+		value json.RawMessage // The value of the field
+	}
+)
+
+// Marshalling the given fields to a JSON object, keeping the fields in the given order
+func marshalOrderedFields(fields []tJSONField) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	buffer.WriteString("{")
+	for index, field := range fields {
+		nameJSON, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if index > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.Write(nameJSON)
+		buffer.WriteString(":")
+		buffer.Write(field.value)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+// Marshalling a value to JSON
+func marshalValue[V any](value V) (json.RawMessage, error) {
+	return json.Marshal(value)
+}
+
+// Marshalling a mapping to a JSON object, with its entries ordered by their keys, and a nil mapping as an empty object
+func marshalOrderedMap[V any](mapping map[string]V, marshalEntry func(V) (json.RawMessage, error)) (json.RawMessage, error) {
+	fields := []tJSONField{}
+	for _, key := range slices.Sorted(maps.Keys(mapping)) {
+		value, err := marshalEntry(mapping[key])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, tJSONField{name: key, value: value})
+	}
+
+	return marshalOrderedFields(fields)
+}
+
+// Marshalling a set of IDs to a JSON object, with its IDs ordered
+func marshalOrderedIDSet(idSet map[string]bool) (json.RawMessage, error) {
+	return marshalOrderedMap(idSet, marshalValue[bool])
+}
+
+// Marshalling a mapping from IDs to sets of IDs to a JSON object, with all IDs ordered
+func marshalOrderedIDSets(idSets map[string]map[string]bool) (json.RawMessage, error) {
+	return marshalOrderedMap(idSets, marshalOrderedIDSet)
+}
+
+// Marshalling a relation type reading to JSON, where nil slices become empty arrays
+func (r TRelationReading) MarshalJSON() ([]byte, error) {
+	involvementTypesJSON, err := json.Marshal(append([]string{}, r.InvolvementTypes...))
+	if err != nil {
+		return nil, err
+	}
+
+	readingElementsJSON, err := json.Marshal(append([]string{}, r.ReadingElements...))
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalOrderedFields([]tJSONField{
+		{name: "involvement types", value: involvementTypesJSON},
+		{name: "reading elements", value: readingElementsJSON},
+	})
+}
+
+// Marshalling the model to JSON, with its fields in the order of their definition, and the entries of its mappings
+// ordered by their keys (where nil mappings become empty objects). This way, equal models always have the same JSON,
+// so no deltas are posted between them.
+func (m TCDMModel) MarshalJSON() ([]byte, error) {
+	fields := []tJSONField{}
+	var err error
+
+	// Adding a field, unless an error occurred before
+	addField := func(name string, marshal func() (json.RawMessage, error)) {
+		if err != nil {
+			return
+		}
+
+		var value json.RawMessage
+		value, err = marshal()
+		fields = append(fields, tJSONField{name: name, value: value})
+	}
+
+	// The fields, in the order of their definition
+	addField("model name", func() (json.RawMessage, error) { return json.Marshal(m.ModelName) })
+	addField("type names", func() (json.RawMessage, error) { return marshalOrderedMap(m.TypeName, marshalValue[string]) })
+	addField("concrete individual types", func() (json.RawMessage, error) { return marshalOrderedIDSet(m.ConcreteIndividualTypes) })
+	addField("quality types", func() (json.RawMessage, error) { return marshalOrderedIDSet(m.QualityTypes) })
+	addField("domains of quality types", func() (json.RawMessage, error) { return marshalOrderedMap(m.DomainOfQualityType, marshalValue[string]) })
+	addField("involvement types", func() (json.RawMessage, error) { return marshalOrderedIDSet(m.InvolvementTypes) })
+	addField("base types of involvement types", func() (json.RawMessage, error) {
+		return marshalOrderedMap(m.BaseTypeOfInvolvementType, marshalValue[string])
+	})
+	addField("relation types of involvement types", func() (json.RawMessage, error) {
+		return marshalOrderedMap(m.RelationTypeOfInvolvementType, marshalValue[string])
+	})
+	addField("relation types", func() (json.RawMessage, error) { return marshalOrderedIDSet(m.RelationTypes) })
+	addField("involvement types of relation types", func() (json.RawMessage, error) {
+		return marshalOrderedIDSets(m.InvolvementTypesOfRelationType)
+	})
+	addField("alternative readings of relation types", func() (json.RawMessage, error) {
+		return marshalOrderedIDSets(m.AlternativeReadingsOfRelationType)
+	})
+	addField("primary readings of relation types", func() (json.RawMessage, error) {
+		return marshalOrderedMap(m.PrimaryReadingOfRelationType, marshalValue[string])
+	})
+	addField("reading definition", func() (json.RawMessage, error) {
+		return marshalOrderedMap(m.ReadingDefinition, marshalValue[TRelationReading])
+	})
+	addField("supertypes of types", func() (json.RawMessage, error) { return marshalOrderedIDSets(m.SupertypesOfType) })
+	addField("uniqueness constraints", func() (json.RawMessage, error) { return marshalOrderedIDSet(m.UniquenessConstraints) })
+	addField("relation types of uniqueness constraints", func() (json.RawMessage, error) {
+		return marshalOrderedMap(m.RelationTypeOfUniquenessConstraint, marshalValue[string])
+	})
+	addField("involvement types of uniqueness constraints", func() (json.RawMessage, error) {
+		return marshalOrderedIDSets(m.InvolvementTypesOfUniquenessConstraint)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalOrderedFields(fields)
+}
+
 // Converting the model to JSON
 func (m *TCDMModel) GetModelAsJSON() (json.RawMessage, bool) {
 	// Converting the model to JSON
@@ -97,9 +250,25 @@ func (m *TCDMModel) GetModelAsJSON() (json.RawMessage, bool) {
 	return json, true
 }
 
+// Estimating the size (in bytes) of the state of the model when it is posted, or 0 when it cannot be converted to JSON
+func (m *TCDMModel) EstimateStateSize() int {
+	modelJSON, ok := m.GetModelAsJSON()
+	if !ok {
+		return 0
+	}
+
+	return generics.PostingSize(modelJSON)
+}
+
 // Converting the JSON to the model
 func (m *TCDMModel) SetModelFromJSON(modelJSON json.RawMessage) bool {
 	m.Clean()
+
+	// Check the structure of the JSON first, as unmarshalling silently ignores wrongly named fields
+	if !m.reportJSONProblems(modelJSON) {
+		return false
+	}
+
 	err := json.Unmarshal(modelJSON, m)
 
 	// Handle potential errors
@@ -110,16 +279,36 @@ func (m *TCDMModel) SetModelFromJSON(modelJSON json.RawMessage) bool {
 	return true
 }
 
+// Reporting the problems, if any, with the structure of the JSON of a model
+func (m *TCDMModel) reportJSONProblems(modelJSON json.RawMessage) bool {
+	problems := ValidateCDMJSON(modelJSON)
+	for _, problem := range problems {
+		m.reporter.Error("Invalid %s model JSON: %s", ModelJSONVersion, problem)
+	}
+
+	return len(problems) == 0
+}
+
 /*
  * Functionality related to the CDM model
  */
 
 // Generating a new element ID
 func (m *TCDMModel) NewElementID() string {
+	// Generating a new element ID using the ID generator, if set
+	if m.newID != nil {
+		return m.newID()
+	}
+
 	// Generating a new element ID based on timestamps
 	return generics.GetTimestamp()
 }
 
+// Generating new element IDs using the connector's (agent prefixed) IDs, so that the IDs of elements created by different agents never collide
+func (m *TCDMModel) UseAgentElementIDs(ModellingBusConnector connect.TModellingBusConnector) {
+	m.newID = ModellingBusConnector.GetNewID
+}
+
 // Setting the model name
 func (m *TCDMModel) SetModelName(name string) {
 	// Setting the model name
@@ -224,6 +413,142 @@ func (m *TCDMModel) AddRelationTypeReading(relationType string, stringsAndInvolv
 	return readingID
 }
 
+// Checking whether a type exists in the model
+func (m *TCDMModel) isType(id string) bool {
+	_, exists := m.TypeName[id]
+
+	return exists
+}
+
+// Adding a subtyping, making subtype a subtype of supertype
+func (m *TCDMModel) AddSubtyping(subtype, supertype string) bool {
+	// Both types should exist
+	if !m.isType(subtype) || !m.isType(supertype) {
+		m.reporter.Error("Cannot add subtyping of %s to %s, as these should both be existing types.", subtype, supertype)
+		return false
+	}
+
+	// Adding the subtyping
+	if m.SupertypesOfType[subtype] == nil {
+		m.SupertypesOfType[subtype] = map[string]bool{}
+	}
+	m.SupertypesOfType[subtype][supertype] = true
+
+	return true
+}
+
+// Adding a uniqueness constraint on the given involvement types of a relation type.
+// For example, a uniqueness constraint on the Person involvement of "Person born on Date" expresses that each
+// Person has at most one birth Date.
+func (m *TCDMModel) AddUniquenessConstraint(relationType string, involvementTypes ...string) string {
+	// The relation type should exist
+	if !m.RelationTypes[relationType] {
+		m.reporter.Error("Cannot add a uniqueness constraint to %s, as it is not a relation type.", relationType)
+		return ""
+	}
+
+	// The involvement types should belong to the relation type
+	if len(involvementTypes) == 0 {
+		m.reporter.Error("Cannot add a uniqueness constraint to %s without involvement types.", relationType)
+		return ""
+	}
+	for _, involvementType := range involvementTypes {
+		if !m.InvolvementTypesOfRelationType[relationType][involvementType] {
+			m.reporter.Error("Cannot add a uniqueness constraint to %s, as %s is not one of its involvement types.", relationType, involvementType)
+			return ""
+		}
+	}
+
+	// Settings things up for a new uniqueness constraint
+	id := m.NewElementID()
+	m.UniquenessConstraints[id] = true
+	m.RelationTypeOfUniquenessConstraint[id] = relationType
+	m.InvolvementTypesOfUniquenessConstraint[id] = map[string]bool{}
+	for _, involvementType := range involvementTypes {
+		m.InvolvementTypesOfUniquenessConstraint[id][involvementType] = true
+	}
+
+	// Return the new constraint ID
+	return id
+}
+
+// Checking whether a type is (directly or indirectly) a subtype of itself, using the given types as "being visited"
+func (m *TCDMModel) hasSubtypingCycle(id string, visiting, visited map[string]bool) bool {
+	// Cycle found
+	if visiting[id] {
+		return true
+	}
+
+	// Already checked
+	if visited[id] {
+		return false
+	}
+
+	// Check the supertypes
+	visiting[id] = true
+	for supertype, isSupertype := range m.SupertypesOfType[id] {
+		if isSupertype && m.hasSubtypingCycle(supertype, visiting, visited) {
+			return true
+		}
+	}
+	visiting[id] = false
+	visited[id] = true
+
+	return false
+}
+
+/*
+ * Validating CDM models
+ */
+
+// Validating the model, reporting the problems found
+func (m *TCDMModel) Validate() bool {
+	valid := true
+
+	// Subtypings should be between existing types
+	for subtype, supertypes := range m.SupertypesOfType {
+		for supertype := range supertypes {
+			if !m.isType(subtype) || !m.isType(supertype) {
+				m.reporter.Error("Subtyping of %s to %s involves an unknown type.", subtype, supertype)
+				valid = false
+			}
+		}
+	}
+
+	// Subtypings should not be cyclic
+	visited := map[string]bool{}
+	for subtype := range m.SupertypesOfType {
+		if m.hasSubtypingCycle(subtype, map[string]bool{}, visited) {
+			m.reporter.Error("Type %s is part of a subtyping cycle.", subtype)
+			valid = false
+		}
+	}
+
+	// Uniqueness constraints should span involvement types of their relation type
+	for constraint := range m.UniquenessConstraints {
+		relationType := m.RelationTypeOfUniquenessConstraint[constraint]
+		if !m.RelationTypes[relationType] {
+			m.reporter.Error("Uniqueness constraint %s is not on a known relation type.", constraint)
+			valid = false
+			continue
+		}
+
+		if len(m.InvolvementTypesOfUniquenessConstraint[constraint]) == 0 {
+			m.reporter.Error("Uniqueness constraint %s has no involvement types.", constraint)
+			valid = false
+		}
+
+		for involvementType := range m.InvolvementTypesOfUniquenessConstraint[constraint] {
+			if !m.InvolvementTypesOfRelationType[relationType][involvementType] {
+				m.reporter.Error("Uniqueness constraint %s involves %s, which is not an involvement type of %s.", constraint, involvementType, relationType)
+				valid = false
+			}
+		}
+	}
+
+	return valid
+}
+
 /*
  * Creating & cleaning CDM models
  */
@@ -244,6 +569,109 @@ func (m *TCDMModel) Clean() {
 	m.AlternativeReadingsOfRelationType = map[string]map[string]bool{}
 	m.PrimaryReadingOfRelationType = map[string]string{}
 	m.ReadingDefinition = map[string]TRelationReading{}
+	m.SupertypesOfType = map[string]map[string]bool{}
+	m.UniquenessConstraints = map[string]bool{}
+	m.RelationTypeOfUniquenessConstraint = map[string]string{}
+	m.InvolvementTypesOfUniquenessConstraint = map[string]map[string]bool{}
+}
+
+// Copying a set of IDs
+func copyIDSet(idSet map[string]bool) map[string]bool {
+	result := map[string]bool{}
+	for id, included := range idSet {
+		result[id] = included
+	}
+
+	return result
+}
+
+// Copying a mapping from IDs to strings
+func copyIDStrings(idStrings map[string]string) map[string]string {
+	result := map[string]string{}
+	for id, value := range idStrings {
+		result[id] = value
+	}
+
+	return result
+}
+
+// Copying a mapping from IDs to sets of IDs
+func copyIDSets(idSets map[string]map[string]bool) map[string]map[string]bool {
+	result := map[string]map[string]bool{}
+	for id, idSet := range idSets {
+		result[id] = copyIDSet(idSet)
+	}
+
+	return result
+}
+
+// Cloning a CDM model, so that mutating the clone does not affect the original model
+func (m TCDMModel) Clone() TCDMModel {
+	// Start with a shallow copy, which takes care of the reporter, model listener, and the other non-map fields
+	clone := m
+
+	// Deep copy the maps
+	clone.ConcreteIndividualTypes = copyIDSet(m.ConcreteIndividualTypes)
+	clone.QualityTypes = copyIDSet(m.QualityTypes)
+	clone.RelationTypes = copyIDSet(m.RelationTypes)
+	clone.InvolvementTypes = copyIDSet(m.InvolvementTypes)
+	clone.TypeName = copyIDStrings(m.TypeName)
+	clone.DomainOfQualityType = copyIDStrings(m.DomainOfQualityType)
+	clone.BaseTypeOfInvolvementType = copyIDStrings(m.BaseTypeOfInvolvementType)
+	clone.RelationTypeOfInvolvementType = copyIDStrings(m.RelationTypeOfInvolvementType)
+	clone.InvolvementTypesOfRelationType = copyIDSets(m.InvolvementTypesOfRelationType)
+	clone.AlternativeReadingsOfRelationType = copyIDSets(m.AlternativeReadingsOfRelationType)
+	clone.PrimaryReadingOfRelationType = copyIDStrings(m.PrimaryReadingOfRelationType)
+	clone.SupertypesOfType = copyIDSets(m.SupertypesOfType)
+	clone.UniquenessConstraints = copyIDSet(m.UniquenessConstraints)
+	clone.RelationTypeOfUniquenessConstraint = copyIDStrings(m.RelationTypeOfUniquenessConstraint)
+	clone.InvolvementTypesOfUniquenessConstraint = copyIDSets(m.InvolvementTypesOfUniquenessConstraint)
+
+	// Deep copy the readings, including their slices
+	clone.ReadingDefinition = map[string]TRelationReading{}
+	for id, reading := range m.ReadingDefinition {
+		clonedReading := TRelationReading{}
+		clonedReading.InvolvementTypes = append([]string{}, reading.InvolvementTypes...)
+		clonedReading.ReadingElements = append([]string{}, reading.ReadingElements...)
+		clone.ReadingDefinition[id] = clonedReading
+	}
+
+	// Return the clone
+	return clone
+}
+
+// Comparing two mappings from IDs to sets of IDs
+func equalIDSets(idSets1, idSets2 map[string]map[string]bool) bool {
+	return maps.EqualFunc(idSets1, idSets2, func(idSet1, idSet2 map[string]bool) bool {
+		return maps.Equal(idSet1, idSet2)
+	})
+}
+
+// Comparing two relation type readings, where the order of their elements matters
+func equalReadings(reading1, reading2 TRelationReading) bool {
+	return slices.Equal(reading1.InvolvementTypes, reading2.InvolvementTypes) &&
+		slices.Equal(reading1.ReadingElements, reading2.ReadingElements)
+}
+
+// Comparing two CDM models on all their semantic fields, ignoring the reporter, model listener, and instance ID counter
+func (m TCDMModel) Equal(other TCDMModel) bool {
+	return m.ModelName == other.ModelName &&
+		maps.Equal(m.ConcreteIndividualTypes, other.ConcreteIndividualTypes) &&
+		maps.Equal(m.QualityTypes, other.QualityTypes) &&
+		maps.Equal(m.RelationTypes, other.RelationTypes) &&
+		maps.Equal(m.InvolvementTypes, other.InvolvementTypes) &&
+		maps.Equal(m.TypeName, other.TypeName) &&
+		maps.Equal(m.DomainOfQualityType, other.DomainOfQualityType) &&
+		maps.Equal(m.BaseTypeOfInvolvementType, other.BaseTypeOfInvolvementType) &&
+		maps.Equal(m.RelationTypeOfInvolvementType, other.RelationTypeOfInvolvementType) &&
+		equalIDSets(m.InvolvementTypesOfRelationType, other.InvolvementTypesOfRelationType) &&
+		equalIDSets(m.AlternativeReadingsOfRelationType, other.AlternativeReadingsOfRelationType) &&
+		maps.Equal(m.PrimaryReadingOfRelationType, other.PrimaryReadingOfRelationType) &&
+		maps.EqualFunc(m.ReadingDefinition, other.ReadingDefinition, equalReadings) &&
+		equalIDSets(m.SupertypesOfType, other.SupertypesOfType) &&
+		maps.Equal(m.UniquenessConstraints, other.UniquenessConstraints) &&
+		maps.Equal(m.RelationTypeOfUniquenessConstraint, other.RelationTypeOfUniquenessConstraint) &&
+		equalIDSets(m.InvolvementTypesOfUniquenessConstraint, other.InvolvementTypesOfUniquenessConstraint)
 }
 
 // Creating a new CDM model
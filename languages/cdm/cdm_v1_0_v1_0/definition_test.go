@@ -0,0 +1,195 @@
+package cdm_v1_0_v1_0
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestClone(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(m *TCDMModel)
+	}{
+		{"model name", func(m *TCDMModel) { m.ModelName = "Deaths" }},
+		{"type name", func(m *TCDMModel) { m.TypeName["id-1"] = "Human" }},
+		{"concrete individual types", func(m *TCDMModel) { delete(m.ConcreteIndividualTypes, "id-1") }},
+		{"quality types", func(m *TCDMModel) { m.QualityTypes["id-9"] = true }},
+		{"domains", func(m *TCDMModel) { m.DomainOfQualityType["id-2"] = "timestamp" }},
+		{"base types", func(m *TCDMModel) { m.BaseTypeOfInvolvementType["id-3"] = "id-2" }},
+		{"involvement types of relation types", func(m *TCDMModel) { m.InvolvementTypesOfRelationType["id-5"]["id-9"] = true }},
+		{"alternative readings", func(m *TCDMModel) { delete(m.AlternativeReadingsOfRelationType["id-5"], "id-6") }},
+		{"reading elements", func(m *TCDMModel) { m.ReadingDefinition["id-6"].ReadingElements[1] = "died on" }},
+		{"reading involvement types", func(m *TCDMModel) { m.ReadingDefinition["id-6"].InvolvementTypes[0] = "id-4" }},
+		{"supertypes", func(m *TCDMModel) { m.SupertypesOfType["id-1"] = map[string]bool{"id-2": true} }},
+		{"uniqueness constraints", func(m *TCDMModel) { m.InvolvementTypesOfUniquenessConstraint["id-7"]["id-4"] = true }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := createBirthsModel()
+			clone := original.Clone()
+			if !clone.Equal(original) {
+				t.Fatalf("Clone() is not equal to the original")
+			}
+
+			// Mutating the clone should leave the original as it was
+			test.mutate(&clone)
+			if !original.Equal(createBirthsModel()) {
+				t.Errorf("mutating the clone changed the original")
+			}
+			if clone.Equal(original) {
+				t.Errorf("mutating the clone did not change the clone")
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(m *TCDMModel)
+		wantEqual bool
+	}{
+		{"same model", func(m *TCDMModel) {}, true},
+		{"different instance ID count", func(m *TCDMModel) { m.InstanceIDCount = 42 }, true},
+		{"different reporter", func(m *TCDMModel) { m.reporter = nil }, true},
+		{"excluded set member", func(m *TCDMModel) { m.QualityTypes["id-9"] = false }, false},
+		{"different model name", func(m *TCDMModel) { m.ModelName = "Deaths" }, false},
+		{"different type name", func(m *TCDMModel) { m.TypeName["id-1"] = "Human" }, false},
+		{"additional relation type", func(m *TCDMModel) { m.RelationTypes["id-9"] = true }, false},
+		{"different reading order", func(m *TCDMModel) {
+			m.ReadingDefinition["id-6"] = TRelationReading{
+				InvolvementTypes: []string{"id-4", "id-3"},
+				ReadingElements:  []string{"", "born on", ""},
+			}
+		}, false},
+		{"additional supertype", func(m *TCDMModel) { m.SupertypesOfType["id-1"] = map[string]bool{"id-2": true} }, false},
+		{"different uniqueness constraint", func(m *TCDMModel) { m.InvolvementTypesOfUniquenessConstraint["id-7"] = map[string]bool{"id-4": true} }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := createBirthsModel()
+			test.change(&m)
+
+			if gotEqual := m.Equal(createBirthsModel()); gotEqual != test.wantEqual {
+				t.Errorf("Equal() = %v, want %v", gotEqual, test.wantEqual)
+			}
+			if gotEqual := createBirthsModel().Equal(m); gotEqual != test.wantEqual {
+				t.Errorf("Equal() the other way around = %v, want %v", gotEqual, test.wantEqual)
+			}
+		})
+	}
+}
+
+func TestNewElementID(t *testing.T) {
+	generics.SetClock(tFixedClock{now: time.Date(2026, 10, 15, 13, 4, 5, 0, time.Local)})
+	t.Cleanup(func() { generics.SetClock(nil) })
+
+	tests := []struct {
+		name    string
+		newID   func() string
+		wantIDs []string
+	}{
+		{"timestamps", nil, []string{"2026-10-15-13-04-05-00", "2026-10-15-13-04-05-01"}},
+		{"agent prefixed", func() string { return "modeller-" + generics.GetTimestamp() },
+			[]string{"modeller-2026-10-15-13-04-05-02", "modeller-2026-10-15-13-04-05-03"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := CreateCDMModel(generics.CreateReporter(0, func(string) {}, func(string) {}))
+			m.newID = test.newID
+
+			for _, wantID := range test.wantIDs {
+				if gotID := m.NewElementID(); gotID != wantID {
+					t.Errorf("NewElementID() = %q, want %q", gotID, wantID)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalJSONDeterministic(t *testing.T) {
+	modelJSON, err := json.Marshal(createBirthsModel())
+	if err != nil {
+		t.Fatalf("MarshalJSON(): %v", err)
+	}
+
+	// Models that are equal, but whose maps were built up differently
+	tests := []struct {
+		name  string
+		model func() TCDMModel
+	}{
+		{"same model", createBirthsModel},
+		{"clone", func() TCDMModel { return createBirthsModel().Clone() }},
+		{"read from JSON", func() TCDMModel {
+			m := createTestModel()
+			m.SetModelFromJSON(modelJSON)
+
+			return m
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for range 10 {
+				gotJSON, err := json.Marshal(test.model())
+				if err != nil {
+					t.Fatalf("MarshalJSON(): %v", err)
+				}
+				if string(gotJSON) != string(modelJSON) {
+					t.Fatalf("MarshalJSON() =\n%s\nwant\n%s", gotJSON, modelJSON)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalJSONFieldOrder(t *testing.T) {
+	// Nil mappings become empty objects, and the fields are in the order of their definition
+	wantJSON := `{"model name":"","type names":{},"concrete individual types":{},"quality types":{},` +
+		`"domains of quality types":{},"involvement types":{},"base types of involvement types":{},` +
+		`"relation types of involvement types":{},"relation types":{},"involvement types of relation types":{},` +
+		`"alternative readings of relation types":{},"primary readings of relation types":{},"reading definition":{},` +
+		`"supertypes of types":{},"uniqueness constraints":{},"relation types of uniqueness constraints":{},` +
+		`"involvement types of uniqueness constraints":{}}`
+
+	gotJSON, err := json.Marshal(TCDMModel{})
+	if err != nil {
+		t.Fatalf("MarshalJSON(): %v", err)
+	}
+	if string(gotJSON) != wantJSON {
+		t.Errorf("MarshalJSON() =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestRelationReadingMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		reading  TRelationReading
+		wantJSON string
+	}{
+		{"empty reading", TRelationReading{}, `{"involvement types":[],"reading elements":[]}`},
+		{
+			"reading",
+			TRelationReading{InvolvementTypes: []string{"id-3", "id-4"}, ReadingElements: []string{"", "born on", ""}},
+			`{"involvement types":["id-3","id-4"],"reading elements":["","born on",""]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotJSON, err := json.Marshal(test.reading)
+			if err != nil {
+				t.Fatalf("MarshalJSON(): %v", err)
+			}
+			if string(gotJSON) != test.wantJSON {
+				t.Errorf("MarshalJSON() = %s, want %s", gotJSON, test.wantJSON)
+			}
+		})
+	}
+}
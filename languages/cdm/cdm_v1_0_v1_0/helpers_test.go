@@ -0,0 +1,59 @@
+package cdm_v1_0_v1_0
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Test helpers
+ */
+
+// Create an empty model, whose element IDs are numbered ("id-1", "id-2", ...), so tests do not depend on timestamps
+func createTestModel() TCDMModel {
+	m := CreateCDMModel(generics.CreateReporter(0, func(string) {}, func(string) {}))
+
+	count := 0
+	m.newID = func() string {
+		count++
+
+		return fmt.Sprintf("id-%d", count)
+	}
+
+	return m
+}
+
+// Create a model of persons being born on dates, with the IDs:
+//
+//	id-1: Person (concrete individual type)
+//	id-2: Date (quality type)
+//	id-3: born (involvement type of Person)
+//	id-4: on (involvement type of Date)
+//	id-5: Birth (relation type)
+//	id-6: "born on" (reading of Birth)
+//	id-7: each Person is born on one Date (uniqueness constraint)
+func createBirthsModel() TCDMModel {
+	m := createTestModel()
+	m.SetModelName("Births")
+
+	person := m.AddConcreteIndividualType("Person")
+	date := m.AddQualityType("Date", "date")
+	born := m.AddInvolvementType("born", person)
+	on := m.AddInvolvementType("on", date)
+	birth := m.AddRelationType("Birth", born, on)
+	m.AddRelationTypeReading(birth, "", born, "born on", on, "")
+	m.AddUniquenessConstraint(birth, born)
+
+	return m
+}
+
+// A clock that always gives the same time
+type tFixedClock struct {
+	now time.Time
+}
+
+func (c tFixedClock) Now() time.Time {
+	return c.now
+}
@@ -11,13 +11,16 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 15.12.2025
+ * Version of: 15.10.2026
  *
  */
 
 package cdm_v1_0_v1_0
 
 import (
+	"maps"
+	"sync"
+
 	"github.com/erikproper/big-modelling-bus.go.v1/connect"
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
@@ -33,7 +36,28 @@ type (
 		CurrentModel    TCDMModel
 		UpdatedModel    TCDMModel
 		ConsideredModel TCDMModel
+
+		subscriptions      []tCDMSubscription // The subscriptions registered by the listener, to be re-established after a reconnect
+		subscriptionsMutex *sync.Mutex        // Guards the subscriptions
 	}
+
+	// A subscription of the model listener
+	tCDMSubscription struct {
+		agentID string // The agent posting the model
+		modelID string // The model listened to
+		channel string // The channel listened to (state, update, or considering)
+		handler func() // The handler to be called
+	}
+)
+
+/*
+ * Defining constants
+ */
+
+const (
+	stateChannel       = "state"       // Listening to model states
+	updateChannel      = "update"      // Listening to model updates
+	consideringChannel = "considering" // Listening to model considerings
 )
 
 /*
@@ -42,36 +66,80 @@ type (
 
 // Updating all models from the modelling bus
 func (l *TCDMModelListener) UpdateModelsFromBus() {
-	l.CurrentModel.SetModelFromJSON(l.ModelListener.CurrentContent)
-	l.UpdatedModel.SetModelFromJSON(l.ModelListener.UpdatedContent)
-	l.ConsideredModel.SetModelFromJSON(l.ModelListener.ConsideredContent)
+	currentContent, updatedContent, consideredContent := l.ModelListener.GetContents()
+	l.CurrentModel.SetModelFromJSON(currentContent)
+	l.UpdatedModel.SetModelFromJSON(updatedContent)
+	l.ConsideredModel.SetModelFromJSON(consideredContent)
 }
 
-// Listening for model state postings on the modelling bus
-func (l *TCDMModelListener) ListenForModelStatePostings(agentID, modelID string, handler func()) {
-	// Setting up listening for model state postings
-	l.ModelListener.ListenForJSONArtefactStatePostings(agentID, modelID, func() {
+// Setting whether postings of our own agent should be ignored, so a modeller does not react to their own edits
+func (l *TCDMModelListener) SetIgnoreOwnPostings(ignore bool) {
+	l.ModelListener.ModellingBusConnector.SetIgnoreOwnPostings(ignore)
+}
+
+// Setting up listening for postings on one of the channels of a model
+func (l *TCDMModelListener) listenOn(subscription tCDMSubscription) {
+	modelHandler := func() {
 		l.UpdateModelsFromBus()
-		handler()
-	})
+		subscription.handler()
+	}
+
+	switch subscription.channel {
+	case stateChannel:
+		l.ModelListener.ListenForJSONArtefactStatePostings(subscription.agentID, subscription.modelID, modelHandler)
+	case updateChannel:
+		l.ModelListener.ListenForJSONArtefactUpdatePostings(subscription.agentID, subscription.modelID, modelHandler)
+	case consideringChannel:
+		l.ModelListener.ListenForJSONArtefactConsideringPostings(subscription.agentID, subscription.modelID, modelHandler)
+	}
+}
+
+// Registering a subscription, and setting up listening for it
+func (l *TCDMModelListener) subscribe(agentID, modelID, channel string, handler func()) {
+	subscription := tCDMSubscription{}
+	subscription.agentID = agentID
+	subscription.modelID = modelID
+	subscription.channel = channel
+	subscription.handler = handler
+
+	// Remember the subscription, and make sure the subscriptions are re-established after a reconnect
+	l.subscriptionsMutex.Lock()
+	if len(l.subscriptions) == 0 {
+		l.ModelListener.ModellingBusConnector.OnReconnect(l.resubscribe)
+	}
+	l.subscriptions = append(l.subscriptions, subscription)
+	l.subscriptionsMutex.Unlock()
+
+	l.listenOn(subscription)
+}
+
+// Re-establishing the subscriptions, e.g. after a reconnect
+func (l *TCDMModelListener) resubscribe() {
+	l.subscriptionsMutex.Lock()
+	subscriptions := append([]tCDMSubscription{}, l.subscriptions...)
+	l.subscriptionsMutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		l.listenOn(subscription)
+	}
+}
+
+// Listening for model state postings on the modelling bus.
+// After a reconnect, the listening is re-established automatically.
+func (l *TCDMModelListener) ListenForModelStatePostings(agentID, modelID string, handler func()) {
+	l.subscribe(agentID, modelID, stateChannel, handler)
 }
 
-// Listening for model update postings on the modelling bus
+// Listening for model update postings on the modelling bus.
+// After a reconnect, the listening is re-established automatically.
 func (l *TCDMModelListener) ListenForModelUpdatePostings(agentID, modelID string, handler func()) {
-	// Setting up listening for model update postings
-	l.ModelListener.ListenForJSONArtefactUpdatePostings(agentID, modelID, func() {
-		l.UpdateModelsFromBus()
-		handler()
-	})
+	l.subscribe(agentID, modelID, updateChannel, handler)
 }
 
-// Listening for model considering postings on the modelling bus
+// Listening for model considering postings on the modelling bus.
+// After a reconnect, the listening is re-established automatically.
 func (l *TCDMModelListener) ListenForModelConsideringPostings(agentID, modelID string, handler func()) {
-	// Setting up listening for model considering postings
-	l.ModelListener.ListenForJSONArtefactConsideringPostings(agentID, modelID, func() {
-		l.UpdateModelsFromBus()
-		handler()
-	})
+	l.subscribe(agentID, modelID, consideringChannel, handler)
 }
 
 /*
@@ -142,6 +210,130 @@ func (l *TCDMModelListener) AlternativeReadingsOfRelationType(relationType strin
 	})
 }
 
+// Look up a value across the models, preferring the considered model, then the updated model, and then the current model
+func (l *TCDMModelListener) LookupValue(mp func(TCDMModel) map[string]string, id string) (string, bool) {
+	// Looking in the models, from the considered model back to the current model
+	for _, m := range []TCDMModel{l.ConsideredModel, l.UpdatedModel, l.CurrentModel} {
+		if value, found := mp(m)[id]; found {
+			return value, true
+		}
+	}
+
+	// The value was not found
+	return "", false
+}
+
+func (l *TCDMModelListener) BaseTypeOfInvolvementType(involvementType string) (string, bool) {
+	// Look up the base type of the given involvement type across the models
+	return l.LookupValue(func(m TCDMModel) map[string]string {
+		return m.BaseTypeOfInvolvementType
+	}, involvementType)
+}
+
+func (l *TCDMModelListener) DomainOfQualityType(qualityType string) (string, bool) {
+	// Look up the domain of the given quality type across the models
+	return l.LookupValue(func(m TCDMModel) map[string]string {
+		return m.DomainOfQualityType
+	}, qualityType)
+}
+
+func (l *TCDMModelListener) TypeName(id string) (string, bool) {
+	// Look up the name of the given type across the models
+	return l.LookupValue(func(m TCDMModel) map[string]string {
+		return m.TypeName
+	}, id)
+}
+
+func (l *TCDMModelListener) AllTypeNames() map[string]string {
+	// Start with an empty result
+	result := map[string]string{}
+
+	// Collecting the names from the current model, then the updated model, and then the considered model,
+	// so the most recent names take precedence
+	for _, m := range []TCDMModel{l.CurrentModel, l.UpdatedModel, l.ConsideredModel} {
+		for id, name := range m.TypeName {
+			result[id] = name
+		}
+	}
+
+	// Return the collected result
+	return result
+}
+
+// Uniting the given sets of IDs into the target mapping from IDs to sets of IDs
+func uniteIDSetsInto(target, idSets map[string]map[string]bool) {
+	for id, idSet := range idSets {
+		if target[id] == nil {
+			target[id] = map[string]bool{}
+		}
+		for element, included := range idSet {
+			if included {
+				target[id][element] = true
+			}
+		}
+	}
+}
+
+// Uniting the current, updated, and considered models into one model.
+// The sets are united, while for the other values the most recent model takes precedence.
+func (l *TCDMModelListener) UnitedModel() TCDMModel {
+	// Start with a copy of the current model
+	united := l.CurrentModel.Clone()
+
+	// Adding the updated model, and then the considered model
+	for _, m := range []TCDMModel{l.UpdatedModel, l.ConsideredModel} {
+		if m.ModelName != "" {
+			united.ModelName = m.ModelName
+		}
+
+		// Uniting the sets
+		for _, sets := range [][2]map[string]bool{
+			{united.ConcreteIndividualTypes, m.ConcreteIndividualTypes},
+			{united.QualityTypes, m.QualityTypes},
+			{united.RelationTypes, m.RelationTypes},
+			{united.InvolvementTypes, m.InvolvementTypes},
+			{united.UniquenessConstraints, m.UniquenessConstraints},
+		} {
+			for id, included := range sets[1] {
+				if included {
+					sets[0][id] = true
+				}
+			}
+		}
+
+		// Overriding the values
+		for _, values := range [][2]map[string]string{
+			{united.TypeName, m.TypeName},
+			{united.DomainOfQualityType, m.DomainOfQualityType},
+			{united.BaseTypeOfInvolvementType, m.BaseTypeOfInvolvementType},
+			{united.RelationTypeOfInvolvementType, m.RelationTypeOfInvolvementType},
+			{united.PrimaryReadingOfRelationType, m.PrimaryReadingOfRelationType},
+			{united.RelationTypeOfUniquenessConstraint, m.RelationTypeOfUniquenessConstraint},
+		} {
+			maps.Copy(values[0], values[1])
+		}
+		maps.Copy(united.ReadingDefinition, m.ReadingDefinition)
+
+		// Uniting the sets of IDs
+		uniteIDSetsInto(united.InvolvementTypesOfRelationType, m.InvolvementTypesOfRelationType)
+		uniteIDSetsInto(united.AlternativeReadingsOfRelationType, m.AlternativeReadingsOfRelationType)
+		uniteIDSetsInto(united.SupertypesOfType, m.SupertypesOfType)
+		uniteIDSetsInto(united.InvolvementTypesOfUniquenessConstraint, m.InvolvementTypesOfUniquenessConstraint)
+	}
+
+	// Return the united model
+	return united
+}
+
+/*
+ *  Closing the model listener
+ */
+
+// Closing the model listener, and its connection to the modelling bus
+func (l *TCDMModelListener) Close() {
+	l.ModelListener.Close()
+}
+
 /*
  *  Creating and updating the model listener
  */
@@ -154,6 +346,7 @@ func CreateCDMListener(ModellingBusConnector connect.TModellingBusConnector, rep
 	cdmModelListener.CurrentModel = CreateCDMModel(reporter)
 	cdmModelListener.UpdatedModel = CreateCDMModel(reporter)
 	cdmModelListener.ConsideredModel = CreateCDMModel(reporter)
+	cdmModelListener.subscriptionsMutex = &sync.Mutex{}
 
 	// Return the created CDM model listener
 	return cdmModelListener
@@ -0,0 +1,38 @@
+package cdm_v1_0_v1_0
+
+import (
+	"testing"
+)
+
+func TestLookupsAcrossModelVersions(t *testing.T) {
+	// The current model has the births, the updated one moves "on" to a new type, and the considered one renames the date domain
+	listener := TCDMModelListener{}
+	listener.CurrentModel = createBirthsModel()
+	listener.UpdatedModel = createTestModel()
+	listener.UpdatedModel.BaseTypeOfInvolvementType["id-4"] = "id-8"
+	listener.ConsideredModel = createTestModel()
+	listener.ConsideredModel.DomainOfQualityType["id-2"] = "timestamp"
+
+	tests := []struct {
+		name      string
+		lookup    func(string) (string, bool)
+		id        string
+		wantValue string
+		wantFound bool
+	}{
+		{"base type in the current model", listener.BaseTypeOfInvolvementType, "id-3", "id-1", true},
+		{"base type overridden by the update", listener.BaseTypeOfInvolvementType, "id-4", "id-8", true},
+		{"unknown involvement type", listener.BaseTypeOfInvolvementType, "id-9", "", false},
+		{"domain overridden by the considering", listener.DomainOfQualityType, "id-2", "timestamp", true},
+		{"domain of a non quality type", listener.DomainOfQualityType, "id-1", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotValue, gotFound := test.lookup(test.id)
+			if gotValue != test.wantValue || gotFound != test.wantFound {
+				t.Errorf("lookup(%q) = %q, %v, want %q, %v", test.id, gotValue, gotFound, test.wantValue, test.wantFound)
+			}
+		})
+	}
+}
@@ -10,7 +10,7 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 15.12.2025
+ * Version of: 15.10.2026
  *
  */
 
@@ -36,7 +36,14 @@ type (
 
 // Posting the model's state
 func (p *TCDMModelPoster) PostState(m TCDMModel) {
-	p.modelPoster.PostJSONArtefactState(m.GetModelAsJSON())
+	modelJSON, ok := m.GetModelAsJSON()
+
+	// Only post models that other agents will be able to load
+	if ok && !m.reportJSONProblems(modelJSON) {
+		return
+	}
+
+	p.modelPoster.PostJSONArtefactState(modelJSON, ok)
 }
 
 // Posting the model's update
@@ -49,6 +56,25 @@ func (p *TCDMModelPoster) PostConsidering(m TCDMModel) {
 	p.modelPoster.PostJSONArtefactConsidering(m.GetModelAsJSON())
 }
 
+// Promoting the model's considered update to an actual update
+func (p *TCDMModelPoster) PromoteConsidered() {
+	p.modelPoster.PromoteConsideredToUpdate()
+}
+
+// Withdrawing the model's considered update
+func (p *TCDMModelPoster) WithdrawConsidering() {
+	p.modelPoster.WithdrawConsidering()
+}
+
+/*
+ *  Closing the model poster
+ */
+
+// Closing the model poster, and its connection to the modelling bus
+func (p *TCDMModelPoster) Close() {
+	p.modelPoster.Close()
+}
+
 /*
  *  Creating the model poster
  */
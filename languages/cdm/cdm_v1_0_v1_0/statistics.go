@@ -0,0 +1,81 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Languages/Conceptual Domain Modelling, Version 1
+ * Component: Statistics
+ *
+ * This component provides summary statistics of models expressed in the
+ *    Conceptual Domain Modelling language, Version 1,
+ * such as the number of types and readings, e.g. for use in modelling dashboards.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package cdm_v1_0_v1_0
+
+/*
+ * Defining statistics
+ */
+
+type (
+	TCDMStats struct {
+		ConcreteIndividualTypes int // The number of concrete individual types
+		QualityTypes            int // The number of quality types
+		InvolvementTypes        int // The number of involvement types
+		RelationTypes           int // The number of relation types
+		Readings                int // The number of relation type readings
+
+		AverageReadingsPerRelationType     float64 // The average number of readings per relation type
+		RelationTypesWithoutPrimaryReading int     // The number of relation types lacking a primary reading
+	}
+)
+
+/*
+ * Computing statistics
+ */
+
+// Counting the IDs that are included in a set of IDs
+func countIDs(idSet map[string]bool) int {
+	count := 0
+	for _, included := range idSet {
+		if included {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Computing the summary statistics of a CDM model
+func (m TCDMModel) Stats() TCDMStats {
+	stats := TCDMStats{}
+
+	// Counting the types and readings
+	stats.ConcreteIndividualTypes = countIDs(m.ConcreteIndividualTypes)
+	stats.QualityTypes = countIDs(m.QualityTypes)
+	stats.InvolvementTypes = countIDs(m.InvolvementTypes)
+	stats.RelationTypes = countIDs(m.RelationTypes)
+	stats.Readings = len(m.ReadingDefinition)
+
+	// Deriving the averages, for models that have relation types
+	if stats.RelationTypes > 0 {
+		stats.AverageReadingsPerRelationType = float64(stats.Readings) / float64(stats.RelationTypes)
+	}
+
+	// Counting the relation types lacking a primary reading
+	for relationType, included := range m.RelationTypes {
+		if included && m.PrimaryReadingOfRelationType[relationType] == "" {
+			stats.RelationTypesWithoutPrimaryReading++
+		}
+	}
+
+	return stats
+}
+
+// Computing the summary statistics of the model united across the current, updated, and considered models
+func (l *TCDMModelListener) Stats() TCDMStats {
+	return l.UnitedModel().Stats()
+}
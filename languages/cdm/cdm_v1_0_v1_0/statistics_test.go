@@ -0,0 +1,71 @@
+package cdm_v1_0_v1_0
+
+import (
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     func() TCDMModel
+		wantStats TCDMStats
+	}{
+		{"empty model", createTestModel, TCDMStats{}},
+		{"births", createBirthsModel, TCDMStats{1, 1, 2, 1, 1, 1, 0}},
+		{
+			"alternative reading",
+			func() TCDMModel {
+				m := createBirthsModel()
+				m.AddRelationTypeReading("id-5", "", "id-4", "is birth date of", "id-3", "")
+
+				return m
+			},
+			TCDMStats{1, 1, 2, 1, 2, 2, 0},
+		},
+		{
+			"relation type without readings",
+			func() TCDMModel {
+				m := createBirthsModel()
+				m.AddRelationType("Death", m.AddInvolvementType("died", "id-1"), m.AddInvolvementType("on", "id-2"))
+
+				return m
+			},
+			TCDMStats{1, 1, 4, 2, 1, 0.5, 1},
+		},
+		{
+			"excluded types",
+			func() TCDMModel {
+				m := createBirthsModel()
+				m.ConcreteIndividualTypes["id-1"] = false
+				m.RelationTypes["id-9"] = false
+
+				return m
+			},
+			TCDMStats{0, 1, 2, 1, 1, 1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if gotStats := test.model().Stats(); gotStats != test.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", gotStats, test.wantStats)
+			}
+		})
+	}
+}
+
+func TestListenerStats(t *testing.T) {
+	// The update adds a relation type without readings, while the considering adds another concrete individual type
+	listener := TCDMModelListener{}
+	listener.CurrentModel = createBirthsModel()
+	listener.UpdatedModel = createBirthsModel()
+	listener.UpdatedModel.AddRelationType("Death", listener.UpdatedModel.AddInvolvementType("died", "id-1"))
+	listener.ConsideredModel = createTestModel()
+	listener.ConsideredModel.newID = func() string { return "id-10" }
+	listener.ConsideredModel.AddConcreteIndividualType("Hospital")
+
+	wantStats := TCDMStats{2, 1, 3, 2, 1, 0.5, 1}
+	if gotStats := listener.Stats(); gotStats != wantStats {
+		t.Errorf("Stats() = %+v, want %+v", gotStats, wantStats)
+	}
+}
@@ -0,0 +1,544 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Languages/Conceptual Domain Modelling, Version 1
+ * Component: Text
+ *
+ * This component provides a human-readable textual format for models expressed in the
+ *    Conceptual Domain Modelling language, Version 1,
+ * in which relation types are shown by way of their verbalised readings, e.g. for review purposes.
+ *
+ * The format is line based, where each element is followed by its ID:
+ *
+ *    model Hello World
+ *    concrete individual type Person #1
+ *    concrete individual type Name #2
+ *    quality type Age #3 with domain integer
+ *    relation type naming #4
+ *        involvement type owner #5 of Person #1
+ *        involvement type owned #6 of Name #2
+ *        reading #7: [owner] has [owned]
+ *        uniqueness constraint #8 on [owner]
+ *    subtype Student #9 of Person #1
+ *
+ * The first reading of a relation type is its primary reading. In readings, and uniqueness constraints,
+ * involvement types are referred to by their name between square brackets. They should therefore be declared
+ * before the readings and constraints of their relation type, and have distinct names within it.
+ * Models can also be read from this format, keeping the IDs of their elements.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package cdm_v1_0_v1_0
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining errors
+ */
+
+// The error when a CDM text cannot be parsed
+var ErrCDMTextSyntax = errors.New("syntax error in CDM text")
+
+/*
+ * Defining the textual format
+ */
+
+var (
+	modelLinePattern                  = regexp.MustCompile(`^model (.*)$`)
+	concreteIndividualTypeLinePattern = regexp.MustCompile(`^concrete individual type (.*) #(\S+)$`)
+	qualityTypeLinePattern            = regexp.MustCompile(`^quality type (.*) #(\S+)(?: with domain (.*))?$`)
+	relationTypeLinePattern           = regexp.MustCompile(`^relation type (.*) #(\S+)$`)
+	involvementTypeLinePattern        = regexp.MustCompile(`^involvement type (.*) #(\S+) of (.*) #(\S*)$`)
+	readingLinePattern                = regexp.MustCompile(`^reading #(\S+):\s*(.*)$`)
+	uniquenessConstraintLinePattern   = regexp.MustCompile(`^uniqueness constraint #(\S+) on (.*)$`)
+	subtypeLinePattern                = regexp.MustCompile(`^subtype (.*) #(\S+) of (.*) #(\S+)$`)
+)
+
+/*
+ * Verbalising models
+ */
+
+// Get the IDs in a set of IDs, ordered by their names and then by the IDs themselves
+func (m TCDMModel) sortedIDs(idSet map[string]bool) []string {
+	ids := []string{}
+	for id, included := range idSet {
+		if included {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.SortFunc(ids, func(id1, id2 string) int {
+		return cmp.Or(cmp.Compare(m.TypeName[id1], m.TypeName[id2]), cmp.Compare(id1, id2))
+	})
+
+	return ids
+}
+
+// Verbalising a relation type reading, interleaving its reading elements with the (bracketed) names of its involvement types
+func (m TCDMModel) verbaliseReading(reading TRelationReading) string {
+	parts := []string{}
+	for position, element := range reading.ReadingElements {
+		if element = strings.TrimSpace(element); element != "" {
+			parts = append(parts, element)
+		}
+
+		if position < len(reading.InvolvementTypes) {
+			parts = append(parts, "["+m.TypeName[reading.InvolvementTypes[position]]+"]")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Get the readings of a relation type, starting with its primary reading
+func (m TCDMModel) readingsOf(relationType string) []string {
+	primaryReading := m.PrimaryReadingOfRelationType[relationType]
+
+	readings := []string{}
+	if _, defined := m.ReadingDefinition[primaryReading]; defined {
+		readings = append(readings, primaryReading)
+	}
+	for _, reading := range slices.Sorted(maps.Keys(m.AlternativeReadingsOfRelationType[relationType])) {
+		if reading != primaryReading && m.AlternativeReadingsOfRelationType[relationType][reading] {
+			readings = append(readings, reading)
+		}
+	}
+
+	return readings
+}
+
+// Writing a relation type, including its involvement types, readings, and uniqueness constraints
+func (m TCDMModel) writeRelationType(text *strings.Builder, relationType string) {
+	fmt.Fprintf(text, "relation type %s #%s\n", m.TypeName[relationType], relationType)
+
+	// The involvement types
+	for _, involvementType := range m.sortedIDs(m.InvolvementTypesOfRelationType[relationType]) {
+		baseType := m.BaseTypeOfInvolvementType[involvementType]
+		fmt.Fprintf(text, "    involvement type %s #%s of %s #%s\n", m.TypeName[involvementType], involvementType, m.TypeName[baseType], baseType)
+	}
+
+	// The readings
+	for _, reading := range m.readingsOf(relationType) {
+		fmt.Fprintf(text, "    reading #%s: %s\n", reading, m.verbaliseReading(m.ReadingDefinition[reading]))
+	}
+
+	// The uniqueness constraints
+	for _, constraint := range slices.Sorted(maps.Keys(m.UniquenessConstraints)) {
+		if m.UniquenessConstraints[constraint] && m.RelationTypeOfUniquenessConstraint[constraint] == relationType {
+			involvementTypes := []string{}
+			for _, involvementType := range m.sortedIDs(m.InvolvementTypesOfUniquenessConstraint[constraint]) {
+				involvementTypes = append(involvementTypes, "["+m.TypeName[involvementType]+"]")
+			}
+			fmt.Fprintf(text, "    uniqueness constraint #%s on %s\n", constraint, strings.Join(involvementTypes, " "))
+		}
+	}
+}
+
+/*
+ * Parsing models
+ */
+
+type (
+	// A declaration in the CDM text that refers to involvement types by name
+	tCDMTextDeclaration struct {
+		id               string   // The ID of the declared element
+		elements         []string // The reading elements (for readings)
+		involvementTypes []string // The involvement types referred to
+		line             int      // The line of the declaration
+	}
+
+	// A relation type in the CDM text, with its involvement types, readings, and uniqueness constraints
+	tCDMTextRelationType struct {
+		name                  string                // The name of the relation type
+		id                    string                // The ID of the relation type
+		involvementTypes      []string              // The IDs of the involvement types
+		involvementTypeNames  []string              // The names of the involvement types
+		involvementBaseTypes  []string              // The base types of the involvement types
+		readings              []tCDMTextDeclaration // The readings
+		uniquenessConstraints []tCDMTextDeclaration // The uniqueness constraints
+		line                  int                   // The line of the relation type
+	}
+
+	// A subtyping in the CDM text
+	tCDMTextSubtyping struct {
+		subtype   string // The ID of the subtype
+		supertype string // The ID of the supertype
+		line      int    // The line of the subtyping
+	}
+
+	// A parser of the CDM text
+	tCDMTextParser struct {
+		model        *TCDMModel            // The model being parsed
+		nextID       string                // The ID to be used for the next element added to the model
+		usedIDs      map[string]bool       // The IDs declared so far
+		relationType *tCDMTextRelationType // The relation type being parsed (if any)
+		subtypings   []tCDMTextSubtyping   // The subtypings, to be added once all types are known
+		line         int                   // The line being parsed
+	}
+)
+
+// Creating a syntax error at the given line and column
+func cdmTextError(line, column int, message string, context ...any) error {
+	return fmt.Errorf("%w at line %d, column %d: %s", ErrCDMTextSyntax, line, column, fmt.Sprintf(message, context...))
+}
+
+// Claiming an ID for the next element to be added to the model, making sure it is declared only once
+func (p *tCDMTextParser) claimID(id string) error {
+	if p.usedIDs[id] {
+		return cdmTextError(p.line, 1, "the ID %s is declared more than once", id)
+	}
+	p.usedIDs[id] = true
+	p.nextID = id
+
+	return nil
+}
+
+// Splitting text, starting at the given line and column, into the strings and the (bracketed) names it interleaves,
+// with the columns of the names
+func splitBracketedNames(text string, line, column int) ([]string, []string, []int, error) {
+	elements := []string{}
+	names := []string{}
+	columns := []int{}
+
+	for {
+		// Find the next bracketed name
+		open := strings.Index(text, "[")
+		if open < 0 {
+			elements = append(elements, strings.TrimSpace(text))
+			return elements, names, columns, nil
+		}
+		end := strings.Index(text[open:], "]")
+		if end < 0 {
+			return nil, nil, nil, cdmTextError(line, column+open, "missing ] after [")
+		}
+
+		// Add the string before, and the name itself
+		elements = append(elements, strings.TrimSpace(text[:open]))
+		names = append(names, text[open+1:open+end])
+		columns = append(columns, column+open)
+
+		// Continue after the name
+		text = text[open+end+1:]
+		column += open + end + 1
+	}
+}
+
+// Resolving the names of involvement types of the relation type being parsed to their IDs
+func (p *tCDMTextParser) resolveInvolvementTypes(names []string, columns []int) ([]string, error) {
+	ids := []string{}
+	for position, name := range names {
+		id := ""
+		for index, involvementTypeName := range p.relationType.involvementTypeNames {
+			if involvementTypeName == name {
+				if id != "" {
+					return nil, cdmTextError(p.line, columns[position], "involvement type [%s] is ambiguous", name)
+				}
+				id = p.relationType.involvementTypes[index]
+			}
+		}
+
+		if id == "" {
+			return nil, cdmTextError(p.line, columns[position], "unknown involvement type [%s]", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Parsing text, starting at the given column, that refers to involvement types of the relation type being parsed
+func (p *tCDMTextParser) parseDeclaration(id, text string, column int) (tCDMTextDeclaration, error) {
+	declaration := tCDMTextDeclaration{}
+	declaration.id = id
+	declaration.line = p.line
+
+	// Splitting the text
+	elements, names, columns, err := splitBracketedNames(text, p.line, column)
+	if err != nil {
+		return declaration, err
+	}
+
+	// Resolving the involvement types
+	declaration.elements = elements
+	declaration.involvementTypes, err = p.resolveInvolvementTypes(names, columns)
+
+	return declaration, err
+}
+
+// Adding the relation type being parsed (if any) to the model
+func (p *tCDMTextParser) addRelationType() error {
+	relationType := p.relationType
+	if relationType == nil {
+		return nil
+	}
+	p.relationType = nil
+
+	// Adding the involvement types
+	for index, involvementType := range relationType.involvementTypes {
+		p.nextID = involvementType
+		p.model.AddInvolvementType(relationType.involvementTypeNames[index], relationType.involvementBaseTypes[index])
+	}
+
+	// Adding the relation type
+	p.nextID = relationType.id
+	p.model.AddRelationType(relationType.name, relationType.involvementTypes...)
+
+	// Adding the readings, where the first one becomes the primary reading
+	for _, reading := range relationType.readings {
+		stringsAndInvolvementTypes := []string{}
+		for position, element := range reading.elements {
+			stringsAndInvolvementTypes = append(stringsAndInvolvementTypes, element)
+			if position < len(reading.involvementTypes) {
+				stringsAndInvolvementTypes = append(stringsAndInvolvementTypes, reading.involvementTypes[position])
+			}
+		}
+
+		p.nextID = reading.id
+		p.model.AddRelationTypeReading(relationType.id, stringsAndInvolvementTypes...)
+	}
+
+	// Adding the uniqueness constraints
+	for _, constraint := range relationType.uniquenessConstraints {
+		p.nextID = constraint.id
+		if p.model.AddUniquenessConstraint(relationType.id, constraint.involvementTypes...) == "" {
+			return cdmTextError(constraint.line, 1, "cannot add uniqueness constraint #%s", constraint.id)
+		}
+	}
+
+	return nil
+}
+
+// Parsing a line that is part of a relation type
+func (p *tCDMTextParser) parseRelationTypeLine(line string, column int) error {
+	switch {
+	case involvementTypeLinePattern.MatchString(line):
+		match := involvementTypeLinePattern.FindStringSubmatch(line)
+		if err := p.claimID(match[2]); err != nil {
+			return err
+		}
+		p.relationType.involvementTypeNames = append(p.relationType.involvementTypeNames, match[1])
+		p.relationType.involvementTypes = append(p.relationType.involvementTypes, match[2])
+		p.relationType.involvementBaseTypes = append(p.relationType.involvementBaseTypes, match[4])
+
+	case readingLinePattern.MatchString(line):
+		match := readingLinePattern.FindStringSubmatchIndex(line)
+		id := line[match[2]:match[3]]
+		if err := p.claimID(id); err != nil {
+			return err
+		}
+		reading, err := p.parseDeclaration(id, line[match[4]:match[5]], column+match[4])
+		if err != nil {
+			return err
+		}
+		if len(reading.involvementTypes) == 0 {
+			return cdmTextError(p.line, column, "reading #%s does not refer to any involvement type", id)
+		}
+		p.relationType.readings = append(p.relationType.readings, reading)
+
+	case uniquenessConstraintLinePattern.MatchString(line):
+		match := uniquenessConstraintLinePattern.FindStringSubmatchIndex(line)
+		id := line[match[2]:match[3]]
+		if err := p.claimID(id); err != nil {
+			return err
+		}
+		constraint, err := p.parseDeclaration(id, line[match[4]:match[5]], column+match[4])
+		if err != nil {
+			return err
+		}
+		p.relationType.uniquenessConstraints = append(p.relationType.uniquenessConstraints, constraint)
+
+	default:
+		return cdmTextError(p.line, column, "expected an involvement type, reading, or uniqueness constraint")
+	}
+
+	return nil
+}
+
+// Parsing a line that is not part of a relation type
+func (p *tCDMTextParser) parseLine(line string, column int) error {
+	switch {
+	case concreteIndividualTypeLinePattern.MatchString(line):
+		match := concreteIndividualTypeLinePattern.FindStringSubmatch(line)
+		if err := p.claimID(match[2]); err != nil {
+			return err
+		}
+		p.model.AddConcreteIndividualType(match[1])
+
+	case qualityTypeLinePattern.MatchString(line):
+		match := qualityTypeLinePattern.FindStringSubmatch(line)
+		if err := p.claimID(match[2]); err != nil {
+			return err
+		}
+		p.model.AddQualityType(match[1], match[3])
+
+	case relationTypeLinePattern.MatchString(line):
+		match := relationTypeLinePattern.FindStringSubmatch(line)
+		if err := p.claimID(match[2]); err != nil {
+			return err
+		}
+		p.relationType = &tCDMTextRelationType{name: match[1], id: match[2], line: p.line}
+
+	case involvementTypeLinePattern.MatchString(line):
+		match := involvementTypeLinePattern.FindStringSubmatch(line)
+		if err := p.claimID(match[2]); err != nil {
+			return err
+		}
+		p.model.AddInvolvementType(match[1], match[4])
+
+	case subtypeLinePattern.MatchString(line):
+		match := subtypeLinePattern.FindStringSubmatch(line)
+		p.subtypings = append(p.subtypings, tCDMTextSubtyping{subtype: match[2], supertype: match[4], line: p.line})
+
+	case modelLinePattern.MatchString(line):
+		p.model.SetModelName(modelLinePattern.FindStringSubmatch(line)[1])
+
+	default:
+		return cdmTextError(p.line, column, "expected a model name, type, or subtyping")
+	}
+
+	return nil
+}
+
+// Parsing the CDM text
+func (p *tCDMTextParser) parse(src string) error {
+	for index, line := range strings.Split(src, "\n") {
+		p.line = index + 1
+
+		// Skip empty lines
+		line = strings.TrimRight(line, " \t\r")
+		trimmedLine := strings.TrimLeft(line, " \t")
+		if trimmedLine == "" {
+			continue
+		}
+		column := len(line) - len(trimmedLine) + 1
+
+		// Indented lines are part of the relation type being parsed
+		if column > 1 {
+			if p.relationType == nil {
+				return cdmTextError(p.line, column, "indented line outside of a relation type")
+			}
+			if err := p.parseRelationTypeLine(trimmedLine, column); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Other lines end the relation type being parsed
+		if err := p.addRelationType(); err != nil {
+			return err
+		}
+		if err := p.parseLine(trimmedLine, column); err != nil {
+			return err
+		}
+	}
+
+	// Adding the last relation type
+	if err := p.addRelationType(); err != nil {
+		return err
+	}
+
+	// Adding the subtypings, now all types are known
+	for _, subtyping := range p.subtypings {
+		if !p.model.AddSubtyping(subtyping.subtype, subtyping.supertype) {
+			return cdmTextError(subtyping.line, 1, "cannot add subtyping of #%s to #%s", subtyping.subtype, subtyping.supertype)
+		}
+	}
+
+	return nil
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Rendering the model in the textual format, listing its types, and its relation types by way of their verbalised readings
+func (m TCDMModel) ToText() string {
+	text := strings.Builder{}
+
+	// The model name
+	if m.ModelName != "" {
+		fmt.Fprintf(&text, "model %s\n", m.ModelName)
+	}
+
+	// The concrete individual types
+	for _, concreteIndividualType := range m.sortedIDs(m.ConcreteIndividualTypes) {
+		fmt.Fprintf(&text, "concrete individual type %s #%s\n", m.TypeName[concreteIndividualType], concreteIndividualType)
+	}
+
+	// The quality types, with their domains
+	for _, qualityType := range m.sortedIDs(m.QualityTypes) {
+		fmt.Fprintf(&text, "quality type %s #%s", m.TypeName[qualityType], qualityType)
+		if domain := m.DomainOfQualityType[qualityType]; domain != "" {
+			fmt.Fprintf(&text, " with domain %s", domain)
+		}
+		text.WriteString("\n")
+	}
+
+	// The relation types
+	for _, relationType := range m.sortedIDs(m.RelationTypes) {
+		m.writeRelationType(&text, relationType)
+	}
+
+	// The involvement types that are not part of a relation type
+	for _, involvementType := range m.sortedIDs(m.InvolvementTypes) {
+		if !m.InvolvementTypesOfRelationType[m.RelationTypeOfInvolvementType[involvementType]][involvementType] {
+			baseType := m.BaseTypeOfInvolvementType[involvementType]
+			fmt.Fprintf(&text, "involvement type %s #%s of %s #%s\n", m.TypeName[involvementType], involvementType, m.TypeName[baseType], baseType)
+		}
+	}
+
+	// The subtypings
+	subtypes := map[string]bool{}
+	for subtype := range m.SupertypesOfType {
+		subtypes[subtype] = true
+	}
+	for _, subtype := range m.sortedIDs(subtypes) {
+		for _, supertype := range m.sortedIDs(m.SupertypesOfType[subtype]) {
+			fmt.Fprintf(&text, "subtype %s #%s of %s #%s\n", m.TypeName[subtype], subtype, m.TypeName[supertype], supertype)
+		}
+	}
+
+	return text.String()
+}
+
+// Creating a CDM model from the textual format (as produced by ToText), keeping the IDs of its elements.
+// Syntax errors are reported, and returned, with their line and column.
+func ParseCDMText(src string, reporter *generics.TReporter) (TCDMModel, error) {
+	// Create an empty CDM model
+	model := CreateCDMModel(reporter)
+
+	// Setting up the parser, which provides the IDs for the elements added to the model
+	parser := tCDMTextParser{}
+	parser.model = &model
+	parser.usedIDs = map[string]bool{}
+	model.newID = func() string {
+		return parser.nextID
+	}
+
+	// Parsing the text
+	err := parser.parse(src)
+	model.newID = nil
+	if err != nil {
+		reporter.ReportError("Cannot parse the CDM text:", err)
+		return model, err
+	}
+
+	// Return the parsed model
+	return model, nil
+}
@@ -0,0 +1,126 @@
+package cdm_v1_0_v1_0
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// Whether to update the golden files with the actual texts, rather than comparing against them
+var updateGoldenFiles = flag.Bool("update", false, "update the golden files in testdata")
+
+// Create the births model, extended with a subtype, an alternative reading, a quality type without a domain, and an
+// involvement type that is not part of a relation type
+func createExtendedBirthsModel() TCDMModel {
+	m := createBirthsModel()
+
+	m.AddSubtyping(m.AddConcreteIndividualType("Student"), "id-1")
+	m.AddRelationTypeReading("id-5", "", "id-4", "is birth date of", "id-3", "")
+	m.AddQualityType("Name", "")
+	m.AddInvolvementType("named", "id-1")
+
+	return m
+}
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name  string
+		model func() TCDMModel
+	}{
+		{"empty", createTestModel},
+		{"births", createBirthsModel},
+		{"extended_births", createExtendedBirthsModel},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			goldenFilePath := filepath.Join("testdata", test.name+".txt")
+			gotText := test.model().ToText()
+
+			if *updateGoldenFiles {
+				if err := os.WriteFile(goldenFilePath, []byte(gotText), 0o644); err != nil {
+					t.Fatalf("writing the golden file: %v", err)
+				}
+			}
+
+			wantText, err := os.ReadFile(goldenFilePath)
+			if err != nil {
+				t.Fatalf("reading the golden file: %v", err)
+			}
+			if gotText != string(wantText) {
+				t.Errorf("ToText() =\n%s\nwant\n%s", gotText, wantText)
+			}
+		})
+	}
+}
+
+func TestParseCDMTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		model func() TCDMModel
+	}{
+		{"empty", createTestModel},
+		{"births", createBirthsModel},
+		{"extended births", createExtendedBirthsModel},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := test.model()
+
+			parsedModel, err := ParseCDMText(model.ToText(), generics.CreateReporter(0, func(string) {}, func(string) {}))
+			if err != nil {
+				t.Fatalf("ParseCDMText(): %v", err)
+			}
+			if !parsedModel.Equal(model) {
+				t.Errorf("ParseCDMText() gave a different model:\n%s\nwant\n%s", parsedModel.ToText(), model.ToText())
+			}
+		})
+	}
+}
+
+func TestParseCDMTextSyntaxErrors(t *testing.T) {
+	relationType := "concrete individual type Person #p\nrelation type Naming #r\n    involvement type owner #o of Person #p\n"
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"unknown line", "model Births\nperson #p", "at line 2, column 1: expected a model name, type, or subtyping"},
+		{"indented line outside of a relation type", "   reading #x: [a]", "at line 1, column 4: indented line outside of a relation type"},
+		{"unknown relation type line", relationType + "    constraint #c", "at line 4, column 5: expected an involvement type, reading, or uniqueness constraint"},
+		{"duplicate ID", "concrete individual type Person #p\nquality type Name #p", "at line 2, column 1: the ID p is declared more than once"},
+		{"unknown involvement type", relationType + "    reading #x: [owner] has [owned]", "at line 4, column 29: unknown involvement type [owned]"},
+		{"missing bracket", relationType + "    reading #x: [owner has", "at line 4, column 17: missing ] after ["},
+		{"reading without involvement types", relationType + "    reading #x: has", "at line 4, column 5: reading #x does not refer to any involvement type"},
+		{
+			"ambiguous involvement type",
+			relationType + "    involvement type owner #o2 of Person #p\n    uniqueness constraint #u on [owner]",
+			"at line 5, column 33: involvement type [owner] is ambiguous",
+		},
+		{"subtyping of an unknown type", "concrete individual type Person #p\nsubtype Student #s of Person #p", "at line 2, column 1: cannot add subtyping of #s to #p"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reported := []string{}
+			reporter := generics.CreateReporter(0, func(message string) { reported = append(reported, message) }, func(string) {})
+
+			_, err := ParseCDMText(test.src, reporter)
+			if !errors.Is(err, ErrCDMTextSyntax) {
+				t.Fatalf("ParseCDMText() = %v, want a syntax error", err)
+			}
+			if wantErr := ErrCDMTextSyntax.Error() + " " + test.wantErr; err.Error() != wantErr {
+				t.Errorf("ParseCDMText() = %q, want %q", err, wantErr)
+			}
+			if len(reported) == 0 {
+				t.Errorf("ParseCDMText() did not report the syntax error")
+			}
+		})
+	}
+}
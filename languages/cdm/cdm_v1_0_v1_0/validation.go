@@ -0,0 +1,139 @@
+/*
+ *
+ * Module:    BIG Modelling Bus, Version 1
+ * Package:   Languages/Conceptual Domain Modelling, Version 1
+ * Component: Validation
+ *
+ * This component provides a structural validation of the JSON representation
+ * of models expressed in the
+ *    Conceptual Domain Modelling language, Version 1,
+ * to catch version drift between the producers of such models.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 15.10.2026
+ *
+ */
+
+package cdm_v1_0_v1_0
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+/*
+ * Defining the expected JSON structure
+ */
+
+type (
+	// The kind of values a field of the JSON structure should hold
+	tCDMFieldKind int
+
+	// The definition of a field of the JSON structure
+	tCDMField struct {
+		kind     tCDMFieldKind // The kind of value the field should hold
+		required bool          // Whether the field must be present
+	}
+)
+
+const (
+	cdmString     tCDMFieldKind = iota // A string
+	cdmIDSet                           // A set of IDs, i.e. a map from IDs to booleans
+	cdmIDStrings                       // A map from IDs to strings
+	cdmIDSets                          // A map from IDs to sets of IDs
+	cdmIDReadings                      // A map from IDs to relation type readings
+)
+
+// The fields of the JSON structure of ModelJSONVersion.
+// Fields that were added later on are optional, so that older postings remain valid.
+var cdmFields = map[string]tCDMField{
+	"model name":                                  {cdmString, true},
+	"type names":                                  {cdmIDStrings, true},
+	"concrete individual types":                   {cdmIDSet, true},
+	"quality types":                               {cdmIDSet, true},
+	"domains of quality types":                    {cdmIDStrings, true},
+	"involvement types":                           {cdmIDSet, true},
+	"base types of involvement types":             {cdmIDStrings, true},
+	"relation types of involvement types":         {cdmIDStrings, true},
+	"relation types":                              {cdmIDSet, true},
+	"involvement types of relation types":         {cdmIDSets, true},
+	"alternative readings of relation types":      {cdmIDSets, true},
+	"primary readings of relation types":          {cdmIDStrings, true},
+	"reading definition":                          {cdmIDReadings, true},
+	"supertypes of types":                         {cdmIDSets, false},
+	"uniqueness constraints":                      {cdmIDSet, false},
+	"relation types of uniqueness constraints":    {cdmIDStrings, false},
+	"involvement types of uniqueness constraints": {cdmIDSets, false},
+}
+
+// The names of the kinds of values, as used in problem descriptions
+var cdmFieldKindNames = map[tCDMFieldKind]string{
+	cdmString:     "a string",
+	cdmIDSet:      "a set of IDs",
+	cdmIDStrings:  "a map from IDs to strings",
+	cdmIDSets:     "a map from IDs to sets of IDs",
+	cdmIDReadings: "a map from IDs to readings",
+}
+
+/*
+ * Validating the JSON structure
+ */
+
+// Checking whether a JSON value holds the given kind of value
+func cdmValueHasKind(value json.RawMessage, kind tCDMFieldKind) bool {
+	var target any
+
+	// Select the Go type that corresponds to the kind of value
+	switch kind {
+	case cdmString:
+		target = new(string)
+	case cdmIDSet:
+		target = new(map[string]bool)
+	case cdmIDStrings:
+		target = new(map[string]string)
+	case cdmIDSets:
+		target = new(map[string]map[string]bool)
+	case cdmIDReadings:
+		target = new(map[string]TRelationReading)
+	}
+
+	return json.Unmarshal(value, target) == nil
+}
+
+// Validating that the JSON representation of a model conforms to ModelJSONVersion.
+// Returns the problems found, which is empty when the JSON is valid.
+func ValidateCDMJSON(data []byte) []string {
+	problems := []string{}
+
+	// The model should be a JSON object
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return append(problems, fmt.Sprintf("The model is not a JSON object: %s", err))
+	}
+
+	// Check the expected fields, in a stable order
+	for _, name := range slices.Sorted(maps.Keys(cdmFields)) {
+		field := cdmFields[name]
+		value, present := fields[name]
+
+		switch {
+		case !present && field.required:
+			problems = append(problems, fmt.Sprintf("Field \"%s\" is missing.", name))
+
+		case present && !cdmValueHasKind(value, field.kind):
+			problems = append(problems, fmt.Sprintf("Field \"%s\" should be %s.", name, cdmFieldKindNames[field.kind]))
+		}
+	}
+
+	// Fields we do not know about signal a different version
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if _, known := cdmFields[name]; !known {
+			problems = append(problems, fmt.Sprintf("Field \"%s\" is not part of %s.", name, ModelJSONVersion))
+		}
+	}
+
+	return problems
+}